@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -17,7 +20,90 @@ type ProviderConfig struct {
 	OpenRouter struct {
 		Model string `json:"model"`
 	} `json:"openrouter"`
+	Languages                 []LanguageConfig `json:"languages"`
 	ProblemGenerationStrategy string
+	Sandbox                   SandboxConfig
+	Execution                 ExecutionConfig
+}
+
+// LanguageConfig describes how to compile and run programs in one language.
+// Source file names and commands may use the {source}, {entry} and {memory_mb}
+// placeholders, and {<option>} for each entry of Options.
+type LanguageConfig struct {
+	ID                    string                    `json:"id"`
+	Name                  string                    `json:"name"`
+	SourceFile            string                    `json:"source_file"`
+	EntryPattern          string                    `json:"entry_pattern,omitempty"` // Regex whose first group names the entry point, e.g. the Java class
+	DefaultEntry          string                    `json:"default_entry,omitempty"`
+	RequiredSnippet       string                    `json:"required_snippet,omitempty"`
+	Compile               []string                  `json:"compile,omitempty"`
+	CompileTimeoutSeconds int                       `json:"compile_timeout_seconds,omitempty"`
+	Run                   []string                  `json:"run"`
+	TimeMultiplier        float64                   `json:"time_multiplier,omitempty"`
+	Judge0ID              int                       `json:"judge0_id,omitempty"` // Language id on a Judge0 server
+	ManagedMemory         bool                      `json:"managed_memory,omitempty"`   // Runtime reserves large virtual memory; skip the address space cap
+	AddressSpaceMB        int                       `json:"address_space_mb,omitempty"` // Fixed address space cap for a managed runtime that tolerates one
+	Version               []string                  `json:"version,omitempty"`
+	Diagnostics           string                    `json:"diagnostics,omitempty"` // Compiler output format: gcc, javac, go, rustc or tsc
+	Env                   map[string]string         `json:"env,omitempty"`
+	Options               map[string]LanguageOption `json:"options,omitempty"` // e.g. "standard", "optimization", "version"
+	Debug                 *LanguageDebugConfig      `json:"debug,omitempty"`   // Sanitizer build used by the "debug" mode
+}
+
+// LanguageDebugConfig describes the instrumented build of the "debug" mode
+type LanguageDebugConfig struct {
+	CompileFlags []string          `json:"compile_flags"` // Appended to the compile command
+	Env          map[string]string `json:"env,omitempty"`
+}
+
+// LanguageOption is a toolchain setting users can choose per execution
+type LanguageOption struct {
+	Default string                `json:"default"`
+	Values  []LanguageOptionValue `json:"values"`
+}
+
+// LanguageOptionValue is one choice of a LanguageOption. A command argument
+// that is exactly {<option>} is replaced by Args (which may be empty); Path is
+// prepended to PATH, e.g. to select a JDK. Path is a list of candidate
+// directories separated by ":", which may use environment variables and
+// globs; the first one found at startup is used, and replaces {path} in Probe.
+type LanguageOptionValue struct {
+	ID    string   `json:"id"`
+	Args  []string `json:"args,omitempty"`
+	Path  string   `json:"path,omitempty"`
+	Probe []string `json:"probe,omitempty"` // Must succeed at startup for the value to be offered
+}
+
+// ExecutionConfig holds judging limits for user code
+type ExecutionConfig struct {
+	MemoryLimitMB     int
+	OutputLimitMB     int
+	Workers           int
+	MaxQueueDepth     int
+	CheckerCacheDir   string
+	CompileCacheDir   string
+	CompileCacheMaxMB int
+	TestCacheDir      string
+	TestCacheMaxMB    int
+	// ArtifactDir holds the full test case files of executions, kept for
+	// ArtifactRetentionHours, or SubmissionArtifactRetentionDays for submissions
+	ArtifactDir                     string
+	ArtifactRetentionHours          int
+	SubmissionArtifactRetentionDays int
+	// Executor runs user code: "local" in the sandbox on this host, or
+	// "judge0" on the Judge0-compatible server at Judge0URL
+	Executor        string
+	Judge0URL       string
+	Judge0AuthToken string
+}
+
+// SandboxConfig holds isolation settings for user code execution
+type SandboxConfig struct {
+	Enabled bool
+	Root    string
+	// ReadOnlyPaths are extra host paths visible to sandboxed programs, e.g.
+	// toolchains installed outside /usr
+	ReadOnlyPaths []string
 }
 
 var Config ProviderConfig
@@ -56,5 +142,145 @@ func LoadConfig() error {
 	
 	Config.ProblemGenerationStrategy = strategy
 
+	loadSandboxConfig()
+	loadExecutionConfig()
+
 	return nil
 }
+
+// loadSandboxConfig loads sandbox settings from env
+func loadSandboxConfig() {
+	// Sandboxing is on unless explicitly disabled (e.g. local development on macOS)
+	Config.Sandbox.Enabled = strings.ToLower(os.Getenv("SANDBOX_ENABLED")) != "false"
+
+	root := os.Getenv("SANDBOX_ROOT")
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "simulate-interview-sandbox")
+	}
+	Config.Sandbox.Root = root
+
+	Config.Sandbox.ReadOnlyPaths = filepath.SplitList(os.Getenv("SANDBOX_READONLY_PATHS"))
+
+	if !Config.Sandbox.Enabled {
+		log.Println("SANDBOX_ENABLED=false, user code will run without isolation")
+	}
+}
+
+// loadExecutionConfig loads judging limits from env
+func loadExecutionConfig() {
+	Config.Execution.MemoryLimitMB = 256
+	if value := os.Getenv("EXECUTION_MEMORY_LIMIT_MB"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 16 {
+			log.Printf("Invalid EXECUTION_MEMORY_LIMIT_MB '%s', defaulting to 256", value)
+		} else {
+			Config.Execution.MemoryLimitMB = limit
+		}
+	}
+
+	Config.Execution.OutputLimitMB = 64
+	if value := os.Getenv("EXECUTION_OUTPUT_LIMIT_MB"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			log.Printf("Invalid EXECUTION_OUTPUT_LIMIT_MB '%s', defaulting to 64", value)
+		} else {
+			Config.Execution.OutputLimitMB = limit
+		}
+	}
+
+	Config.Execution.Workers = runtime.NumCPU()
+	if value := os.Getenv("EXECUTION_WORKERS"); value != "" {
+		workers, err := strconv.Atoi(value)
+		if err != nil || workers < 1 {
+			log.Printf("Invalid EXECUTION_WORKERS '%s', defaulting to %d", value, Config.Execution.Workers)
+		} else {
+			Config.Execution.Workers = workers
+		}
+	}
+
+	Config.Execution.MaxQueueDepth = 200
+	if value := os.Getenv("EXECUTION_MAX_QUEUE_DEPTH"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 1 {
+			log.Printf("Invalid EXECUTION_MAX_QUEUE_DEPTH '%s', defaulting to 200", value)
+		} else {
+			Config.Execution.MaxQueueDepth = depth
+		}
+	}
+
+	Config.Execution.CheckerCacheDir = os.Getenv("CHECKER_CACHE_DIR")
+	if Config.Execution.CheckerCacheDir == "" {
+		Config.Execution.CheckerCacheDir = filepath.Join(os.TempDir(), "simulate-interview-checkers")
+	}
+
+	Config.Execution.CompileCacheDir = os.Getenv("COMPILE_CACHE_DIR")
+	if Config.Execution.CompileCacheDir == "" {
+		Config.Execution.CompileCacheDir = filepath.Join(os.TempDir(), "simulate-interview-builds")
+	}
+
+	Config.Execution.CompileCacheMaxMB = 512
+	if value := os.Getenv("COMPILE_CACHE_MAX_MB"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 {
+			log.Printf("Invalid COMPILE_CACHE_MAX_MB '%s', defaulting to 512", value)
+		} else {
+			Config.Execution.CompileCacheMaxMB = size
+		}
+	}
+
+	Config.Execution.TestCacheDir = os.Getenv("TEST_CACHE_DIR")
+	if Config.Execution.TestCacheDir == "" {
+		Config.Execution.TestCacheDir = filepath.Join(os.TempDir(), "simulate-interview-tests")
+	}
+
+	Config.Execution.TestCacheMaxMB = 1024
+	if value := os.Getenv("TEST_CACHE_MAX_MB"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 {
+			log.Printf("Invalid TEST_CACHE_MAX_MB '%s', defaulting to 1024", value)
+		} else {
+			Config.Execution.TestCacheMaxMB = size
+		}
+	}
+
+	Config.Execution.ArtifactDir = os.Getenv("EXECUTION_ARTIFACT_DIR")
+	if Config.Execution.ArtifactDir == "" {
+		Config.Execution.ArtifactDir = filepath.Join(os.TempDir(), "simulate-interview-artifacts")
+	}
+
+	Config.Execution.ArtifactRetentionHours = 24
+	if value := os.Getenv("EXECUTION_ARTIFACT_RETENTION_HOURS"); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 1 {
+			log.Printf("Invalid EXECUTION_ARTIFACT_RETENTION_HOURS '%s', defaulting to 24", value)
+		} else {
+			Config.Execution.ArtifactRetentionHours = hours
+		}
+	}
+
+	Config.Execution.SubmissionArtifactRetentionDays = 30
+	if value := os.Getenv("SUBMISSION_ARTIFACT_RETENTION_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 {
+			log.Printf("Invalid SUBMISSION_ARTIFACT_RETENTION_DAYS '%s', defaulting to 30", value)
+		} else {
+			Config.Execution.SubmissionArtifactRetentionDays = days
+		}
+	}
+
+	Config.Execution.Executor = strings.ToLower(os.Getenv("EXECUTOR"))
+	switch Config.Execution.Executor {
+	case "", "local":
+		Config.Execution.Executor = "local"
+	case "judge0":
+		Config.Execution.Judge0URL = strings.TrimSuffix(os.Getenv("JUDGE0_URL"), "/")
+		Config.Execution.Judge0AuthToken = os.Getenv("JUDGE0_AUTH_TOKEN")
+		if Config.Execution.Judge0URL == "" {
+			log.Println("EXECUTOR=judge0 needs JUDGE0_URL, running user code locally")
+			Config.Execution.Executor = "local"
+		}
+	default:
+		log.Printf("Invalid EXECUTOR '%s', defaulting to 'local'", Config.Execution.Executor)
+		Config.Execution.Executor = "local"
+	}
+}
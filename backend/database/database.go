@@ -152,6 +152,8 @@ func Migrate() error {
 		&models.InterviewSession{},
 		&models.SessionProblem{},
 		&models.SessionToken{},
+		&models.ExecutionJob{},
+		&models.Submission{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
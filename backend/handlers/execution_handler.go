@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -8,21 +11,53 @@ import (
 	"github.com/boobachad/simulate-interview/backend/models"
 	"github.com/boobachad/simulate-interview/backend/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// ExecuteCode compiles and executes C++ code against test cases
-func ExecuteCode(c *gin.Context) {
+type ExecutionHandler struct {
+	executionService  *services.ExecutionService
+	jobService        *services.ExecutionJobService
+	submissionService *services.SubmissionService
+	artifacts         *services.ArtifactStore
+}
+
+func NewExecutionHandler(executionService *services.ExecutionService, jobService *services.ExecutionJobService, submissionService *services.SubmissionService, artifacts *services.ArtifactStore) *ExecutionHandler {
+	return &ExecutionHandler{
+		executionService:  executionService,
+		jobService:        jobService,
+		submissionService: submissionService,
+		artifacts:         artifacts,
+	}
+}
+
+// executionRequest is a validated execution request ready to be passed to Execute
+type executionRequest struct {
+	problemID string
+	mode      string
+	code      string
+	language  string
+	testCases []models.TestCase
+	opts      services.ExecutionOptions
+	// submission is set for signed-in users; the execution is only recorded
+	// when it is a submission, see SubmissionSpec.Recorded
+	submission *services.SubmissionSpec
+}
+
+// prepareExecution parses and validates an execution request and loads the
+// test cases of its problem. On failure it writes the error response and returns false.
+func (h *ExecutionHandler) prepareExecution(c *gin.Context) (*executionRequest, bool) {
 	var request models.ExecutionRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid request format",
 		})
-		return
+		return nil, false
 	}
 
 	// Get problem from database or mock
 	var problem models.Problem
+	var problemID, sessionProblemID *uuid.UUID
 
 	if request.ProblemID == "testing" {
 		mockProblem, err := services.LoadMockProblem()
@@ -31,14 +66,9 @@ func ExecuteCode(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to load mock problem",
 			})
-			return
-		}
-		// Convert mock problem struct to models.Problem (simplified mapping)
-		problem = models.Problem{
-			Title:       mockProblem.Title,
-			SampleCases: mockProblem.SampleCases,
-			HiddenCases: mockProblem.HiddenCases,
+			return nil, false
 		}
+		problem = problemFromResponse(mockProblem)
 	} else if request.ProblemID == "playground" {
 		// Empty problem for playground/scratchpad mode
 		// This allows code to run against custom cases only without failing mock problem cases
@@ -47,52 +77,244 @@ func ExecuteCode(c *gin.Context) {
 			SampleCases: []models.TestCase{},
 			HiddenCases: []models.TestCase{},
 		}
+	} else if result := database.DB.First(&problem, "id = ?", request.ProblemID); result.Error == nil {
+		problemID = &problem.ID
 	} else {
-		result := database.DB.First(&problem, "id = ?", request.ProblemID)
-		if result.Error != nil {
+		// Problems generated for a session are only stored in the session, which
+		// must belong to the user
+		userID, _ := c.Get("user_id")
+		var sessionProblem models.SessionProblem
+		if err := database.DB.
+			Joins("JOIN interview_sessions ON interview_sessions.id = session_problems.session_id").
+			Where("session_problems.id = ? AND interview_sessions.user_id = ?", request.ProblemID, userID).
+			First(&sessionProblem).Error; err != nil {
 			log.Printf("Error fetching problem: %v", result.Error)
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Problem not found",
 			})
-			return
+			return nil, false
 		}
+		var problemResponse models.ProblemGenerationResponse
+		if !sessionProblem.IsPlayable() || json.Unmarshal(sessionProblem.ProblemData, &problemResponse) != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Problem is not ready",
+			})
+			return nil, false
+		}
+		problem = problemFromResponse(&problemResponse)
+		sessionProblemID = &sessionProblem.ID
 	}
 
-	// Create execution service
-	execService := services.NewExecutionService()
-
 	// Default to C++ if no language specified
 	language := request.Language
 	if language == "" {
 		language = "cpp"
 	}
+	mode := request.Mode
+	if mode == "" {
+		mode = "run"
+	}
+	switch mode {
+	case "run", "submit", "debug", "analyze":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Mode must be run, submit, debug or analyze",
+		})
+		return nil, false
+	}
+
+	// Function problems run the user's function inside a generated harness
+	code := request.Code
+	referenceCode := problem.ReferenceCode
+	if problem.ProblemType == models.ProblemTypeFunction {
+		var err error
+		if code, err = services.BuildHarness(language, problem.Signature, request.Code); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return nil, false
+		}
+		if referenceCode != "" {
+			if referenceCode, err = services.BuildHarness("cpp", problem.Signature, referenceCode); err != nil {
+				log.Printf("Error building reference harness: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Problem has an invalid signature",
+				})
+				return nil, false
+			}
+		}
+	}
 
 	// Validate code
-	if err := execService.ValidateCode(request.Code, language); err != nil {
+	if err := h.executionService.ValidateCode(code, language); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
-		return
+		return nil, false
+	}
+	if err := h.executionService.ValidateToolchain(language, request.Toolchain); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
 	}
 
 	// Determine test cases based on mode
 	var allCases []models.TestCase
 
-	if request.Mode == "submit" {
+	if request.Mode == "submit" || request.Mode == "analyze" {
 		// Submit: Run against hidden cases (and sample cases usually, but user asked specifically for hidden check differentiation)
 		// Standard practice: Submit runs EVERYTHING to ensure it passes all constraints.
 		allCases = append(problem.SampleCases, problem.HiddenCases...)
 	} else {
-		// Run: Run against Sample Cases + Custom Cases
+		// Run and debug: Run against Sample Cases + Custom Cases
 		allCases = problem.SampleCases
 		if len(request.CustomCases) > 0 {
 			allCases = append(allCases, request.CustomCases...)
 		}
 	}
 
-	// Execute code
 	log.Printf("Executing %s code for problem: %s", language, problem.Title)
-	results, err := execService.Execute(request.Code, allCases, language)
+	opts := services.ExecutionOptions{
+		Toolchain:      request.Toolchain,
+		Debug:          request.Mode == "debug",
+		ComparisonMode: problem.ComparisonMode,
+		FloatEpsilon:   problem.FloatEpsilon,
+		CheckerCode:    problem.CheckerCode,
+		ValidatorCode:  problem.ValidatorCode,
+		TimeLimitMs:    problem.TimeLimitMs,
+		MemoryLimitMB:  problem.MemoryLimitMB,
+		OutputLimitMB:  problem.OutputLimitMB,
+	}
+	if problem.ProblemType == models.ProblemTypeInteractive {
+		if problem.InteractorCode == "" {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Interactive problem has no interactor",
+			})
+			return nil, false
+		}
+		opts.InteractorCode = problem.InteractorCode
+	} else if (request.Mode == "submit" || request.Mode == "analyze") && problem.GeneratorCode != "" && len(problem.GeneratedTests) > 0 {
+		// Large hidden tests that enforce the intended complexity
+		if referenceCode == "" {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Problem has generated tests but no reference solution",
+			})
+			return nil, false
+		}
+		opts.Generator = &services.TestGenerator{
+			GeneratorCode: problem.GeneratorCode,
+			ReferenceCode: referenceCode,
+			Tests:         problem.GeneratedTests,
+		}
+	}
+	if request.Mode == "analyze" {
+		// Inputs of growing size come from the generator, whose first argument is the size
+		maxSize := services.ComplexityMaxSize(problem.GeneratedTests)
+		if problem.ProblemType == models.ProblemTypeInteractive || problem.GeneratorCode == "" || referenceCode == "" || maxSize == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Problem does not support complexity analysis",
+			})
+			return nil, false
+		}
+		if request.ClaimedComplexity != "" {
+			if _, err := services.ParseComplexity(request.ClaimedComplexity); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return nil, false
+			}
+		}
+		opts.Complexity = &services.ComplexityOptions{
+			GeneratorCode: problem.GeneratorCode,
+			ReferenceCode: referenceCode,
+			MaxSize:       maxSize,
+			Claimed:       request.ClaimedComplexity,
+		}
+	}
+	var submission *services.SubmissionSpec
+	if userID, exists := c.Get("user_id"); exists {
+		opts.UserID = fmt.Sprint(userID)
+		if uid, ok := userID.(uuid.UUID); ok {
+			// The full test case files can be downloaded by the user
+			opts.Artifacts = h.artifacts.New(uid)
+			submission = &services.SubmissionSpec{
+				UserID:           uid,
+				ProblemID:        problemID,
+				SessionProblemID: sessionProblemID,
+				Language:         language,
+				Code:             request.Code,
+				Mode:             mode,
+			}
+		}
+	}
+
+	return &executionRequest{
+		problemID:  request.ProblemID,
+		mode:       mode,
+		code:       code,
+		language:   language,
+		testCases:  allCases,
+		opts:       opts,
+		submission: submission,
+	}, true
+}
+
+// problemFromResponse converts a generated problem that is not stored in the
+// problems table, i.e. the mock problem or a session problem, for execution
+func problemFromResponse(problemResponse *models.ProblemGenerationResponse) models.Problem {
+	return models.Problem{
+		Title:          problemResponse.Title,
+		SampleCases:    problemResponse.SampleCases,
+		HiddenCases:    problemResponse.HiddenCases,
+		ComparisonMode: problemResponse.ComparisonMode,
+		FloatEpsilon:   problemResponse.FloatEpsilon,
+		CheckerCode:    problemResponse.CheckerCode,
+		ProblemType:    problemResponse.ProblemType,
+		InteractorCode: problemResponse.InteractorCode,
+		GeneratorCode:  problemResponse.GeneratorCode,
+		GeneratedTests: problemResponse.GeneratedTests,
+		ReferenceCode:  problemResponse.ReferenceCode,
+		ValidatorCode:  problemResponse.ValidatorCode,
+		TimeLimitMs:    problemResponse.TimeLimitMs,
+		MemoryLimitMB:  problemResponse.MemoryLimitMB,
+		OutputLimitMB:  problemResponse.OutputLimitMB,
+		Signature:      problemResponse.Signature,
+	}
+}
+
+// recordSubmission stores a judged execution as a submission if it is one. A
+// failure is only logged, since the response is still valid without it.
+func (h *ExecutionHandler) recordSubmission(req *executionRequest, response *models.ExecutionResponse) {
+	if req.submission == nil {
+		return
+	}
+	if _, err := h.submissionService.Record(*req.submission, response); err != nil {
+		log.Printf("Failed to record submission: %v", err)
+	}
+}
+
+// ExecuteCode compiles and executes code against test cases
+func (h *ExecutionHandler) ExecuteCode(c *gin.Context) {
+	req, ok := h.prepareExecution(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.executionService.Execute(c.Request.Context(), req.code, req.testCases, req.language, req.opts)
+	if errors.Is(err, services.ErrExecutionQueueFull) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrJudgeUnavailable) {
+		log.Printf("Execution error: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Execution error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -101,20 +323,280 @@ func ExecuteCode(c *gin.Context) {
 		return
 	}
 
-	// Count passed tests
-	totalPassed := 0
-	for _, result := range results {
-		if result.Passed {
-			totalPassed++
+	h.recordSubmission(req, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// StreamExecuteCode executes code like ExecuteCode but streams progress as
+// Server-Sent Events: "compiled" once the program is built, "case_result" for
+// each finished test case and "summary" with the final response. Closing the
+// connection cancels the execution and kills running test cases. A full queue
+// is answered with 429 before the stream starts; if it fills up in between,
+// the stream ends with an "error" event like any other failure.
+func (h *ExecutionHandler) StreamExecuteCode(c *gin.Context) {
+	req, ok := h.prepareExecution(c)
+	if !ok {
+		return
+	}
+
+	if h.executionService.QueueFull() {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": services.ErrExecutionQueueFull.Error(),
+		})
+		return
+	}
+
+	// Set headers for Server-Sent Events
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Transfer-Encoding", "chunked")
+
+	// Room for every event, so execution never blocks on a slow client. Generated
+	// tests can add cases, sends then stop waiting once the client is gone.
+	eventChan := make(chan executionEvent, len(req.testCases)+req.opts.GeneratedTestCount()+1)
+	doneChan := make(chan executionOutcome, 1)
+
+	ctx := c.Request.Context()
+	sendEvent := func(event executionEvent) {
+		select {
+		case eventChan <- event:
+		case <-ctx.Done():
 		}
 	}
+	req.opts.OnCompiled = func(totalCases int) {
+		sendEvent(executionEvent{name: "compiled", data: gin.H{
+			"language":    req.language,
+			"total_cases": totalCases,
+		}})
+	}
+	req.opts.OnCaseResult = func(result models.ExecutionResult) {
+		sendEvent(executionEvent{name: "case_result", data: result})
+	}
+
+	go func() {
+		response, err := h.executionService.Execute(ctx, req.code, req.testCases, req.language, req.opts)
+		doneChan <- executionOutcome{response: response, err: err}
+	}()
+
+	for {
+		select {
+		case event := <-eventChan:
+			writeSSEEvent(c, event.name, event.data)
 
-	response := models.ExecutionResponse{
-		Success:     totalPassed == len(results),
-		Results:     results,
-		TotalPassed: totalPassed,
-		TotalCases:  len(results),
+		case outcome := <-doneChan:
+			// Progress events are sent before Execute returns
+			for len(eventChan) > 0 {
+				event := <-eventChan
+				writeSSEEvent(c, event.name, event.data)
+			}
+			if outcome.err != nil {
+				log.Printf("Execution error: %v", outcome.err)
+				writeSSEEvent(c, "error", gin.H{"error": outcome.err.Error()})
+				return
+			}
+			h.recordSubmission(req, outcome.response)
+			writeSSEEvent(c, "summary", outcome.response)
+			return
+
+		case <-ctx.Done():
+			log.Printf("Client disconnected, execution cancelled")
+			return
+		}
+	}
+}
+
+// DownloadCaseFile returns the full input, expected output or actual output of
+// a test case of one of the user's executions, by the artifact_id of its
+// response, until the files expire
+func (h *ExecutionHandler) DownloadCaseFile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	uid, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	artifactID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid artifact ID"})
+		return
+	}
+	caseNumber, ok := parseCaseFile(c)
+	if !ok {
+		return
+	}
+
+	serveCaseFile(c, h.artifacts, uid, artifactID, caseNumber)
+}
+
+// StressTest compares a solution against a brute force on generated inputs
+// and returns the first input on which they disagree
+func (h *ExecutionHandler) StressTest(c *gin.Context) {
+	var request models.StressRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	programs := map[string]*models.StressProgram{
+		"generator": &request.Generator,
+		"brute":     &request.Brute,
+		"solution":  &request.Solution,
+	}
+	for name, program := range programs {
+		// Default to C++ if no language specified
+		if program.Language == "" {
+			program.Language = "cpp"
+		}
+		// Stress tests run locally even when user code is judged remotely
+		if err := h.executionService.ValidateLocalCode(program.Code, program.Language); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("%s: %v", name, err),
+			})
+			return
+		}
+		if err := h.executionService.ValidateToolchain(program.Language, program.Toolchain); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("%s: %v", name, err),
+			})
+			return
+		}
+	}
+
+	var userID string
+	if id, exists := c.Get("user_id"); exists {
+		userID = fmt.Sprint(id)
+	}
+
+	log.Printf("Stress testing %s solution against %s brute force", request.Solution.Language, request.Brute.Language)
+	response, err := h.executionService.Stress(c.Request.Context(), request, userID)
+	if errors.Is(err, services.ErrExecutionQueueFull) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Stress test error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Stress test failed",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ListLanguages returns every configured language with its version, toolchain
+// options, whether it can be run on this server and hints for writing a program in it
+func (h *ExecutionHandler) ListLanguages(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"languages": h.executionService.Languages(),
+	})
+}
+
+// CreateExecutionJob starts an execution in the background and returns the job
+// to poll. The job keeps running if the client disconnects. A full execution
+// queue is answered with 429 without creating a job.
+func (h *ExecutionHandler) CreateExecutionJob(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	uid, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	req, ok := h.prepareExecution(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobService.Submit(uid, services.ExecutionJobSpec{
+		ProblemID:  req.problemID,
+		Mode:       req.mode,
+		Code:       req.code,
+		Language:   req.language,
+		TestCases:  req.testCases,
+		Options:    req.opts,
+		Submission: req.submission,
+	})
+	if errors.Is(err, services.ErrExecutionQueueFull) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating execution job: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create execution job"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetExecutionJob returns the status of an execution job and, once done, its results
+func (h *ExecutionHandler) GetExecutionJob(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	uid, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid execution ID"})
+		return
+	}
+
+	job, err := h.jobService.Get(jobID, uid)
+	if errors.Is(err, services.ErrExecutionJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Execution not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching execution job: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch execution"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+type executionEvent struct {
+	name string
+	data interface{}
+}
+
+type executionOutcome struct {
+	response *models.ExecutionResponse
+	err      error
+}
+
+// writeSSEEvent sends a named event with a JSON payload
+func writeSSEEvent(c *gin.Context, name string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", name, err)
+		return
+	}
+	c.Writer.WriteString(fmt.Sprintf("event: %s\ndata: %s\n\n", name, payload))
+	c.Writer.Flush()
+}
@@ -14,11 +14,13 @@ import (
 
 type GenerationHandler struct {
 	statsService services.StatsService
+	verifier     services.ProblemVerifier
 }
 
-func NewGenerationHandler(statsService services.StatsService) *GenerationHandler {
+func NewGenerationHandler(statsService services.StatsService, verifier services.ProblemVerifier) *GenerationHandler {
 	return &GenerationHandler{
 		statsService: statsService,
+		verifier:     verifier,
 	}
 }
 
@@ -112,7 +114,9 @@ func (h *GenerationHandler) GenerateProblem(c *gin.Context) {
 	if request.TargetRating != nil {
 		log.Printf("Target rating: %d", *request.TargetRating)
 	}
-	problemResponse, err := llmProvider.GenerateProblem(ctx, request.FocusAreas, personalizationContext, request.TargetRating)
+	problemResponse, verification, err := h.verifier.GenerateVerifiedProblem(ctx, func() (*models.ProblemGenerationResponse, error) {
+		return llmProvider.GenerateProblem(ctx, request.FocusAreas, personalizationContext, request.TargetRating)
+	}, nil)
 	if err != nil {
 		log.Printf("Error generating problem: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -145,7 +149,11 @@ func (h *GenerationHandler) GenerateProblem(c *gin.Context) {
 			"rating":       mockProblem.Rating,
 			"focus_area":   mockProblem.FocusArea,
 			"sample_cases": mockProblem.SampleCases,
+			"problem_type": mockProblem.ProblemType,
+			"signature":    mockProblem.Signature,
+			"starter_code": starterCode(mockProblem.ProblemType, mockProblem.Signature),
 			"hidden_cases": mockProblem.HiddenCases,
+			"status":       verification.Status,
 			"created_at":   nil,
 		})
 		return
@@ -184,6 +192,10 @@ func (h *GenerationHandler) GenerateProblem(c *gin.Context) {
 			"rating":       existingProblem.Rating,
 			"focus_area":   focusAreaValue,
 			"sample_cases": existingProblem.SampleCases,
+			"problem_type": existingProblem.ProblemType,
+			"signature":    existingProblem.Signature,
+			"starter_code": starterCode(existingProblem.ProblemType, existingProblem.Signature),
+			"status":       existingProblem.Status,
 			"created_at":   existingProblem.CreatedAt,
 		})
 		return
@@ -199,7 +211,21 @@ func (h *GenerationHandler) GenerateProblem(c *gin.Context) {
 		FocusAreaTopic: &focusAreaTopic,
 		SampleCases:    problemResponse.SampleCases,
 		HiddenCases:    problemResponse.HiddenCases,
+		ComparisonMode: problemResponse.ComparisonMode,
+		FloatEpsilon:   problemResponse.FloatEpsilon,
+		CheckerCode:    problemResponse.CheckerCode,
+		ProblemType:    problemResponse.ProblemType,
+		InteractorCode: problemResponse.InteractorCode,
+		GeneratorCode:  problemResponse.GeneratorCode,
+		GeneratedTests: problemResponse.GeneratedTests,
+		ReferenceCode:  problemResponse.ReferenceCode,
+		ValidatorCode:  problemResponse.ValidatorCode,
+		TimeLimitMs:    problemResponse.TimeLimitMs,
+		MemoryLimitMB:  problemResponse.MemoryLimitMB,
+		OutputLimitMB:  problemResponse.OutputLimitMB,
+		Signature:      problemResponse.Signature,
 	}
+	applyVerification(&problem, verification)
 
 	result = database.DB.Create(&problem)
 	if result.Error != nil {
@@ -218,6 +244,19 @@ func (h *GenerationHandler) GenerateProblem(c *gin.Context) {
 		"rating":       problem.Rating,
 		"focus_area":   focusAreaTopic,
 		"sample_cases": problem.SampleCases,
+		"problem_type": problem.ProblemType,
+		"signature":    problem.Signature,
+		"starter_code": starterCode(problem.ProblemType, problem.Signature),
+		"status":       problem.Status,
 		"created_at":   problem.CreatedAt,
 	})
 }
+
+// applyVerification records the verification outcome of a generated problem
+func applyVerification(problem *models.Problem, verification services.ProblemVerification) {
+	problem.Status = verification.Status
+	if verification.Reason != "" {
+		reason := verification.Reason
+		problem.VerificationError = &reason
+	}
+}
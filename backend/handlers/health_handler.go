@@ -5,34 +5,80 @@ import (
 	"time"
 
 	"github.com/boobachad/simulate-interview/backend/database"
+	"github.com/boobachad/simulate-interview/backend/services"
 	"github.com/gin-gonic/gin"
 )
 
-func HealthCheck(c *gin.Context) {
+type HealthHandler struct {
+	executionService *services.ExecutionService
+}
+
+func NewHealthHandler(executionService *services.ExecutionService) *HealthHandler {
+	return &HealthHandler{
+		executionService: executionService,
+	}
+}
+
+// HealthCheck reports the database connection and which languages can be run.
+// The status is "degraded" while some languages are unavailable and "error"
+// when none are.
+func (h *HealthHandler) HealthCheck(c *gin.Context) {
+	available := []string{}
+	unavailable := map[string]string{}
+	for _, lang := range h.executionService.Languages() {
+		if lang.Available {
+			available = append(available, lang.ID)
+		} else {
+			unavailable[lang.ID] = lang.UnavailableReason
+		}
+	}
+	languages := gin.H{
+		"available":   available,
+		"unavailable": unavailable,
+	}
+
 	db := database.GetDB()
 	sqlDB, err := db.DB()
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status": "error",
-			"db":     "disconnected",
-			"error":  err.Error(),
-			"time":   time.Now(),
+			"status":    "error",
+			"db":        "disconnected",
+			"error":     err.Error(),
+			"languages": languages,
+			"time":      time.Now(),
 		})
 		return
 	}
 	err = sqlDB.Ping()
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status": "error",
-			"db":     "disconnected",
-			"error":  err.Error(),
+			"status":    "error",
+			"db":        "disconnected",
+			"error":     err.Error(),
+			"languages": languages,
 		})
 		return
 	}
 
+	if len(available) == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":    "error",
+			"db":        "connected",
+			"error":     "no language can be run on this server",
+			"languages": languages,
+			"time":      time.Now(),
+		})
+		return
+	}
+
+	status := "ok"
+	if len(unavailable) > 0 {
+		status = "degraded"
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"db":     "connected",
-		"time":   time.Now(),
+		"status":    status,
+		"db":        "connected",
+		"languages": languages,
+		"time":      time.Now(),
 	})
 }
@@ -17,6 +17,16 @@ import (
 	"gorm.io/gorm"
 )
 
+type ProblemHandler struct {
+	executionService *services.ExecutionService
+}
+
+func NewProblemHandler(executionService *services.ExecutionService) *ProblemHandler {
+	return &ProblemHandler{
+		executionService: executionService,
+	}
+}
+
 // GetFocusAreas returns all available focus areas
 func GetFocusAreas(c *gin.Context) {
 	var focusAreas []models.FocusArea
@@ -34,7 +44,7 @@ func GetFocusAreas(c *gin.Context) {
 }
 
 // GetProblems returns problems, optionally filtered by focus area
-func GetProblems(c *gin.Context) {
+func (h *ProblemHandler) GetProblems(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
@@ -62,11 +72,11 @@ func GetProblems(c *gin.Context) {
 		return
 	}
 
-	// Query session_problems table for ready problems (user-specific)
+	// Query session_problems table for playable problems (user-specific)
 	var sessionProblems []models.SessionProblem
 	sessionQuery := database.DB.
 		Joins("JOIN interview_sessions ON interview_sessions.id = session_problems.session_id").
-		Where("interview_sessions.user_id = ? AND session_problems.status = ?", uid, "ready").
+		Where("interview_sessions.user_id = ? AND session_problems.status IN ?", uid, []string{models.ProblemStatusReady, models.ProblemStatusUnverified}).
 		Order("session_problems.generated_at DESC")
 
 	if err := sessionQuery.Find(&sessionProblems).Error; err != nil {
@@ -159,9 +169,9 @@ func GetProblems(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GetProblem returns a single problem by ID
+// GetProblem returns a single problem by ID with its effective limits
 // Special handling for "testing" ID which returns the mock problem
-func GetProblem(c *gin.Context) {
+func (h *ProblemHandler) GetProblem(c *gin.Context) {
 	problemID := c.Param("id")
 
 	// Handle mock/testing problem
@@ -183,6 +193,10 @@ func GetProblem(c *gin.Context) {
 			"rating":       mockProblem.Rating,
 			"sample_cases": mockProblem.SampleCases,
 			"hidden_cases": mockProblem.HiddenCases,
+			"problem_type": mockProblem.ProblemType,
+			"signature":    mockProblem.Signature,
+			"starter_code": starterCode(mockProblem.ProblemType, mockProblem.Signature),
+			"limits":       h.executionService.Limits(mockProblem.TimeLimitMs, mockProblem.MemoryLimitMB, mockProblem.OutputLimitMB),
 			"created_at":   nil,
 		})
 		return
@@ -212,6 +226,10 @@ func GetProblem(c *gin.Context) {
 					"focus_area":   problemResponse.FocusArea,
 					"sample_cases": problemResponse.SampleCases,
 					"hidden_cases": problemResponse.HiddenCases,
+					"problem_type": problemResponse.ProblemType,
+					"signature":    problemResponse.Signature,
+					"starter_code": starterCode(problemResponse.ProblemType, problemResponse.Signature),
+					"limits":       h.executionService.Limits(problemResponse.TimeLimitMs, problemResponse.MemoryLimitMB, problemResponse.OutputLimitMB),
 					"created_at":   sessionProblem.GeneratedAt,
 				})
 				return
@@ -245,12 +263,25 @@ func GetProblem(c *gin.Context) {
 		"focus_area":   focusAreaValue,
 		"sample_cases": problem.SampleCases,
 		"hidden_cases": problem.HiddenCases,
+		"problem_type": problem.ProblemType,
+		"signature":    problem.Signature,
+		"starter_code": starterCode(problem.ProblemType, problem.Signature),
+		"limits":       h.executionService.Limits(problem.TimeLimitMs, problem.MemoryLimitMB, problem.OutputLimitMB),
 		"created_at":   problem.CreatedAt,
 	})
 }
 
+// starterCode returns the function each language starts from for function
+// problems, and nil for problems read from stdin
+func starterCode(problemType models.ProblemType, signature *models.FunctionSignature) map[string]string {
+	if problemType != models.ProblemTypeFunction {
+		return nil
+	}
+	return services.StarterCode(signature)
+}
+
 // GetProblemSession returns the most recent session containing the problem
-func GetProblemSession(c *gin.Context) {
+func (h *ProblemHandler) GetProblemSession(c *gin.Context) {
 	problemID := c.Param("id")
 
 	// Handle mock/testing problem
@@ -130,6 +130,9 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 		"focus_area":   firstProblem.FocusArea,
 		"rating":       firstProblem.Rating,
 		"sample_cases": firstProblem.SampleCases,
+		"problem_type": firstProblem.ProblemType,
+		"signature":    firstProblem.Signature,
+		"starter_code": starterCode(firstProblem.ProblemType, firstProblem.Signature),
 		"created_at":   createdAt,
 	}
 
@@ -187,7 +190,7 @@ func (h *SessionHandler) GetSession(c *gin.Context) {
 			ErrorMessage:  sp.ErrorMessage,
 		}
 
-		if sp.Status == "ready" && len(sp.ProblemData) > 0 {
+		if sp.IsPlayable() && len(sp.ProblemData) > 0 {
 			var problemResponse models.ProblemGenerationResponse
 			if err := json.Unmarshal(sp.ProblemData, &problemResponse); err != nil {
 				log.Printf("Failed to unmarshal session problem ID=%s GeneratedAt=%v: %v", sp.ID, sp.GeneratedAt, err)
@@ -204,6 +207,9 @@ func (h *SessionHandler) GetSession(c *gin.Context) {
 					"focus_area":   problemResponse.FocusArea,
 					"rating":       problemResponse.Rating,
 					"sample_cases": problemResponse.SampleCases,
+					"problem_type": problemResponse.ProblemType,
+					"signature":    problemResponse.Signature,
+					"starter_code": starterCode(problemResponse.ProblemType, problemResponse.Signature),
 					"created_at":   createdAt,
 				}
 				problems[i].Problem = &problemMap
@@ -277,6 +283,9 @@ func (h *SessionHandler) GetNextProblem(c *gin.Context) {
 		"description":  problem.Description,
 		"focus_area":   problem.FocusArea,
 		"sample_cases": problem.SampleCases,
+		"problem_type": problem.ProblemType,
+		"signature":    problem.Signature,
+		"starter_code": starterCode(problem.ProblemType, problem.Signature),
 	}
 
 	c.JSON(http.StatusOK, NextProblemResponse{
@@ -320,7 +329,7 @@ func (h *SessionHandler) CompleteSession(c *gin.Context) {
 	// Validate all problems are ready before allowing completion
 	readyCount := 0
 	for _, p := range sessionData.Problems {
-		if p.Status == "ready" {
+		if p.IsPlayable() {
 			readyCount++
 		}
 	}
@@ -15,8 +15,12 @@ import (
 	"github.com/google/uuid"
 )
 
-// StreamGenerateProblem generates a new problem using LLM with streaming
-func StreamGenerateProblem(c *gin.Context) {
+// StreamGenerateProblem generates a new problem using LLM with streaming. The
+// streamed problem cannot be generated again, so one that fails verification
+// is saved as unverified. The raw model output contains the checker and other
+// judge sources, so only its progress is streamed and the problem itself is
+// sent in the complete event.
+func (h *GenerationHandler) StreamGenerateProblem(c *gin.Context) {
 	var request models.ProblemGenerationRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -61,10 +65,10 @@ func StreamGenerateProblem(c *gin.Context) {
 		doneChan <- err
 	}()
 
-	// Collect full response while streaming to client
+	// Collect full response while streaming progress to client
 	var fullResponse strings.Builder
 
-	// Stream chunks to client
+	// Stream progress to client
 	for {
 		select {
 		case chunk, ok := <-streamChan:
@@ -74,8 +78,8 @@ func StreamGenerateProblem(c *gin.Context) {
 			}
 			fullResponse.WriteString(chunk)
 
-			// Send chunk as SSE
-			event := fmt.Sprintf("data: %s\n\n", chunk)
+			// Send progress as SSE
+			event := fmt.Sprintf("event: progress\ndata: {\"received_bytes\": %d}\n\n", fullResponse.Len())
 			c.Writer.WriteString(event)
 			c.Writer.Flush()
 
@@ -136,6 +140,10 @@ process_response:
 		return
 	}
 
+	c.Writer.WriteString("event: verifying\ndata: {}\n\n")
+	c.Writer.Flush()
+	verification := h.verifier.VerifyProblem(ctx, &problemResponse)
+
 	// Save problem to database
 	focusAreaTopic := focusArea.Slug
 	problem := models.Problem{
@@ -146,7 +154,21 @@ process_response:
 		FocusAreaTopic: &focusAreaTopic,
 		SampleCases:    problemResponse.SampleCases,
 		HiddenCases:    problemResponse.HiddenCases,
+		ComparisonMode: problemResponse.ComparisonMode,
+		FloatEpsilon:   problemResponse.FloatEpsilon,
+		CheckerCode:    problemResponse.CheckerCode,
+		ProblemType:    problemResponse.ProblemType,
+		InteractorCode: problemResponse.InteractorCode,
+		GeneratorCode:  problemResponse.GeneratorCode,
+		GeneratedTests: problemResponse.GeneratedTests,
+		ReferenceCode:  problemResponse.ReferenceCode,
+		ValidatorCode:  problemResponse.ValidatorCode,
+		TimeLimitMs:    problemResponse.TimeLimitMs,
+		MemoryLimitMB:  problemResponse.MemoryLimitMB,
+		OutputLimitMB:  problemResponse.OutputLimitMB,
+		Signature:      problemResponse.Signature,
 	}
+	applyVerification(&problem, verification)
 
 	result = database.DB.Create(&problem)
 	if result.Error != nil {
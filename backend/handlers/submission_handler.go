@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+	"github.com/boobachad/simulate-interview/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SubmissionHandler struct {
+	submissionService *services.SubmissionService
+	artifacts         *services.ArtifactStore
+}
+
+func NewSubmissionHandler(submissionService *services.SubmissionService, artifacts *services.ArtifactStore) *SubmissionHandler {
+	return &SubmissionHandler{
+		submissionService: submissionService,
+		artifacts:         artifacts,
+	}
+}
+
+// ListProblemSubmissions returns the user's submissions on a problem or session
+// problem, newest first. Only submit executions are recorded; the optional
+// "mode" query parameter keeps only submissions of that mode.
+func (h *SubmissionHandler) ListProblemSubmissions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	uid, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	problemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		return
+	}
+
+	submissions, err := h.submissionService.ListForProblem(uid, problemID, c.Query("mode"))
+	if err != nil {
+		log.Printf("Error listing submissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"submissions": submissions,
+	})
+}
+
+// GetSubmission returns a submission with its code and per-case results
+func (h *SubmissionHandler) GetSubmission(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	uid, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		return
+	}
+
+	submission, err := h.submissionService.Get(submissionID, uid)
+	if errors.Is(err, services.ErrSubmissionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching submission: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// DownloadCaseFile returns the full input, expected output or actual output of
+// a test case of a submission as a text file, for data too large for the
+// previews in its results
+func (h *SubmissionHandler) DownloadCaseFile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	uid, ok := userID.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		return
+	}
+	caseNumber, ok := parseCaseFile(c)
+	if !ok {
+		return
+	}
+
+	submission, err := h.submissionService.Get(submissionID, uid)
+	if errors.Is(err, services.ErrSubmissionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching submission: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submission"})
+		return
+	}
+
+	if submission.Result == nil || !slices.ContainsFunc(submission.Result.Results, func(result models.ExecutionResult) bool {
+		return result.CaseNumber == caseNumber
+	}) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Test case not found"})
+		return
+	}
+	if submission.Result.ArtifactID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Only the previews in the submission's results were stored for this test case"})
+		return
+	}
+
+	serveCaseFile(c, h.artifacts, uid, *submission.Result.ArtifactID, caseNumber)
+}
+
+// parseCaseFile validates the case number and file name of a test case file
+// download. On failure it writes the error response and returns false.
+func parseCaseFile(c *gin.Context) (int, bool) {
+	caseNumber, err := strconv.Atoi(c.Param("case"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case number"})
+		return 0, false
+	}
+	switch c.Param("file") {
+	case services.ArtifactInput, services.ArtifactExpected, services.ArtifactActual:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File must be input, expected or actual"})
+		return 0, false
+	}
+	return caseNumber, true
+}
+
+// serveCaseFile sends a stored test case file as an attachment
+func serveCaseFile(c *gin.Context, artifacts *services.ArtifactStore, userID uuid.UUID, artifactID uuid.UUID, caseNumber int) {
+	file, err := artifacts.Open(userID, artifactID, caseNumber, c.Param("file"))
+	if errors.Is(err, services.ErrArtifactNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Test case file not found; it was not stored or has expired"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening test case file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read test case file"})
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		log.Printf("Error opening test case file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read test case file"})
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, info.Size(), "text/plain; charset=utf-8", file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="case-%d-%s.txt"`, caseNumber, c.Param("file")),
+	})
+}
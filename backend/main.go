@@ -59,7 +59,42 @@ func main() {
 		log.Fatalf("Failed to create LLM provider: %v", err)
 	}
 
-	generationService := services.NewGenerationService(db, llmProvider, statsService, rateLimiter)
+	sandbox, err := services.NewSandbox()
+	if err != nil {
+		log.Fatalf("Failed to initialize sandbox: %v", err)
+	}
+	executionPool := services.NewExecutionPool(config.Config.Execution.Workers, config.Config.Execution.MaxQueueDepth)
+	languages, err := services.NewLanguageRegistry(config.Config.Languages)
+	if err != nil {
+		log.Fatalf("Failed to load languages: %v", err)
+	}
+	compileCache, err := services.NewCompileCache(config.Config.Execution.CompileCacheDir, int64(config.Config.Execution.CompileCacheMaxMB)*1024*1024)
+	if err != nil {
+		log.Fatalf("Failed to initialize compile cache: %v", err)
+	}
+	testCache, err := services.NewCompileCache(config.Config.Execution.TestCacheDir, int64(config.Config.Execution.TestCacheMaxMB)*1024*1024)
+	if err != nil {
+		log.Fatalf("Failed to initialize generated test cache: %v", err)
+	}
+	var remoteExecutor services.Executor
+	if config.Config.Execution.Executor == "judge0" {
+		log.Printf("Running user code on the Judge0 server at %s", config.Config.Execution.Judge0URL)
+		remoteExecutor = services.NewJudge0Executor(config.Config.Execution.Judge0URL, config.Config.Execution.Judge0AuthToken, config.Config.Languages)
+	}
+	executionService := services.NewExecutionService(sandbox, executionPool, languages, compileCache, testCache, remoteExecutor)
+	artifactStore, err := services.NewArtifactStore(
+		config.Config.Execution.ArtifactDir,
+		time.Duration(config.Config.Execution.ArtifactRetentionHours)*time.Hour,
+		time.Duration(config.Config.Execution.SubmissionArtifactRetentionDays)*24*time.Hour,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize artifact store: %v", err)
+	}
+	go artifactStore.PruneLoop(ctx, services.ARTIFACT_PRUNE_INTERVAL)
+	submissionService := services.NewSubmissionService(db, artifactStore)
+	executionJobService := services.NewExecutionJobService(db, executionService, submissionService)
+
+	generationService := services.NewGenerationService(db, llmProvider, statsService, rateLimiter, executionService)
 	sessionService := services.NewSessionService(db, generationService, statsService)
 
 	// Initialize handlers
@@ -68,7 +103,11 @@ func main() {
 	statsHandler := handlers.NewStatsHandler(statsService)
 	focusAreasHandler := handlers.NewFocusAreasHandler()
 	sessionHandler := handlers.NewSessionHandler(sessionService)
-	generationHandler := handlers.NewGenerationHandler(statsService)
+	generationHandler := handlers.NewGenerationHandler(statsService, generationService)
+	executionHandler := handlers.NewExecutionHandler(executionService, executionJobService, submissionService, artifactStore)
+	submissionHandler := handlers.NewSubmissionHandler(submissionService, artifactStore)
+	problemHandler := handlers.NewProblemHandler(executionService)
+	healthHandler := handlers.NewHealthHandler(executionService)
 
 	// Setup Gin router
 	router := gin.Default()
@@ -92,6 +131,9 @@ func main() {
 		api.POST("/auth/login", authHandler.Login)
 		api.POST("/auth/logout", authHandler.Logout)
 
+		// Languages and toolchain readiness (public)
+		api.GET("/languages", executionHandler.ListLanguages)
+
 		// protected routes
 		protected := api.Group("")
 		protected.Use(middleware.AuthRequired(authService))
@@ -100,14 +142,24 @@ func main() {
 			protected.GET("/focus-areas", focusAreasHandler.GetFocusAreas)
 
 			// Problems
-			protected.GET("/problems", handlers.GetProblems)
-			protected.GET("/problems/:id", handlers.GetProblem)
-			protected.GET("/problems/:id/session", handlers.GetProblemSession)
+			protected.GET("/problems", problemHandler.GetProblems)
+			protected.GET("/problems/:id", problemHandler.GetProblem)
+			protected.GET("/problems/:id/session", problemHandler.GetProblemSession)
+			protected.GET("/problems/:id/submissions", submissionHandler.ListProblemSubmissions)
 			protected.POST("/problems/generate", generationHandler.GenerateProblem)
-			protected.POST("/problems/generate-stream", handlers.StreamGenerateProblem)
+			protected.POST("/problems/generate-stream", generationHandler.StreamGenerateProblem)
 
 			// Code execution
-			protected.POST("/execute", handlers.ExecuteCode)
+			protected.POST("/execute", executionHandler.ExecuteCode)
+			protected.POST("/execute/stream", executionHandler.StreamExecuteCode)
+			protected.POST("/stress", executionHandler.StressTest)
+			protected.POST("/executions", executionHandler.CreateExecutionJob)
+			protected.GET("/executions/:id", executionHandler.GetExecutionJob)
+			protected.GET("/artifacts/:id/cases/:case/:file", executionHandler.DownloadCaseFile)
+
+			// Submissions
+			protected.GET("/submissions/:id", submissionHandler.GetSubmission)
+			protected.GET("/submissions/:id/cases/:case/:file", submissionHandler.DownloadCaseFile)
 
 			// Profile routes
 			protected.POST("/profile/setup", profileHandler.Setup)
@@ -128,7 +180,7 @@ func main() {
 	}
 
 	// Health check
-	router.GET("/health", handlers.HealthCheck)
+	router.GET("/health", healthHandler.HealthCheck)
 
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
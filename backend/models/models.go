@@ -25,6 +25,8 @@ type TestCase struct {
 	Input          string `json:"input"`
 	ExpectedOutput string `json:"expected_output"`
 	Explanation    string `json:"explanation,omitempty"`
+	// Generated marks tests expanded from a generator, which are too large to send back in full
+	Generated bool `json:"-"`
 }
 
 // TestCaseList wrapper for []TestCase to implement Scanner and Valuer interfaces
@@ -44,20 +46,128 @@ func (t *TestCaseList) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, t)
 }
 
+// GeneratedTest is a hidden test whose input is produced at judge time by the
+// problem's generator, called as "generator <seed> [args...]"
+type GeneratedTest struct {
+	Seed int64    `json:"seed"`
+	Args []string `json:"args,omitempty"` // e.g. the size of the input
+}
+
+// GeneratedTestList wrapper for []GeneratedTest to implement Scanner and Valuer interfaces
+type GeneratedTestList []GeneratedTest
+
+// Value implementation for driver.Valuer
+func (t GeneratedTestList) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan implementation for sql.Scanner
+func (t *GeneratedTestList) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, t)
+}
+
 // Problem represents a coding interview problem
 type Problem struct {
-	ID             uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Title          string       `gorm:"type:varchar(500);not null" json:"title"`
-	Description    string       `gorm:"type:text;not null" json:"description"`
-	Rating         int          `gorm:"type:integer;not null;default:1200" json:"rating"`
-	FocusAreaID    *uuid.UUID   `gorm:"type:uuid" json:"focus_area_id,omitempty"`
-	FocusAreaTopic *string      `gorm:"type:varchar(255);index" json:"focus_area_topic,omitempty"`
-	FocusArea      FocusArea    `gorm:"foreignKey:FocusAreaID" json:"focus_area,omitempty"`
-	SampleCases    TestCaseList `gorm:"type:jsonb;not null" json:"sample_cases"`
-	HiddenCases    TestCaseList `gorm:"type:jsonb;not null" json:"hidden_cases"`
-	CreatedAt      time.Time    `gorm:"index" json:"created_at"`
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title          string         `gorm:"type:varchar(500);not null" json:"title"`
+	Description    string         `gorm:"type:text;not null" json:"description"`
+	Rating         int            `gorm:"type:integer;not null;default:1200" json:"rating"`
+	FocusAreaID    *uuid.UUID     `gorm:"type:uuid" json:"focus_area_id,omitempty"`
+	FocusAreaTopic *string        `gorm:"type:varchar(255);index" json:"focus_area_topic,omitempty"`
+	FocusArea      FocusArea      `gorm:"foreignKey:FocusAreaID" json:"focus_area,omitempty"`
+	SampleCases    TestCaseList   `gorm:"type:jsonb;not null" json:"sample_cases"`
+	HiddenCases    TestCaseList   `gorm:"type:jsonb;not null" json:"hidden_cases"`
+	ComparisonMode ComparisonMode `gorm:"type:varchar(30);not null;default:'exact'" json:"comparison_mode"`
+	FloatEpsilon   float64        `gorm:"not null;default:0" json:"float_epsilon,omitempty"`
+	CheckerCode    string         `gorm:"type:text" json:"-"` // Special judge source, never sent to clients
+	ProblemType    ProblemType    `gorm:"type:varchar(20);not null;default:'standard'" json:"problem_type"`
+	InteractorCode string         `gorm:"type:text" json:"-"` // Interactor source, never sent to clients
+	// GeneratorCode produces the inputs of GeneratedTests and ReferenceCode their
+	// expected outputs, so that large tests need not be stored
+	GeneratorCode  string            `gorm:"type:text" json:"-"`
+	GeneratedTests GeneratedTestList `gorm:"type:jsonb" json:"-"`
+	ReferenceCode  string            `gorm:"type:text" json:"-"`
+	// ValidatorCode checks that test inputs respect the stated constraints
+	ValidatorCode string `gorm:"type:text" json:"-"`
+	// TimeLimitMs and MemoryLimitMB are the limits of each test case before
+	// per-language time multipliers; 0 uses the server defaults
+	TimeLimitMs   int `gorm:"not null;default:0" json:"time_limit_ms,omitempty"`
+	MemoryLimitMB int `gorm:"not null;default:0" json:"memory_limit_mb,omitempty"`
+	// OutputLimitMB caps the output of each test case; 0 uses the server default
+	OutputLimitMB int `gorm:"not null;default:0" json:"output_limit_mb,omitempty"`
+	// Signature is the function users implement in function problems
+	Signature *FunctionSignature `gorm:"type:jsonb" json:"signature,omitempty"`
+	// Status is ProblemStatusReady once the reference solution passed every case
+	Status            string    `gorm:"type:varchar(20);not null;default:'ready'" json:"status"`
+	VerificationError *string   `gorm:"type:text" json:"verification_error,omitempty"`
+	CreatedAt         time.Time `gorm:"index" json:"created_at"`
+}
+
+// ProblemType distinguishes one-shot stdin/stdout problems from interactive
+// ones and from function problems, where users implement a function and a
+// generated harness does the input and output
+type ProblemType string
+
+const (
+	ProblemTypeStandard    ProblemType = "standard"
+	ProblemTypeInteractive ProblemType = "interactive"
+	ProblemTypeFunction    ProblemType = "function"
+)
+
+// FunctionSignature is the function of a function problem. Each test input has
+// one line per parameter holding its JSON value; the expected output is the
+// return value as printed by the harness.
+type FunctionSignature struct {
+	Name       string          `json:"name"`
+	Params     []FunctionParam `json:"params"`
+	ReturnType string          `json:"return_type"`
+}
+
+// FunctionParam is a parameter of a FunctionSignature. Types are "int", "long",
+// "double", "bool", "string", "int[]", "long[]", "double[]", "string[]" and "int[][]".
+type FunctionParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Value implementation for driver.Valuer
+func (f FunctionSignature) Value() (driver.Value, error) {
+	return json.Marshal(f)
 }
 
+// Scan implementation for sql.Scanner
+func (f *FunctionSignature) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+// ComparisonMode selects how program output is matched against the expected output
+type ComparisonMode string
+
+const (
+	// ComparisonExact compares trimmed output byte for byte
+	ComparisonExact ComparisonMode = "exact"
+	// ComparisonTokens compares whitespace-separated tokens
+	ComparisonTokens ComparisonMode = "tokens"
+	// ComparisonFloat compares tokens, treating numbers as equal within an absolute or relative epsilon
+	ComparisonFloat ComparisonMode = "float"
+	// ComparisonUnordered compares the lines as a multiset, in any order
+	ComparisonUnordered ComparisonMode = "unordered"
+	// ComparisonCaseInsensitive compares tokens ignoring letter case
+	ComparisonCaseInsensitive ComparisonMode = "case_insensitive"
+)
+
 // BeforeCreate sets UUID before creating record
 func (f *FocusArea) BeforeCreate(tx *gorm.DB) error {
 	if f.ID == uuid.Nil {
@@ -82,12 +192,26 @@ type ProblemGenerationRequest struct {
 
 // ProblemGenerationResponse represents the LLM response format
 type ProblemGenerationResponse struct {
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	FocusArea   string       `json:"focus_area"`
-	Rating      int          `json:"rating"`
-	SampleCases TestCaseList `json:"sample_cases"`
-	HiddenCases TestCaseList `json:"hidden_cases"`
+	Title          string            `json:"title"`
+	Description    string            `json:"description"`
+	FocusArea      string            `json:"focus_area"`
+	Rating         int               `json:"rating"`
+	SampleCases    TestCaseList      `json:"sample_cases"`
+	HiddenCases    TestCaseList      `json:"hidden_cases"`
+	ComparisonMode ComparisonMode    `json:"comparison_mode"`
+	FloatEpsilon   float64           `json:"float_epsilon,omitempty"`
+	CheckerCode    string            `json:"checker_code,omitempty"`
+	ProblemType    ProblemType       `json:"problem_type,omitempty"`
+	InteractorCode string            `json:"interactor_code,omitempty"`
+	GeneratorCode  string            `json:"generator_code,omitempty"`
+	GeneratedTests GeneratedTestList `json:"generated_tests,omitempty"`
+	ReferenceCode  string            `json:"reference_code,omitempty"`
+	ValidatorCode  string            `json:"validator_code,omitempty"`
+	TimeLimitMs    int               `json:"time_limit_ms,omitempty"`
+	MemoryLimitMB  int               `json:"memory_limit_mb,omitempty"`
+	OutputLimitMB  int               `json:"output_limit_mb,omitempty"`
+	// Signature is set for function problems
+	Signature *FunctionSignature `json:"signature,omitempty"`
 }
 
 // ExecutionRequest represents a code execution request
@@ -96,25 +220,286 @@ type ExecutionRequest struct {
 	ProblemID   string     `json:"problem_id" binding:"required"`
 	Language    string     `json:"language"` // "cpp", "python", "java", "javascript"
 	CustomCases []TestCase `json:"custom_cases"`
-	Mode        string     `json:"mode"` // "run" or "submit"
-}
+	// Mode is "run", "submit", "debug" (run with sanitizers) or "analyze"
+	// (submit, then estimate the time complexity of an accepted solution)
+	Mode string `json:"mode"`
+	// Toolchain selects language options, e.g. {"standard": "c++17", "optimization": "O2"} or {"version": "11"}
+	Toolchain map[string]string `json:"toolchain,omitempty"`
+	// ClaimedComplexity is compared with the estimate in analyze mode, e.g. "O(n log n)"
+	ClaimedComplexity string `json:"claimed_complexity,omitempty"`
+}
+
+// Verdict is the judge outcome of a test case or of a whole execution
+type Verdict string
+
+const (
+	VerdictAccepted            Verdict = "AC"
+	VerdictWrongAnswer         Verdict = "WA"
+	VerdictTimeLimitExceeded   Verdict = "TLE"
+	VerdictMemoryLimitExceeded Verdict = "MLE"
+	VerdictRuntimeError        Verdict = "RE"
+	VerdictCompilationError    Verdict = "CE"
+	VerdictOutputLimitExceeded Verdict = "OLE"
+)
 
 // ExecutionResult represents the result of a test case execution
 type ExecutionResult struct {
-	CaseNumber     int    `json:"case_number"`
-	Input          string `json:"input"`
-	ExpectedOutput string `json:"expected_output"`
-	ActualOutput   string `json:"actual_output"`
-	Passed         bool   `json:"passed"`
-	Error          string `json:"error,omitempty"`
+	CaseNumber     int     `json:"case_number"`
+	Input          string  `json:"input"`
+	ExpectedOutput string  `json:"expected_output"`
+	ActualOutput   string  `json:"actual_output"`
+	Passed         bool    `json:"passed"`
+	Verdict        Verdict `json:"verdict"`
+	Error          string  `json:"error,omitempty"`
+	CheckerMessage string  `json:"checker_message,omitempty"`
+	QueryCount     int     `json:"query_count,omitempty"`
+	TimeMs         int64   `json:"time_ms"`
+	CPUTimeMs      int64   `json:"cpu_time_ms"`
+	MemoryKB       int64   `json:"memory_kb"`
+	// SanitizerFindings are the AddressSanitizer/UBSan reports of a debug run
+	SanitizerFindings []SanitizerFinding `json:"sanitizer_findings,omitempty"`
+	// Diff locates the differences from the expected output on a wrong answer
+	Diff *OutputDiff `json:"diff,omitempty"`
+	// OutputBytes is the size of the program's stdout, counted up to the output
+	// limit. OutputTruncated is set when ActualOutput only holds its start.
+	OutputBytes     int64 `json:"output_bytes"`
+	OutputTruncated bool  `json:"output_truncated,omitempty"`
+}
+
+// OutputDiff describes how the actual output of a test case differs from the
+// expected output. Positions are 1-based; tokens are counted within their line.
+type OutputDiff struct {
+	FirstMismatchLine  int    `json:"first_mismatch_line"`
+	FirstMismatchToken int    `json:"first_mismatch_token"`
+	ExpectedToken      string `json:"expected_token"` // Empty when the expected line ends there
+	ActualToken        string `json:"actual_token"`   // Empty when the actual line ends there
+	ExpectedLines      int    `json:"expected_lines"`
+	ActualLines        int    `json:"actual_lines"`
+	// Hunks are the changed regions with a few lines of context, like a unified diff
+	Hunks []DiffHunk `json:"hunks"`
+	// Truncated is set when the hunks do not cover every difference, or lines were shortened
+	Truncated bool   `json:"truncated,omitempty"`
+	Summary   string `json:"summary"`
+}
+
+// DiffHunk is one changed region of an output diff
+type DiffHunk struct {
+	ExpectedStart int        `json:"expected_start"`
+	ExpectedCount int        `json:"expected_count"`
+	ActualStart   int        `json:"actual_start"`
+	ActualCount   int        `json:"actual_count"`
+	Lines         []DiffLine `json:"lines"`
+}
+
+// DiffLine is a line of a diff hunk
+type DiffLine struct {
+	Kind string `json:"kind"` // "context", "expected" (missing from the output) or "actual" (not expected)
+	Text string `json:"text"`
+}
+
+// SanitizerFinding is one error reported by a sanitizer during a debug run
+type SanitizerFinding struct {
+	Sanitizer string       `json:"sanitizer"` // "AddressSanitizer", "UndefinedBehaviorSanitizer"
+	Kind      string       `json:"kind"`      // e.g. "heap-buffer-overflow", "signed-integer-overflow"
+	Message   string       `json:"message"`
+	File      string       `json:"file,omitempty"` // Location in the user's source, when known
+	Line      int          `json:"line,omitempty"`
+	Column    int          `json:"column,omitempty"`
+	Stack     []StackFrame `json:"stack,omitempty"`
+}
+
+// StackFrame is one frame of a sanitizer stack trace
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// CompileDiagnostic is a single error or warning reported by a compiler
+type CompileDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // "error", "warning"
+	Message  string `json:"message"`
 }
 
 // ExecutionResponse represents the response from code execution
 type ExecutionResponse struct {
-	Success     bool              `json:"success"`
-	Results     []ExecutionResult `json:"results"`
-	TotalPassed int               `json:"total_passed"`
-	TotalCases  int               `json:"total_cases"`
+	Success       bool                `json:"success"`
+	Verdict       Verdict             `json:"verdict"`
+	Results       []ExecutionResult   `json:"results"`
+	TotalPassed   int                 `json:"total_passed"`
+	TotalCases    int                 `json:"total_cases"`
+	MaxTimeMs     int64               `json:"max_time_ms"`
+	MaxMemoryKB   int64               `json:"max_memory_kb"`
+	CompileOutput string              `json:"compile_output,omitempty"`
+	Diagnostics   []CompileDiagnostic `json:"diagnostics,omitempty"`
+	// SubmissionID is the stored submission of the execution, if it was recorded
+	SubmissionID *uuid.UUID `json:"submission_id,omitempty"`
+	// ArtifactID identifies the full test case files of the execution, which
+	// can be downloaded until they expire
+	ArtifactID *uuid.UUID `json:"artifact_id,omitempty"`
+	// Complexity is the estimated time complexity of an accepted solution in analyze mode
+	Complexity *ComplexityEstimate `json:"complexity,omitempty"`
+}
+
+// ComplexityEstimate is the time complexity of a solution estimated from its
+// running times on generated inputs of growing size
+type ComplexityEstimate struct {
+	// Class is the best fitting complexity class, e.g. "O(n log n)", or empty
+	// when the running times are too short or too few to fit
+	Class      string             `json:"class"`
+	Confidence float64            `json:"confidence"` // From 0 to 1
+	Samples    []ComplexitySample `json:"samples"`
+	Fits       []ComplexityFit    `json:"fits"` // Best fit first
+	// ClaimedClass is the user's claim in normalized form; ClaimMatches is unset
+	// when there is no claim or no estimate
+	ClaimedClass string `json:"claimed_class,omitempty"`
+	ClaimMatches *bool  `json:"claim_matches,omitempty"`
+	Note         string `json:"note,omitempty"`
+}
+
+// ComplexitySample is the running time of the solution for one input size.
+// Samples after the first one that did not pass are not measured.
+type ComplexitySample struct {
+	N       int     `json:"n"`
+	TimeMs  int64   `json:"time_ms"` // Fastest CPU time of the repeated runs
+	Verdict Verdict `json:"verdict"`
+}
+
+// ComplexityFit is how well a complexity class explains the running times
+type ComplexityFit struct {
+	Class    string  `json:"class"`
+	RSquared float64 `json:"r_squared"`
+}
+
+// Value implementation for driver.Valuer
+func (r ExecutionResponse) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan implementation for sql.Scanner
+func (r *ExecutionResponse) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// StressProgram is one of the programs of a stress test
+type StressProgram struct {
+	Code      string            `json:"code" binding:"required"`
+	Language  string            `json:"language"`
+	Toolchain map[string]string `json:"toolchain,omitempty"`
+}
+
+// StressRequest represents a stress test: the generator prints a random input
+// for the seed it gets as its only argument, and the outputs of the brute force
+// and the solution on that input are compared
+type StressRequest struct {
+	Generator      StressProgram  `json:"generator" binding:"required"`
+	Brute          StressProgram  `json:"brute" binding:"required"`
+	Solution       StressProgram  `json:"solution" binding:"required"`
+	Iterations     int            `json:"iterations"`      // Stop after this many inputs
+	TimeBudgetMs   int            `json:"time_budget_ms"`  // Stop after this much time
+	ComparisonMode ComparisonMode `json:"comparison_mode"` // Defaults to exact
+	FloatEpsilon   float64        `json:"float_epsilon,omitempty"`
+}
+
+// StressResponse represents the outcome of a stress test
+type StressResponse struct {
+	Found          bool                  `json:"found"` // A counterexample was found
+	Iterations     int                   `json:"iterations"`
+	ElapsedMs      int64                 `json:"elapsed_ms"`
+	Counterexample *StressCounterexample `json:"counterexample,omitempty"`
+	// Failure is set when the generator or the brute force did not build or
+	// crashed, or the solution did not build, which ends the stress test
+	Failure *StressFailure `json:"failure,omitempty"`
+}
+
+// StressCounterexample is an input on which the solution disagrees with the brute force
+type StressCounterexample struct {
+	Iteration      int     `json:"iteration"`
+	Seed           int     `json:"seed"`
+	Input          string  `json:"input"`
+	BruteOutput    string  `json:"brute_output"`
+	SolutionOutput string  `json:"solution_output"`
+	Verdict        Verdict `json:"verdict"` // WA, or the solution's TLE, MLE or RE
+	Error          string  `json:"error,omitempty"`
+}
+
+// StressFailure describes a program that prevented the stress test from running
+type StressFailure struct {
+	Program       string              `json:"program"` // "generator", "brute" or "solution"
+	Verdict       Verdict             `json:"verdict"`
+	Error         string              `json:"error,omitempty"`
+	Seed          int                 `json:"seed,omitempty"`
+	Input         string              `json:"input,omitempty"`
+	CompileOutput string              `json:"compile_output,omitempty"`
+	Diagnostics   []CompileDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// ExecutionJobStatus is the progress of an asynchronous execution
+type ExecutionJobStatus string
+
+const (
+	ExecutionJobQueued    ExecutionJobStatus = "queued"
+	ExecutionJobCompiling ExecutionJobStatus = "compiling"
+	ExecutionJobRunning   ExecutionJobStatus = "running"
+	ExecutionJobDone      ExecutionJobStatus = "done"
+	ExecutionJobFailed    ExecutionJobStatus = "failed" // The code could not be judged, see Error
+)
+
+// ExecutionJob is an execution running in the background. Clients poll it by ID.
+type ExecutionJob struct {
+	ID         uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	ProblemID  string             `gorm:"type:varchar(255);not null" json:"problem_id"` // Problem UUID, "testing" or "playground"
+	Language   string             `gorm:"type:varchar(30);not null" json:"language"`
+	Mode       string             `gorm:"type:varchar(20);not null" json:"mode"`
+	Status     ExecutionJobStatus `gorm:"type:varchar(20);not null;default:'queued';index" json:"status"`
+	Result     *ExecutionResponse `gorm:"type:jsonb" json:"result,omitempty"`
+	Error      *string            `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+}
+
+// BeforeCreate sets UUID before creating record
+func (j *ExecutionJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// Submission is a judged execution of a user's code on a problem or a session problem
+type Submission struct {
+	ID               uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID           uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	ProblemID        *uuid.UUID         `gorm:"type:uuid;index" json:"problem_id,omitempty"`
+	SessionProblemID *uuid.UUID         `gorm:"type:uuid;index" json:"session_problem_id,omitempty"`
+	Language         string             `gorm:"type:varchar(30);not null" json:"language"`
+	Code             string             `gorm:"type:text;not null" json:"code,omitempty"` // Left out of submission lists
+	Mode             string             `gorm:"type:varchar(20);not null" json:"mode"`
+	Verdict          Verdict            `gorm:"type:varchar(10);not null" json:"verdict"`
+	TotalPassed      int                `gorm:"not null" json:"total_passed"`
+	TotalCases       int                `gorm:"not null" json:"total_cases"`
+	MaxTimeMs        int64              `gorm:"not null" json:"max_time_ms"`
+	MaxMemoryKB      int64              `gorm:"not null" json:"max_memory_kb"`
+	Result           *ExecutionResponse `gorm:"type:jsonb" json:"result,omitempty"` // Per-case results, left out of submission lists
+	CreatedAt        time.Time          `gorm:"index" json:"created_at"`
+}
+
+// BeforeCreate sets UUID before creating record
+func (s *Submission) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
 }
 
 // ============================================================================
@@ -286,7 +671,23 @@ type SessionProblem struct {
 	ProblemID     *uuid.UUID  `gorm:"type:uuid" json:"problem_id"`
 	ProblemData   ProblemData `gorm:"type:jsonb" json:"problem_data"`
 	GeneratedAt   *time.Time  `json:"generated_at"`
-	ErrorMessage  *string     `gorm:"type:text" json:"error_message"`
+	ErrorMessage  *string     `gorm:"type:text" json:"error_message"` // Why generation failed or the problem is unverified
+}
+
+// Problem statuses, shared by problems and session problems
+const (
+	ProblemStatusGenerating = "generating"
+	// ProblemStatusReady problems passed verification by their reference solution
+	ProblemStatusReady = "ready"
+	// ProblemStatusUnverified problems can be solved, but their reference solution
+	// disagreed with some expected outputs or could not be run
+	ProblemStatusUnverified = "unverified"
+	ProblemStatusFailed     = "failed"
+)
+
+// IsPlayable reports whether the problem has been generated and can be solved
+func (s *SessionProblem) IsPlayable() bool {
+	return s.Status == ProblemStatusReady || s.Status == ProblemStatusUnverified
 }
 
 // BeforeCreate sets UUID before creating record
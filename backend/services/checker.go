@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+const CHECKER_TIMEOUT = 5 * time.Second
+
+// Checker exit codes, compatible with testlib
+const (
+	CHECKER_EXIT_ACCEPTED           = 0
+	CHECKER_EXIT_WRONG_ANSWER       = 1
+	CHECKER_EXIT_PRESENTATION_ERROR = 2
+)
+
+// checkerLimits returns the sandbox limits for checker programs
+func checkerLimits() SandboxLimits {
+	return SandboxLimits{
+		WallTime:     CHECKER_TIMEOUT,
+		CPUTime:      CHECKER_TIMEOUT,
+		AddressSpace: SANDBOX_MAX_ADDRESS_SPACE,
+		FileSize:     SANDBOX_MAX_FILE_SIZE,
+		Processes:    SANDBOX_MAX_PROCESSES,
+		Stack:        SANDBOX_MAX_STACK,
+	}
+}
+
+// prepareJudgeProgram compiles a problem-supplied C++ judge program (checker
+// or interactor), or reuses the cached binary, and copies it into a fresh
+// working directory. Judge programs get their own directory so that user
+// programs cannot read the test data.
+func (s *ExecutionService) prepareJudgeProgram(ctx context.Context, opts ExecutionOptions, code string, name string) (string, error) {
+	binary, err := s.judgeProgramBinary(ctx, opts, code, name)
+	if err != nil {
+		return "", err
+	}
+
+	judgeDir, err := s.sandbox.CreateWorkDir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := copyFile(binary, filepath.Join(judgeDir, name), 0755); err != nil {
+		s.sandbox.RemoveWorkDir(judgeDir)
+		return "", fmt.Errorf("failed to copy %s: %w", name, err)
+	}
+
+	return judgeDir, nil
+}
+
+// judgeProgramBinary returns the path of a compiled judge program, compiling
+// it on the first use. Binaries are cached on disk by the hash of their source.
+func (s *ExecutionService) judgeProgramBinary(ctx context.Context, opts ExecutionOptions, code string, name string) (string, error) {
+	hash := sha256.Sum256([]byte(code))
+	cachePath := filepath.Join(config.Config.Execution.CheckerCacheDir, hex.EncodeToString(hash[:]))
+
+	if _, err := os.Stat(cachePath); err == nil {
+		return cachePath, nil
+	}
+
+	// Concurrent judges of the same program wait for one compilation. It is not
+	// cancelled with the request that started it, since the others need it too.
+	build := s.judgeBuilds.DoChan(cachePath, func() (interface{}, error) {
+		return nil, s.compileJudgeProgram(context.WithoutCancel(ctx), opts, code, name, cachePath)
+	})
+	select {
+	case result := <-build:
+		if result.Err != nil {
+			return "", result.Err
+		}
+		return cachePath, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// compileJudgeProgram compiles a judge program into the cache at cachePath
+func (s *ExecutionService) compileJudgeProgram(ctx context.Context, opts ExecutionOptions, code string, name string, cachePath string) error {
+	// A build that finished while this one was being started
+	if _, err := os.Stat(cachePath); err == nil {
+		return nil
+	}
+
+	workDir, err := s.sandbox.CreateWorkDir()
+	if err != nil {
+		return err
+	}
+	defer s.sandbox.RemoveWorkDir(workDir)
+
+	if err := os.WriteFile(filepath.Join(workDir, name+".cpp"), []byte(code), 0644); err != nil {
+		return fmt.Errorf("failed to write %s source: %w", name, err)
+	}
+
+	log.Printf("Compiling %s %s", name, filepath.Base(cachePath))
+	if output, err := s.compile(ctx, opts, workDir, COMPILE_TIMEOUT, nil, []string{"g++", "-O2", "-std=c++17", name + ".cpp", "-o", name}); err != nil {
+		// Not a CompilationError of the user's code
+		return fmt.Errorf("failed to compile %s: %s", name, strings.TrimSpace(output))
+	}
+
+	if err := os.MkdirAll(config.Config.Execution.CheckerCacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s cache: %w", name, err)
+	}
+
+	// Write then rename so a crash never leaves a truncated binary in the cache
+	tmpPath := cachePath + ".tmp"
+	if err := copyFile(filepath.Join(workDir, name), tmpPath, 0755); err != nil {
+		return fmt.Errorf("failed to cache %s: %w", name, err)
+	}
+	if err := os.Rename(tmpPath, cachePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to cache %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// runChecker grades one test case with the checker. It is called as
+// "checker <input> <expected> <output>" and reports the verdict through its
+// exit code and an optional message on stderr.
+func (s *ExecutionService) runChecker(ctx context.Context, checkerDir string, testCase models.TestCase, output io.Reader, caseNumber int) (bool, string, error) {
+	inputFile := fmt.Sprintf("input-%d.txt", caseNumber)
+	expectedFile := fmt.Sprintf("expected-%d.txt", caseNumber)
+	outputFile := fmt.Sprintf("output-%d.txt", caseNumber)
+
+	files := map[string]io.Reader{
+		inputFile:    strings.NewReader(testCase.Input),
+		expectedFile: strings.NewReader(testCase.ExpectedOutput),
+		outputFile:   output,
+	}
+	for name, content := range files {
+		path := filepath.Join(checkerDir, name)
+		if err := writeFile(path, content, 0644); err != nil {
+			return false, "", fmt.Errorf("failed to write checker file: %w", err)
+		}
+		// The output can be as large as the output limit
+		defer os.Remove(path)
+	}
+
+	var stdout, stderr bytes.Buffer
+	checkResult, err := s.sandbox.Run(ctx, SandboxCommand{
+		Dir:    checkerDir,
+		Path:   "./checker",
+		Args:   []string{inputFile, expectedFile, outputFile},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Limits: checkerLimits(),
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to run checker: %w", err)
+	}
+
+	message := strings.TrimSpace(stderr.String())
+	if message == "" {
+		message = strings.TrimSpace(stdout.String())
+	}
+
+	if checkResult.TimedOut {
+		return false, "", fmt.Errorf("checker timeout (%s limit exceeded)", CHECKER_TIMEOUT)
+	}
+
+	switch checkResult.ExitCode {
+	case CHECKER_EXIT_ACCEPTED:
+		return true, message, nil
+	case CHECKER_EXIT_WRONG_ANSWER, CHECKER_EXIT_PRESENTATION_ERROR:
+		return false, message, nil
+	default:
+		return false, "", fmt.Errorf("checker failed with exit code %d: %s", checkResult.ExitCode, message)
+	}
+}
+
+// copyFile copies a file, creating or truncating the destination
+func copyFile(src string, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFile(dst, in, perm)
+}
+
+// writeFile writes everything read from r to a file, creating or truncating it
+func writeFile(path string, r io.Reader, perm os.FileMode) error {
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
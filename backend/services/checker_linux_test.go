@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// nearChecker accepts answers within 1 of the expected number and crashes on
+// a negative answer
+const nearChecker = `#include <cstdio>
+#include <cstdlib>
+int main(int argc, char** argv) {
+    FILE* expected = fopen(argv[2], "r");
+    FILE* output = fopen(argv[3], "r");
+    long long e, a;
+    if (fscanf(expected, "%lld", &e) != 1) return 3;
+    if (fscanf(output, "%lld", &a) != 1) {
+        fprintf(stderr, "no answer");
+        return 1;
+    }
+    if (a < 0) abort();
+    if (a < e - 1 || a > e + 1) {
+        fprintf(stderr, "expected %lld, got %lld", e, a);
+        return 1;
+    }
+    fprintf(stderr, "close enough");
+    return 0;
+}
+`
+
+func TestChecker(t *testing.T) {
+	s := newTestExecutionService(t)
+	opts := ExecutionOptions{CheckerCode: nearChecker}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    models.Verdict
+		message string
+		error   string
+	}{
+		{"accept", "5", models.VerdictAccepted, "close enough", ""},
+		{"reject", "5", models.VerdictWrongAnswer, "expected 9, got 5", ""},
+		{"crash", "-5", "", "", "checker failed"},
+	}
+	expected := map[string]string{"accept": "6", "reject": "9", "crash": "5"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCases := []models.TestCase{{Input: tt.input, ExpectedOutput: expected[tt.name]}}
+			response, err := s.Execute(context.Background(), echoProgram, testCases, "cpp", opts)
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Fatalf("got error %v, want %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := response.Results[0]
+			if result.Verdict != tt.want || result.CheckerMessage != tt.message {
+				t.Errorf("got %s %q, want %s %q", result.Verdict, result.CheckerMessage, tt.want, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckerCache(t *testing.T) {
+	s := newTestExecutionService(t)
+
+	// Concurrent judges of a new checker share one compilation
+	paths := make([]string, 4)
+	errs := make([]error, 4)
+	var wg sync.WaitGroup
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			paths[i], errs[i] = s.judgeProgramBinary(context.Background(), ExecutionOptions{}, nearChecker, "checker")
+		}()
+	}
+	wg.Wait()
+	for i := range paths {
+		if errs[i] != nil || paths[i] != paths[0] {
+			t.Fatalf("judge %d got %q, %v; want %q", i, paths[i], errs[i], paths[0])
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(paths[0]))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("checker cache holds %d entries (%v), want the binary only", len(entries), err)
+	}
+
+	// Identical source reuses the cached binary, here replaced by one that
+	// accepts everything
+	if err := os.WriteFile(paths[0], []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []models.TestCase{{Input: "5", ExpectedOutput: "9"}}
+	response, err := s.Execute(context.Background(), echoProgram, testCases, "cpp", ExecutionOptions{CheckerCode: nearChecker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Verdict != models.VerdictAccepted {
+		t.Errorf("got %s, want the cached checker to accept", response.Verdict)
+	}
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// DEFAULT_FLOAT_EPSILON is used by float comparison when the problem does not set one
+const DEFAULT_FLOAT_EPSILON = 1e-6
+
+// COMPARE_MAX_TOKEN_BYTES bounds how much longer than the longest expected
+// token an output token may be before it is rejected unread; longer tokens
+// only match in float mode, as numbers padded with digits
+const COMPARE_MAX_TOKEN_BYTES = 1 << 20
+
+// CompareOutput reports whether actual output matches expected output under the
+// given comparison mode. Unknown modes fall back to exact comparison.
+func CompareOutput(expected string, actual string, mode models.ComparisonMode, epsilon float64) bool {
+	// Reading a string cannot fail
+	matches, _ := CompareOutputReader(expected, strings.NewReader(actual), mode, epsilon)
+	return matches
+}
+
+// CompareOutputReader is CompareOutput for actual output read from r. The
+// output is compared as it is read, holding no more than a token or line of
+// it in memory; the error is only set when r fails.
+func CompareOutputReader(expected string, actual io.Reader, mode models.ComparisonMode, epsilon float64) (bool, error) {
+	switch mode {
+	case models.ComparisonTokens:
+		return compareTokenStream(expected, actual, func(e, a string) bool { return e == a })
+	case models.ComparisonCaseInsensitive:
+		return compareTokenStream(expected, actual, strings.EqualFold)
+	case models.ComparisonFloat:
+		if epsilon <= 0 {
+			epsilon = DEFAULT_FLOAT_EPSILON
+		}
+		return compareTokenStream(expected, actual, func(e, a string) bool { return floatTokensEqual(e, a, epsilon) })
+	case models.ComparisonUnordered:
+		return compareUnorderedLines(expected, actual)
+	default:
+		return compareExact(expected, actual)
+	}
+}
+
+// compareExact compares both outputs without their leading and trailing whitespace
+func compareExact(expected string, actual io.Reader) (bool, error) {
+	r := bufio.NewReader(actual)
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			return strings.TrimSpace(expected) == "", nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !unicode.IsSpace(c) {
+			r.UnreadRune()
+			break
+		}
+	}
+
+	chunk := make([]byte, 32<<10)
+	for rest := strings.TrimSpace(expected); rest != ""; {
+		n := min(len(rest), len(chunk))
+		if _, err := io.ReadFull(r, chunk[:n]); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if string(chunk[:n]) != rest[:n] {
+			return false, nil
+		}
+		rest = rest[n:]
+	}
+
+	// Only whitespace may follow
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !unicode.IsSpace(c) {
+			return false, nil
+		}
+	}
+}
+
+// compareTokenStream compares the tokens of actual output, read as it is
+// split on any whitespace, with those of the expected output
+func compareTokenStream(expected string, actual io.Reader, equal func(e, a string) bool) (bool, error) {
+	expectedTokens := strings.Fields(expected)
+	longest := 0
+	for _, token := range expectedTokens {
+		longest = max(longest, len(token))
+	}
+
+	scanner := bufio.NewScanner(actual)
+	scanner.Buffer(make([]byte, 0, 64<<10), longest+COMPARE_MAX_TOKEN_BYTES)
+	scanner.Split(bufio.ScanWords)
+	for _, token := range expectedTokens {
+		if !scanner.Scan() {
+			return false, scannerError(scanner)
+		}
+		if !equal(token, scanner.Text()) {
+			return false, nil
+		}
+	}
+	if scanner.Scan() || scanner.Err() != nil {
+		// Tokens too long to buffer are extra output too
+		return false, scannerError(scanner)
+	}
+	return true, nil
+}
+
+// scannerError returns the read error of a scanner. Tokens too long to
+// buffer are not an error, they fail the comparison.
+func scannerError(scanner *bufio.Scanner) error {
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return err
+	}
+	return nil
+}
+
+// compareTokens splits both outputs on any whitespace and compares token by token
+func compareTokens(expected string, actual string, equal func(e, a string) bool) bool {
+	expectedTokens := strings.Fields(expected)
+	actualTokens := strings.Fields(actual)
+	if len(expectedTokens) != len(actualTokens) {
+		return false
+	}
+	for i := range expectedTokens {
+		if !equal(expectedTokens[i], actualTokens[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// floatTokensEqual compares two tokens as numbers when both parse, accepting an
+// absolute or relative error of at most epsilon. Other tokens must match exactly.
+func floatTokensEqual(expected string, actual string, epsilon float64) bool {
+	if expected == actual {
+		return true
+	}
+
+	e, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+	a, err := strconv.ParseFloat(actual, 64)
+	if err != nil || math.IsNaN(a) || math.IsInf(a, 0) {
+		return false
+	}
+
+	diff := math.Abs(e - a)
+	return diff <= epsilon || diff <= epsilon*math.Abs(e)
+}
+
+// compareUnorderedLines compares non-empty lines as a multiset. Whitespace
+// inside each line is normalized so that spacing differences do not matter.
+func compareUnorderedLines(expected string, actual io.Reader) (bool, error) {
+	remaining := make(map[string]int)
+	lines, longest := 0, 0
+	for _, line := range strings.Split(expected, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			normalized := strings.Join(fields, " ")
+			remaining[normalized]++
+			lines++
+			longest = max(longest, len(normalized))
+		}
+	}
+
+	// Actual lines are normalized as they are read; one longer than every
+	// expected line cannot match
+	r := bufio.NewReader(actual)
+	var line []byte
+	space := false
+	for {
+		c, size, err := r.ReadRune()
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if err == io.EOF || c == '\n' {
+			if len(line) > 0 {
+				if remaining[string(line)] == 0 {
+					return false, nil
+				}
+				remaining[string(line)]--
+				lines--
+				line, space = line[:0], false
+			}
+			if err == io.EOF {
+				return lines == 0, nil
+			}
+			continue
+		}
+
+		if unicode.IsSpace(c) {
+			space = len(line) > 0
+			continue
+		}
+		if space {
+			line = append(line, ' ')
+			space = false
+		}
+		if c == utf8.RuneError && size == 1 {
+			// Invalid bytes are kept as they are
+			r.UnreadRune()
+			b, _ := r.ReadByte()
+			line = append(line, b)
+		} else {
+			line = utf8.AppendRune(line, c)
+		}
+		if len(line) > longest {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+func TestCompareOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     models.ComparisonMode
+		epsilon  float64
+		expected string
+		actual   string
+		want     bool
+	}{
+		{"exact match", models.ComparisonExact, 0, "1 2\n3", "1 2\n3", true},
+		{"exact trims surrounding whitespace", models.ComparisonExact, 0, "1 2\n3\n", "\n1 2\n3  \n\n", true},
+		{"exact keeps inner whitespace", models.ComparisonExact, 0, "1 2", "1  2", false},
+		{"unknown mode is exact", "", 0, "1 2", "1  2", false},
+
+		{"tokens ignore spacing and line breaks", models.ComparisonTokens, 0, "1 2\n3", "1\n2   3\n", true},
+		{"tokens differ", models.ComparisonTokens, 0, "1 2 3", "1 2 4", false},
+		{"tokens missing", models.ComparisonTokens, 0, "1 2 3", "1 2", false},
+		{"tokens extra", models.ComparisonTokens, 0, "1 2", "1 2 3", false},
+		{"tokens are case sensitive", models.ComparisonTokens, 0, "YES", "yes", false},
+
+		{"float within absolute error", models.ComparisonFloat, 1e-6, "0.333333", "0.3333334", true},
+		{"float within relative error", models.ComparisonFloat, 1e-6, "1000000000", "1000000500", true},
+		{"float outside error", models.ComparisonFloat, 1e-6, "0.5", "0.501", false},
+		{"float default epsilon", models.ComparisonFloat, 0, "2.0000000", "2.0000001", true},
+		{"float formatting differs", models.ComparisonFloat, 1e-6, "2", "2.000000", true},
+		{"float words must match", models.ComparisonFloat, 1e-6, "answer 1.5", "result 1.5", false},
+		{"float rejects nan", models.ComparisonFloat, 1e-6, "1.5", "nan", false},
+		{"float rejects inf", models.ComparisonFloat, 1e-6, "1e308", "inf", false},
+		{"float token count", models.ComparisonFloat, 1e-6, "1.5 2.5", "1.5", false},
+
+		{"unordered lines", models.ComparisonUnordered, 0, "a 1\nb 2\nc 3", "c 3\na 1\nb 2", true},
+		{"unordered normalizes spacing and blank lines", models.ComparisonUnordered, 0, "a 1\nb 2", "\nb   2\n\na 1  \n", true},
+		{"unordered is a multiset", models.ComparisonUnordered, 0, "a\na\nb", "a\nb\nb", false},
+		{"unordered keeps tokens within a line", models.ComparisonUnordered, 0, "1 2", "2 1", false},
+
+		{"unordered line longer than any expected", models.ComparisonUnordered, 0, "a\nb", "a\nb b", false},
+		{"unordered keeps invalid bytes", models.ComparisonUnordered, 0, "a\xff", "a\xfe", false},
+
+		{"tokens extra token too long to buffer", models.ComparisonTokens, 0, "1", "1 " + strings.Repeat("x", COMPARE_MAX_TOKEN_BYTES+2), false},
+		{"tokens token too long to buffer", models.ComparisonTokens, 0, "1 2", "1 " + strings.Repeat("x", COMPARE_MAX_TOKEN_BYTES+2), false},
+		{"exact long output", models.ComparisonExact, 0, strings.Repeat("1 2\n", 50000), strings.Repeat("1 2\n", 50000), true},
+		{"exact output ends early", models.ComparisonExact, 0, "1 2 3", "1 2", false},
+		{"exact trims unicode whitespace", models.ComparisonExact, 0, "1 2", "\u00a01 2\u2003", true},
+
+		{"case insensitive", models.ComparisonCaseInsensitive, 0, "YES\nNo", "yes NO", true},
+		{"case insensitive still compares tokens", models.ComparisonCaseInsensitive, 0, "YES", "YESS", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := CompareOutput(test.expected, test.actual, test.mode, test.epsilon)
+			if got != test.want {
+				t.Errorf("CompareOutput(%q, %q, %q, %g) = %v, want %v", test.expected, test.actual, test.mode, test.epsilon, got, test.want)
+			}
+		})
+	}
+}
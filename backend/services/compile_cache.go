@@ -0,0 +1,255 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CompileCache stores build artifacts on disk, keyed by a hash of the source
+// and the toolchain that compiled it. Each entry is a directory holding the
+// files the compiler produced. When the cache grows past its size limit the
+// least recently used entries are evicted. The expanded generated tests are
+// kept in a cache of their own.
+type CompileCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*compileCacheEntry
+	size    int64
+}
+
+type compileCacheEntry struct {
+	size     int64
+	lastUsed time.Time
+	readers  int // Restores copying from the entry, which must not be evicted meanwhile
+}
+
+// NewCompileCache opens the cache directory and indexes existing entries
+func NewCompileCache(dir string, maxBytes int64) (*CompileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create compile cache: %w", err)
+	}
+
+	c := &CompileCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*compileCacheEntry),
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compile cache: %w", err)
+	}
+	for _, dirEntry := range dirEntries {
+		path := filepath.Join(dir, dirEntry.Name())
+		// Leftovers of interrupted writes
+		if !dirEntry.IsDir() || strings.HasSuffix(dirEntry.Name(), ".tmp") {
+			os.RemoveAll(path)
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		size, err := dirSize(path)
+		if err != nil {
+			continue
+		}
+		c.entries[dirEntry.Name()] = &compileCacheEntry{size: size, lastUsed: info.ModTime()}
+		c.size += size
+	}
+
+	log.Printf("Compile cache ready: dir=%s entries=%d size=%dKB max=%dMB", dir, len(c.entries), c.size/1024, maxBytes/(1024*1024))
+	return c, nil
+}
+
+// Key derives the cache key for a build. Any change to the code, language,
+// compiler version, compile command or environment produces a different key.
+func (c *CompileCache) Key(language string, version string, command []string, env []string, code string) string {
+	hash := sha256.New()
+	for _, part := range []string{language, version, strings.Join(command, "\x00"), strings.Join(env, "\x00"), code} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Restore copies the cached artifacts for key into workDir. It reports false on a miss.
+func (c *CompileCache) Restore(key string, workDir string) bool {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		entry.lastUsed = now
+		entry.readers++
+	}
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	entryDir := filepath.Join(c.dir, key)
+	err := copyDir(entryDir, workDir)
+
+	c.mu.Lock()
+	entry.readers--
+	// Stores that finished during the copy may have left the cache over its limit
+	c.evict()
+	c.mu.Unlock()
+
+	if err != nil {
+		log.Printf("Failed to restore compile cache entry %s: %v", key, err)
+		return false
+	}
+	// The modification time carries the LRU order across restarts
+	os.Chtimes(entryDir, now, now)
+	return true
+}
+
+// Store saves the files in workDir, except the excluded ones, as the artifacts for key
+func (c *CompileCache) Store(key string, workDir string, exclude ...string) error {
+	c.mu.Lock()
+	_, exists := c.entries[key]
+	c.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	entryDir := filepath.Join(c.dir, key)
+	// Concurrent builds of the same code each write their own temporary directory
+	tmpDir, err := os.MkdirTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create compile cache entry: %w", err)
+	}
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("failed to create compile cache entry: %w", err)
+	}
+
+	files, err := os.ReadDir(workDir)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("failed to read build directory: %w", err)
+	}
+	for _, file := range files {
+		if slices.Contains(exclude, file.Name()) {
+			continue
+		}
+		if err := copyPath(filepath.Join(workDir, file.Name()), filepath.Join(tmpDir, file.Name())); err != nil {
+			os.RemoveAll(tmpDir)
+			return fmt.Errorf("failed to copy build artifact: %w", err)
+		}
+	}
+
+	size, err := dirSize(tmpDir)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("failed to measure compile cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another build of the same code may have finished first
+	if _, exists := c.entries[key]; exists {
+		os.RemoveAll(tmpDir)
+		return nil
+	}
+	if err := os.Rename(tmpDir, entryDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("failed to store compile cache entry: %w", err)
+	}
+
+	c.entries[key] = &compileCacheEntry{size: size, lastUsed: time.Now()}
+	c.size += size
+	c.evict()
+	return nil
+}
+
+// evict removes least recently used entries until the cache fits its size limit.
+// Entries being restored are skipped. The caller must hold c.mu.
+func (c *CompileCache) evict() {
+	for c.size > c.maxBytes {
+		var oldestKey string
+		var oldest *compileCacheEntry
+		for key, entry := range c.entries {
+			if entry.readers > 0 {
+				continue
+			}
+			if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+				oldestKey, oldest = key, entry
+			}
+		}
+		if oldest == nil {
+			// Evicted once the restores finish
+			return
+		}
+
+		if err := os.RemoveAll(filepath.Join(c.dir, oldestKey)); err != nil {
+			log.Printf("Failed to evict compile cache entry %s: %v", oldestKey, err)
+		}
+		delete(c.entries, oldestKey)
+		c.size -= oldest.size
+	}
+}
+
+// copyDir copies the contents of src into dst
+func copyDir(src string, dst string) error {
+	files, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := copyPath(filepath.Join(src, file.Name()), filepath.Join(dst, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyPath copies a file or a directory tree, keeping file permissions
+func copyPath(src string, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+		return copyDir(src, dst)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	return copyFile(src, dst, info.Mode().Perm())
+}
+
+// dirSize returns the total size of the regular files under path
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
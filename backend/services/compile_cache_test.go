@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// writeBuild creates a build directory holding the given files
+func writeBuild(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// restored returns the content of a restored file, or "" on a miss
+func restored(t *testing.T, cache *CompileCache, key string, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if !cache.Restore(key, dir) {
+		return ""
+	}
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("restore %s: %v", key, err)
+	}
+	return string(content)
+}
+
+func TestCompileCacheStoreRestore(t *testing.T) {
+	cache, err := NewCompileCache(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := cache.Key("cpp", "g++ 13", []string{"g++", "main.cpp"}, nil, "int main() {}")
+	if cache.Key("cpp", "g++ 14", []string{"g++", "main.cpp"}, nil, "int main() {}") == key {
+		t.Error("key does not depend on the compiler version")
+	}
+
+	build := writeBuild(t, map[string]string{"main": "binary", "main.cpp": "int main() {}"})
+	if err := cache.Store(key, build, "main.cpp"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if !cache.Restore(key, dir) {
+		t.Fatal("stored entry missed")
+	}
+	info, err := os.Stat(filepath.Join(dir, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("restored binary lost its permissions: %v", info.Mode())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.cpp")); !os.IsNotExist(err) {
+		t.Error("excluded source was cached")
+	}
+	if cache.Restore("missing", t.TempDir()) {
+		t.Error("missing entry restored")
+	}
+}
+
+func TestCompileCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCompileCache(dir, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(bytes.Repeat([]byte("x"), 100))
+	for _, key := range []string{"a", "b"} {
+		if err := cache.Store(key, writeBuild(t, map[string]string{"main": content})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Using a makes b the least recently used entry
+	if restored(t, cache, "a", "main") == "" {
+		t.Fatal("a missed")
+	}
+	if err := cache.Store("c", writeBuild(t, map[string]string{"main": content})); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if got := restored(t, cache, key, "main") != ""; got != want {
+			t.Errorf("entry %s cached = %v, want %v", key, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b")); !os.IsNotExist(err) {
+		t.Error("evicted entry left on disk")
+	}
+
+	// Entries survive a restart, leftovers of interrupted writes do not
+	if err := os.Mkdir(filepath.Join(dir, "d-123.tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewCompileCache(dir, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored(t, reopened, "c", "main") != content {
+		t.Error("entry lost on restart")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "d-123.tmp")); !os.IsNotExist(err) {
+		t.Error("temporary directory kept on restart")
+	}
+}
+
+func TestCompileCacheConcurrentStoreRestore(t *testing.T) {
+	dir := t.TempDir()
+	// Room for about four entries, so stores keep evicting
+	cache, err := NewCompileCache(dir, 4*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builds := make([]string, 8)
+	for i := range builds {
+		content := string(bytes.Repeat([]byte{byte('a' + i)}, 1024))
+		builds[i] = writeBuild(t, map[string]string{"main": content, "lib": content})
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				n := (worker + i) % len(builds)
+				key := fmt.Sprintf("key-%d", n)
+				if err := cache.Store(key, builds[n]); err != nil {
+					t.Errorf("store %s: %v", key, err)
+					return
+				}
+
+				restoreDir, err := os.MkdirTemp(t.TempDir(), "restore-")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !cache.Restore(key, restoreDir) {
+					// Evicted by another store in between, before the copy started
+					if files, _ := os.ReadDir(restoreDir); len(files) > 0 {
+						t.Errorf("restore %s: entry evicted during the copy", key)
+						return
+					}
+					continue
+				}
+				want := string(bytes.Repeat([]byte{byte('a' + n)}, 1024))
+				for _, name := range []string{"main", "lib"} {
+					content, err := os.ReadFile(filepath.Join(restoreDir, name))
+					if err != nil || string(content) != want {
+						t.Errorf("restore %s: incomplete %s: %v", key, name, err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.size > cache.maxBytes {
+		t.Errorf("cache size %d over its limit %d", cache.size, cache.maxBytes)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(cache.entries) {
+		t.Errorf("%d directories on disk for %d entries", len(files), len(cache.entries))
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+const (
+	// COMPLEXITY_SIZES input sizes are measured, up to the largest size of the
+	// problem's generated tests
+	COMPLEXITY_SIZES = 8
+
+	// COMPLEXITY_REPEATS runs of each size are timed and the fastest is kept
+	COMPLEXITY_REPEATS = 3
+
+	// COMPLEXITY_MIN_SAMPLES passing sizes are needed for an estimate
+	COMPLEXITY_MIN_SAMPLES = 4
+
+	// COMPLEXITY_MIN_GROWTH_MS is the least growth of the running time that
+	// can be told apart from timer noise
+	COMPLEXITY_MIN_GROWTH_MS = 20
+
+	// COMPLEXITY_SEED seeds the generator for every size, so that inputs of
+	// the same size are cached across analyses
+	COMPLEXITY_SEED = 1
+)
+
+// ComplexityOptions asks Execute to estimate the time complexity of an
+// accepted solution. The generator is run as "generator <seed> <n>" and the
+// reference solution checks the outputs.
+type ComplexityOptions struct {
+	GeneratorCode string
+	ReferenceCode string
+	// MaxSize is the largest n measured, see ComplexityMaxSize
+	MaxSize int
+	// Claimed is the user's claimed complexity, e.g. "O(n log n)", or empty
+	Claimed string
+}
+
+// complexityClass is a candidate growth function for running times
+type complexityClass struct {
+	name   string
+	growth func(n float64) float64
+	// maxN is the largest n the growth function can be evaluated at, 0 if any
+	maxN float64
+}
+
+// complexityClasses are fitted from the slowest growing up, so that the
+// simpler class wins a tie
+var complexityClasses = []complexityClass{
+	{name: "O(1)", growth: func(float64) float64 { return 1 }},
+	{name: "O(log n)", growth: math.Log2},
+	{name: "O(sqrt(n))", growth: math.Sqrt},
+	{name: "O(n)", growth: func(n float64) float64 { return n }},
+	{name: "O(n log n)", growth: func(n float64) float64 { return n * math.Log2(n) }},
+	{name: "O(n^2)", growth: func(n float64) float64 { return n * n }},
+	{name: "O(n^3)", growth: func(n float64) float64 { return n * n * n }},
+	{name: "O(2^n)", growth: math.Exp2, maxN: 64},
+}
+
+// complexityAliases maps normalized spellings of a complexity to its class name
+var complexityAliases = map[string]string{
+	"1":       "O(1)",
+	"logn":    "O(log n)",
+	"sqrt(n)": "O(sqrt(n))",
+	"sqrtn":   "O(sqrt(n))",
+	"n^0.5":   "O(sqrt(n))",
+	"n":       "O(n)",
+	"nlogn":   "O(n log n)",
+	"n^2":     "O(n^2)",
+	"nn":      "O(n^2)",
+	"n^3":     "O(n^3)",
+	"nnn":     "O(n^3)",
+	"2^n":     "O(2^n)",
+}
+
+// ParseComplexity normalizes a complexity written by a user, such as
+// "O(N log N)", "n^2" or "Θ(n²)", to the name of its class
+func ParseComplexity(claim string) (string, error) {
+	key := strings.NewReplacer(" ", "", "*", "", "·", "").Replace(strings.ToLower(claim))
+	for _, prefix := range []string{"o(", "θ(", "theta("} {
+		if strings.HasPrefix(key, prefix) && strings.HasSuffix(key, ")") {
+			key = key[len(prefix) : len(key)-1]
+			break
+		}
+	}
+	key = strings.NewReplacer("²", "^2", "³", "^3", "ⁿ", "^n", "√n", "sqrt(n)", "lg", "log").Replace(key)
+	key = strings.ReplaceAll(key, "log(n)", "logn")
+
+	class, ok := complexityAliases[key]
+	if !ok {
+		return "", fmt.Errorf("unrecognized complexity %q, use e.g. O(n), O(n log n) or O(n^2)", claim)
+	}
+	return class, nil
+}
+
+// ComplexityMaxSize returns the largest input size among generated tests,
+// which is their first argument, or 0 if none gives one
+func ComplexityMaxSize(tests []models.GeneratedTest) int {
+	maxSize := 0
+	for _, test := range tests {
+		if len(test.Args) == 0 {
+			continue
+		}
+		if n, err := strconv.Atoi(test.Args[0]); err == nil {
+			maxSize = max(maxSize, n)
+		}
+	}
+	return maxSize
+}
+
+// complexitySizes returns the input sizes to measure: halving from maxN, or
+// evenly spaced when maxN is too small to halve that often, as for
+// exponential solutions
+func complexitySizes(maxN int) []int {
+	var sizes []int
+	if maxN>>(COMPLEXITY_SIZES-1) >= 8 {
+		for i := COMPLEXITY_SIZES - 1; i >= 0; i-- {
+			sizes = append(sizes, maxN>>i)
+		}
+		return sizes
+	}
+	for i := 1; i <= COMPLEXITY_SIZES; i++ {
+		n := maxN * i / COMPLEXITY_SIZES
+		if n > 0 && (len(sizes) == 0 || sizes[len(sizes)-1] != n) {
+			sizes = append(sizes, n)
+		}
+	}
+	return sizes
+}
+
+// estimateComplexity times the program on generated inputs of growing size and
+// fits the running times against the complexity classes. Sizes stop at the
+// first input the program does not pass.
+func (s *ExecutionService) estimateComplexity(ctx context.Context, opts ExecutionOptions, prog Program) (*models.ComplexityEstimate, error) {
+	sizes := complexitySizes(opts.Complexity.MaxSize)
+	generator := &TestGenerator{
+		GeneratorCode: opts.Complexity.GeneratorCode,
+		ReferenceCode: opts.Complexity.ReferenceCode,
+		Tests:         make([]models.GeneratedTest, len(sizes)),
+	}
+	for i, n := range sizes {
+		generator.Tests[i] = models.GeneratedTest{Seed: COMPLEXITY_SEED, Args: []string{strconv.Itoa(n)}}
+	}
+	generateOpts := opts
+	generateOpts.Generator = generator
+	testCases, err := s.expandGeneratedTests(ctx, generateOpts)
+	if err != nil {
+		return nil, fmt.Errorf("complexity analysis: %w", err)
+	}
+
+	estimate := &models.ComplexityEstimate{
+		Samples: []models.ComplexitySample{},
+		Fits:    []models.ComplexityFit{},
+	}
+	for i, testCase := range testCases {
+		sample := models.ComplexitySample{N: sizes[i]}
+		// Runs are timed one at a time so that they do not compete with each other
+		for run := 0; run < COMPLEXITY_REPEATS; run++ {
+			var result models.ExecutionResult
+			var err error
+			if poolErr := s.pool.Run(ctx, opts.UserID, []func(){func() {
+				var output *ProgramOutput
+				result, output, err = s.runTestCase(ctx, opts, prog, testCase, i+1)
+				output.Close()
+			}}); poolErr != nil {
+				return nil, poolErr
+			}
+			if err != nil {
+				return nil, err
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			if run == 0 || result.CPUTimeMs < sample.TimeMs {
+				sample.TimeMs = result.CPUTimeMs
+			}
+			sample.Verdict = result.Verdict
+			if result.Verdict != models.VerdictAccepted {
+				break
+			}
+		}
+		estimate.Samples = append(estimate.Samples, sample)
+		if sample.Verdict != models.VerdictAccepted {
+			break
+		}
+	}
+
+	fitComplexity(estimate)
+	if opts.Complexity.Claimed != "" {
+		checkComplexityClaim(estimate, opts.Complexity.Claimed)
+	}
+	return estimate, nil
+}
+
+// checkComplexityClaim compares the fitted class with the user's claim
+func checkComplexityClaim(estimate *models.ComplexityEstimate, claimed string) {
+	estimate.ClaimedClass, _ = ParseComplexity(claimed)
+	if estimate.Class != "" && estimate.ClaimedClass != "" {
+		matches := estimate.Class == estimate.ClaimedClass
+		estimate.ClaimMatches = &matches
+		if !matches && estimate.Note == "" {
+			estimate.Note = fmt.Sprintf("The running times fit %s better than the claimed %s", estimate.Class, estimate.ClaimedClass)
+		}
+	}
+}
+
+// fitComplexity fits time = a + b*growth(n), with b >= 0, for every complexity
+// class by least squares on the passing samples and picks the class with the
+// smallest error. The confidence is the best fit's R² scaled by how much
+// better it is than the runner-up.
+func fitComplexity(estimate *models.ComplexityEstimate) {
+	var sizes, times []float64
+	for _, sample := range estimate.Samples {
+		if sample.Verdict == models.VerdictAccepted {
+			sizes = append(sizes, float64(sample.N))
+			times = append(times, float64(sample.TimeMs))
+		}
+	}
+	if len(sizes) < COMPLEXITY_MIN_SAMPLES {
+		estimate.Note = fmt.Sprintf("Only %d input sizes passed, at least %d are needed for an estimate", len(sizes), COMPLEXITY_MIN_SAMPLES)
+		return
+	}
+	maxN := sizes[len(sizes)-1]
+	if slices.Max(times)-slices.Min(times) < COMPLEXITY_MIN_GROWTH_MS {
+		estimate.Note = fmt.Sprintf("The running time grew by less than %dms up to n = %.0f, too little to tell complexity classes apart", COMPLEXITY_MIN_GROWTH_MS, maxN)
+		return
+	}
+
+	type fit struct {
+		class    string
+		rss      float64
+		rSquared float64
+	}
+	var fits []fit
+	growths := make([]float64, len(sizes))
+	for _, class := range complexityClasses {
+		if class.maxN > 0 && maxN > class.maxN {
+			continue
+		}
+		// Scaled to 1 at the largest size to keep fast growing classes finite
+		for i, n := range sizes {
+			growths[i] = class.growth(n) / class.growth(maxN)
+		}
+		rss, rSquared := leastSquares(growths, times)
+		fits = append(fits, fit{class: class.name, rss: rss, rSquared: rSquared})
+	}
+	sort.SliceStable(fits, func(i, j int) bool {
+		return fits[i].rss < fits[j].rss
+	})
+
+	for _, f := range fits {
+		estimate.Fits = append(estimate.Fits, models.ComplexityFit{Class: f.class, RSquared: math.Round(f.rSquared*1e4) / 1e4})
+	}
+	best, second := fits[0], fits[1]
+	estimate.Class = best.class
+	confidence := 0.0
+	if second.rss > 0 {
+		confidence = max(0, best.rSquared) * (1 - best.rss/second.rss)
+	}
+	estimate.Confidence = math.Round(confidence*100) / 100
+}
+
+// leastSquares fits y = a + b*x with b >= 0 and returns the residual sum of
+// squares and the coefficient of determination
+func leastSquares(x []float64, y []float64) (float64, float64) {
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i] / n
+		meanY += y[i] / n
+	}
+	var sxx, sxy, tss float64
+	for i := range x {
+		sxx += (x[i] - meanX) * (x[i] - meanX)
+		sxy += (x[i] - meanX) * (y[i] - meanY)
+		tss += (y[i] - meanY) * (y[i] - meanY)
+	}
+
+	slope := 0.0
+	if sxx > 0 && sxy > 0 {
+		slope = sxy / sxx
+	}
+	intercept := meanY - slope*meanX
+
+	var rss float64
+	for i := range x {
+		residual := y[i] - intercept - slope*x[i]
+		rss += residual * residual
+	}
+	if tss == 0 {
+		return rss, 0
+	}
+	return rss, 1 - rss/tss
+}
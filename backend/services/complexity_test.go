@@ -0,0 +1,146 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+func TestParseComplexity(t *testing.T) {
+	tests := []struct {
+		claim string
+		want  string
+	}{
+		{"O(n)", "O(n)"},
+		{"O(N log N)", "O(n log n)"},
+		{"n lg n", "O(n log n)"},
+		{"O(n*log(n))", "O(n log n)"},
+		{"Θ(n²)", "O(n^2)"},
+		{"O(n * n)", "O(n^2)"},
+		{"O(2^n)", "O(2^n)"},
+		{"O(√n)", "O(sqrt(n))"},
+		{"O(1)", "O(1)"},
+		{"log n", "O(log n)"},
+		{"fast", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseComplexity(tt.claim)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%q: got %q, want an error", tt.claim, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %q, %v; want %q", tt.claim, got, err, tt.want)
+		}
+	}
+}
+
+func TestComplexitySizes(t *testing.T) {
+	if got, want := complexitySizes(200000), []int{1562, 3125, 6250, 12500, 25000, 50000, 100000, 200000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("large n: got %v, want %v", got, want)
+	}
+	if got, want := complexitySizes(20), []int{2, 5, 7, 10, 12, 15, 17, 20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("small n: got %v, want %v", got, want)
+	}
+	if got, want := complexitySizes(3), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tiny n: got %v, want %v", got, want)
+	}
+}
+
+func TestFitComplexity(t *testing.T) {
+	// Running times of 5ms startup plus the class's growth up to peakMs, with
+	// a few milliseconds of alternating noise
+	samples := func(sizes []int, peakMs float64, growth func(n float64) float64) []models.ComplexitySample {
+		maxN := float64(sizes[len(sizes)-1])
+		var samples []models.ComplexitySample
+		for i, n := range sizes {
+			noise := float64(i%2*6 - 3)
+			timeMs := 5 + peakMs*growth(float64(n))/growth(maxN) + noise
+			samples = append(samples, models.ComplexitySample{N: n, TimeMs: int64(math.Round(timeMs)), Verdict: models.VerdictAccepted})
+		}
+		return samples
+	}
+	large := complexitySizes(200000)
+
+	tests := []struct {
+		name    string
+		samples []models.ComplexitySample
+		class   string
+	}{
+		{"linear", samples(large, 800, func(n float64) float64 { return n }), "O(n)"},
+		{"n log n", samples(large, 800, func(n float64) float64 { return n * math.Log2(n) }), "O(n log n)"},
+		{"quadratic", samples(large, 800, func(n float64) float64 { return n * n }), "O(n^2)"},
+		{"exponential", samples(complexitySizes(24), 800, math.Exp2), "O(2^n)"},
+		{"too fast to measure", samples(large, 10, func(n float64) float64 { return n }), ""},
+		{"too few passing sizes", append(samples(large[:3], 800, func(n float64) float64 { return n }),
+			models.ComplexitySample{N: large[3], TimeMs: 2000, Verdict: models.VerdictTimeLimitExceeded}), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate := &models.ComplexityEstimate{Samples: tt.samples}
+			fitComplexity(estimate)
+			if estimate.Class != tt.class {
+				t.Errorf("got %q (fits %+v), want %q", estimate.Class, estimate.Fits, tt.class)
+			}
+			if tt.class == "" {
+				if estimate.Note == "" {
+					t.Error("no note explaining the missing estimate")
+				}
+				return
+			}
+			if estimate.Confidence <= 0 || estimate.Confidence > 1 {
+				t.Errorf("confidence %v outside (0, 1]", estimate.Confidence)
+			}
+			if estimate.Fits[0].Class != tt.class {
+				t.Errorf("best fit %q listed first, want %q", estimate.Fits[0].Class, tt.class)
+			}
+		})
+	}
+}
+
+func TestComplexityClaim(t *testing.T) {
+	sizes := complexitySizes(200000)
+	// Running times that vary but do not grow with n, like a runtime warming
+	// up, and ones that grow linearly
+	constant := make([]models.ComplexitySample, len(sizes))
+	linear := make([]models.ComplexitySample, len(sizes))
+	for i, n := range sizes {
+		constant[i] = models.ComplexitySample{N: n, TimeMs: int64(80 - 5*i), Verdict: models.VerdictAccepted}
+		linear[i] = models.ComplexitySample{N: n, TimeMs: int64(5 + 800*n/sizes[len(sizes)-1]), Verdict: models.VerdictAccepted}
+	}
+
+	tests := []struct {
+		name    string
+		samples []models.ComplexitySample
+		claimed string
+		class   string
+		matches bool
+	}{
+		{"constant claimed constant", constant, "O(1)", "O(1)", true},
+		{"linear claimed constant", linear, "O(1)", "O(n)", false},
+		{"linear claimed linear", linear, "O(N)", "O(n)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate := &models.ComplexityEstimate{Samples: tt.samples}
+			fitComplexity(estimate)
+			checkComplexityClaim(estimate, tt.claimed)
+			if estimate.Class != tt.class {
+				t.Fatalf("got %q (fits %+v), want %q", estimate.Class, estimate.Fits, tt.class)
+			}
+			if estimate.ClaimMatches == nil || *estimate.ClaimMatches != tt.matches {
+				t.Errorf("claim %q matches %v, want %v", tt.claimed, estimate.ClaimMatches, tt.matches)
+			}
+			if !tt.matches && estimate.Note == "" {
+				t.Error("no note explaining the mismatch")
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+var (
+	// gccDiagnosticPattern matches "main.cpp:12:5: error: message"
+	gccDiagnosticPattern = regexp.MustCompile(`^(.+?):(\d+):(\d+): (fatal error|error|warning): (.*)$`)
+
+	// javacDiagnosticPattern matches "Main.java:12: error: message"
+	javacDiagnosticPattern = regexp.MustCompile(`^(.+?\.java):(\d+): (error|warning): (.*)$`)
+
+	// goDiagnosticPattern matches "./main.go:12:5: message"
+	goDiagnosticPattern = regexp.MustCompile(`^(?:\./)?(.+?\.go):(\d+):(\d+): (.*)$`)
+
+	// rustcHeaderPattern matches "error[E0425]: message", followed by rustcLocationPattern " --> main.rs:12:5"
+	rustcHeaderPattern   = regexp.MustCompile(`^(error|warning)(?:\[\w+\])?: (.*)$`)
+	rustcLocationPattern = regexp.MustCompile(`^\s*--> (.+?):(\d+):(\d+)$`)
+
+	// tscDiagnosticPattern matches "main.ts(12,5): error TS2304: message"
+	tscDiagnosticPattern = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning) (.*)$`)
+)
+
+// ParseDiagnostics extracts structured errors and warnings from compiler output
+// in the given format (see config.LanguageConfig.Diagnostics). Lines that are
+// not diagnostics (context, notes, carets) are skipped.
+func ParseDiagnostics(output string, format string) []models.CompileDiagnostic {
+	switch format {
+	case "gcc":
+		return parseGCCDiagnostics(output)
+	case "javac":
+		return parseJavacDiagnostics(output)
+	case "go":
+		return parseGoDiagnostics(output)
+	case "rustc":
+		return parseRustcDiagnostics(output)
+	case "tsc":
+		return parseTscDiagnostics(output)
+	}
+	return nil
+}
+
+// parseGCCDiagnostics parses g++ output
+func parseGCCDiagnostics(output string) []models.CompileDiagnostic {
+	var diagnostics []models.CompileDiagnostic
+
+	for _, line := range strings.Split(output, "\n") {
+		match := gccDiagnosticPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		severity := match[4]
+		if severity == "fatal error" {
+			severity = "error"
+		}
+
+		diagnostics = append(diagnostics, models.CompileDiagnostic{
+			File:     match[1],
+			Line:     lineNumber,
+			Column:   column,
+			Severity: severity,
+			Message:  match[5],
+		})
+	}
+
+	return diagnostics
+}
+
+// parseJavacDiagnostics parses javac output. javac does not print a column,
+// so it is recovered from the caret line that follows the quoted source line.
+func parseJavacDiagnostics(output string) []models.CompileDiagnostic {
+	var diagnostics []models.CompileDiagnostic
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		match := javacDiagnosticPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+
+		lineNumber, _ := strconv.Atoi(match[2])
+		diagnostic := models.CompileDiagnostic{
+			File:     match[1],
+			Line:     lineNumber,
+			Severity: match[3],
+			Message:  match[4],
+		}
+
+		if i+2 < len(lines) {
+			caretLine := strings.TrimRight(lines[i+2], "\r")
+			if strings.TrimSpace(caretLine) == "^" {
+				diagnostic.Column = strings.Index(caretLine, "^") + 1
+			}
+		}
+
+		diagnostics = append(diagnostics, diagnostic)
+	}
+
+	return diagnostics
+}
+
+// parseGoDiagnostics parses go build output. The Go compiler only reports errors.
+func parseGoDiagnostics(output string) []models.CompileDiagnostic {
+	var diagnostics []models.CompileDiagnostic
+
+	for _, line := range strings.Split(output, "\n") {
+		match := goDiagnosticPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		diagnostics = append(diagnostics, models.CompileDiagnostic{
+			File:     match[1],
+			Line:     lineNumber,
+			Column:   column,
+			Severity: "error",
+			Message:  match[4],
+		})
+	}
+
+	return diagnostics
+}
+
+// parseRustcDiagnostics parses rustc output, where the location is on the line
+// after the message. Messages without a location ("aborting due to...") are skipped.
+func parseRustcDiagnostics(output string) []models.CompileDiagnostic {
+	var diagnostics []models.CompileDiagnostic
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		header := rustcHeaderPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if header == nil || i+1 >= len(lines) {
+			continue
+		}
+		location := rustcLocationPattern.FindStringSubmatch(strings.TrimRight(lines[i+1], "\r"))
+		if location == nil {
+			continue
+		}
+
+		lineNumber, _ := strconv.Atoi(location[2])
+		column, _ := strconv.Atoi(location[3])
+		diagnostics = append(diagnostics, models.CompileDiagnostic{
+			File:     location[1],
+			Line:     lineNumber,
+			Column:   column,
+			Severity: header[1],
+			Message:  header[2],
+		})
+	}
+
+	return diagnostics
+}
+
+// parseTscDiagnostics parses TypeScript compiler output
+func parseTscDiagnostics(output string) []models.CompileDiagnostic {
+	var diagnostics []models.CompileDiagnostic
+
+	for _, line := range strings.Split(output, "\n") {
+		match := tscDiagnosticPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		diagnostics = append(diagnostics, models.CompileDiagnostic{
+			File:     match[1],
+			Line:     lineNumber,
+			Column:   column,
+			Severity: match[4],
+			Message:  match[5],
+		})
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		output string
+		want   []models.CompileDiagnostic
+	}{
+		{
+			name:   "gcc",
+			format: "gcc",
+			output: `main.cpp: In function 'int main()':
+main.cpp:4:5: error: 'x' was not declared in this scope
+    4 |     x = 1;
+      |     ^
+main.cpp:5:9: warning: unused variable 'y' [-Wunused-variable]
+main.cpp:1:10: fatal error: missing.h: No such file or directory
+compilation terminated.`,
+			want: []models.CompileDiagnostic{
+				{File: "main.cpp", Line: 4, Column: 5, Severity: "error", Message: "'x' was not declared in this scope"},
+				{File: "main.cpp", Line: 5, Column: 9, Severity: "warning", Message: "unused variable 'y' [-Wunused-variable]"},
+				{File: "main.cpp", Line: 1, Column: 10, Severity: "error", Message: "missing.h: No such file or directory"},
+			},
+		},
+		{
+			name:   "javac",
+			format: "javac",
+			output: "Main.java:3: error: cannot find symbol\r\n" +
+				"        int y = x + 1;\r\n" +
+				"                ^\r\n" +
+				"  symbol:   variable x\r\n" +
+				"Main.java:7: error: ';' expected\n" +
+				"        return\n" +
+				"1 error",
+			want: []models.CompileDiagnostic{
+				{File: "Main.java", Line: 3, Column: 17, Severity: "error", Message: "cannot find symbol"},
+				{File: "Main.java", Line: 7, Severity: "error", Message: "';' expected"},
+			},
+		},
+		{
+			name:   "go",
+			format: "go",
+			output: `# command-line-arguments
+./main.go:6:2: undefined: x
+main.go:8:10: missing return`,
+			want: []models.CompileDiagnostic{
+				{File: "main.go", Line: 6, Column: 2, Severity: "error", Message: "undefined: x"},
+				{File: "main.go", Line: 8, Column: 10, Severity: "error", Message: "missing return"},
+			},
+		},
+		{
+			name:   "rustc",
+			format: "rustc",
+			output: `error[E0425]: cannot find value ` + "`x`" + ` in this scope
+ --> main.rs:2:13
+  |
+2 |     let y = x;
+  |             ^ not found in this scope
+
+warning: unused variable: ` + "`y`" + `
+ --> main.rs:2:9
+
+error: aborting due to 1 previous error`,
+			want: []models.CompileDiagnostic{
+				{File: "main.rs", Line: 2, Column: 13, Severity: "error", Message: "cannot find value `x` in this scope"},
+				{File: "main.rs", Line: 2, Column: 9, Severity: "warning", Message: "unused variable: `y`"},
+			},
+		},
+		{
+			name:   "tsc",
+			format: "tsc",
+			output: `main.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.
+main.ts(10,1): error TS2304: Cannot find name 'foo'.`,
+			want: []models.CompileDiagnostic{
+				{File: "main.ts", Line: 3, Column: 7, Severity: "error", Message: "TS2322: Type 'string' is not assignable to type 'number'."},
+				{File: "main.ts", Line: 10, Column: 1, Severity: "error", Message: "TS2304: Cannot find name 'foo'."},
+			},
+		},
+		{
+			name:   "unknown format",
+			format: "",
+			output: "main.cpp:4:5: error: 'x' was not declared in this scope",
+			want:   nil,
+		},
+		{
+			name:   "no diagnostics",
+			format: "gcc",
+			output: "collect2: error: ld returned 1 exit status",
+			want:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ParseDiagnostics(test.output, test.format)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseDiagnostics() =\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+	"github.com/google/uuid"
+)
+
+// ARTIFACT_PRUNE_INTERVAL is how often expired execution artifacts are removed
+const ARTIFACT_PRUNE_INTERVAL = time.Hour
+
+// artifactKeepFile marks the artifacts of an execution recorded as a submission
+const artifactKeepFile = "submission"
+
+// Test case files of an execution
+const (
+	ArtifactInput    = "input"
+	ArtifactExpected = "expected"
+	ArtifactActual   = "actual"
+)
+
+// ErrArtifactNotFound is returned when a test case file was not stored, has
+// expired or belongs to another user
+var ErrArtifactNotFound = errors.New("execution artifact not found")
+
+// ArtifactStore keeps the full input, expected output and actual output of
+// each test case of an execution, of which results only hold previews. Files
+// are grouped by user and execution. Those of executions recorded as
+// submissions are kept for submissionRetention, the others for runRetention.
+type ArtifactStore struct {
+	dir                 string
+	runRetention        time.Duration
+	submissionRetention time.Duration
+}
+
+// ExecutionArtifacts are the stored test case files of one execution. The
+// directory is only created once a file is stored.
+type ExecutionArtifacts struct {
+	ID  uuid.UUID
+	dir string
+}
+
+// NewArtifactStore opens the artifact directory
+func NewArtifactStore(dir string, runRetention time.Duration, submissionRetention time.Duration) (*ArtifactStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create artifact store: %w", err)
+	}
+	return &ArtifactStore{dir: dir, runRetention: runRetention, submissionRetention: submissionRetention}, nil
+}
+
+// New returns the artifacts of a new execution by the user
+func (s *ArtifactStore) New(userID uuid.UUID) *ExecutionArtifacts {
+	id := uuid.New()
+	return &ExecutionArtifacts{ID: id, dir: filepath.Join(s.dir, userID.String(), id.String())}
+}
+
+// StoreCase writes the full input and expected output of a test case, and the
+// actual output if the program's stdout was captured
+func (a *ExecutionArtifacts) StoreCase(caseNumber int, testCase models.TestCase, output *ProgramOutput) error {
+	if err := os.MkdirAll(a.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	files := map[string]io.Reader{
+		ArtifactInput:    strings.NewReader(testCase.Input),
+		ArtifactExpected: strings.NewReader(testCase.ExpectedOutput),
+	}
+	if output != nil {
+		actual, err := output.Reader()
+		if err != nil {
+			return err
+		}
+		files[ArtifactActual] = actual
+	}
+	for file, content := range files {
+		if err := writeFile(artifactPath(a.dir, caseNumber, file), content, 0600); err != nil {
+			return fmt.Errorf("failed to store %s of case %d: %w", file, caseNumber, err)
+		}
+	}
+	return nil
+}
+
+// artifactPath returns the path of a test case file in an execution's directory
+func artifactPath(dir string, caseNumber int, file string) string {
+	return filepath.Join(dir, fmt.Sprintf("case-%d-%s.txt", caseNumber, file))
+}
+
+// Keep marks the artifacts of an execution as those of a submission, which
+// are kept longer
+func (s *ArtifactStore) Keep(userID uuid.UUID, id uuid.UUID) error {
+	dir := filepath.Join(s.dir, userID.String(), id.String())
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		// Nothing was stored, e.g. for a compilation error
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(dir, artifactKeepFile), nil, 0600); err != nil {
+		return fmt.Errorf("failed to keep artifacts: %w", err)
+	}
+	return nil
+}
+
+// Open opens a test case file of one of the user's executions
+func (s *ArtifactStore) Open(userID uuid.UUID, id uuid.UUID, caseNumber int, file string) (*os.File, error) {
+	switch file {
+	case ArtifactInput, ArtifactExpected, ArtifactActual:
+	default:
+		return nil, ErrArtifactNotFound
+	}
+
+	f, err := os.Open(artifactPath(filepath.Join(s.dir, userID.String(), id.String()), caseNumber, file))
+	if os.IsNotExist(err) {
+		return nil, ErrArtifactNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	return f, nil
+}
+
+// PruneLoop removes expired artifacts every interval until ctx is done
+func (s *ArtifactStore) PruneLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.Prune()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Prune removes the artifacts of executions older than their retention
+func (s *ArtifactStore) Prune() {
+	users, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("Failed to read artifact store: %v", err)
+		return
+	}
+
+	removed := 0
+	for _, user := range users {
+		userDir := filepath.Join(s.dir, user.Name())
+		executions, err := os.ReadDir(userDir)
+		if err != nil {
+			continue
+		}
+		for _, execution := range executions {
+			dir := filepath.Join(userDir, execution.Name())
+			info, err := execution.Info()
+			if err != nil {
+				continue
+			}
+			retention := s.runRetention
+			if _, err := os.Stat(filepath.Join(dir, artifactKeepFile)); err == nil {
+				retention = s.submissionRetention
+			}
+			if time.Since(info.ModTime()) > retention {
+				os.RemoveAll(dir)
+				removed++
+			}
+		}
+		// Fails unless the user has no executions left
+		os.Remove(userDir)
+	}
+	if removed > 0 {
+		log.Printf("Removed the artifacts of %d expired executions", removed)
+	}
+}
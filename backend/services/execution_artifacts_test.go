@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+	"github.com/google/uuid"
+)
+
+func TestArtifactStore(t *testing.T) {
+	store, err := NewArtifactStore(t.TempDir(), time.Hour, 48*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uuid.New()
+
+	// Files are stored in full, not as the previews of results
+	full := strings.Repeat("12345\n", RESULT_OUTPUT_PREVIEW_BYTES)
+	output := &ProgramOutput{}
+	output.Write([]byte(full))
+	defer output.Close()
+	artifacts := store.New(owner)
+	if err := artifacts.StoreCase(2, models.TestCase{Input: "in", ExpectedOutput: "out"}, output); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, want := range map[string]string{ArtifactInput: "in", ArtifactExpected: "out", ArtifactActual: full} {
+		f, err := store.Open(owner, artifacts.ID, 2, file)
+		if err != nil {
+			t.Fatalf("open %s: %v", file, err)
+		}
+		got, err := io.ReadAll(f)
+		f.Close()
+		if err != nil || string(got) != want {
+			t.Errorf("%s: read %d bytes (%v), want %d", file, len(got), err, len(want))
+		}
+	}
+
+	notFound := []struct {
+		name       string
+		user       uuid.UUID
+		caseNumber int
+		file       string
+	}{
+		{"other user", uuid.New(), 2, ArtifactInput},
+		{"other case", owner, 1, ArtifactInput},
+		{"unknown file", owner, 2, "../input"},
+	}
+	for _, tt := range notFound {
+		if _, err := store.Open(tt.user, artifacts.ID, tt.caseNumber, tt.file); !errors.Is(err, ErrArtifactNotFound) {
+			t.Errorf("%s: got %v, want ErrArtifactNotFound", tt.name, err)
+		}
+	}
+
+	// Runs expire before submissions
+	kept := store.New(owner)
+	if err := kept.StoreCase(1, models.TestCase{Input: "in"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Keep(owner, kept.ID); err != nil {
+		t.Fatal(err)
+	}
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	for _, dir := range []string{artifacts.dir, kept.dir} {
+		if err := os.Chtimes(dir, dayAgo, dayAgo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Prune()
+	if _, err := store.Open(owner, artifacts.ID, 2, ArtifactInput); !errors.Is(err, ErrArtifactNotFound) {
+		t.Errorf("expired run: got %v, want ErrArtifactNotFound", err)
+	}
+	if f, err := store.Open(owner, kept.ID, 1, ArtifactInput); err != nil {
+		t.Errorf("submission: %v", err)
+	} else {
+		f.Close()
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EXECUTION_JOB_TIMEOUT bounds how long a background execution may run
+const EXECUTION_JOB_TIMEOUT = 10 * time.Minute
+
+// EXECUTION_JOB_RETRY_INTERVAL is how long an accepted job waits before
+// retrying when the execution queue filled up after it was submitted
+const EXECUTION_JOB_RETRY_INTERVAL = time.Second
+
+// ErrExecutionJobNotFound is returned when a job does not exist or belongs to another user
+var ErrExecutionJobNotFound = errors.New("execution job not found")
+
+// ExecutionJobSpec describes the execution a job runs
+type ExecutionJobSpec struct {
+	ProblemID string
+	Mode      string
+	Code      string
+	Language  string
+	TestCases []models.TestCase
+	Options   ExecutionOptions
+	// Submission, when set, records the judged job if it is a submission
+	Submission *SubmissionSpec
+}
+
+// ExecutionJobService runs executions in the background and records their
+// progress and results in the database
+type ExecutionJobService struct {
+	db                *gorm.DB
+	executionService  *ExecutionService
+	submissionService *SubmissionService
+}
+
+// NewExecutionJobService creates a new job service. Jobs left unfinished by a
+// previous run of the server are marked as failed.
+func NewExecutionJobService(db *gorm.DB, executionService *ExecutionService, submissionService *SubmissionService) *ExecutionJobService {
+	s := &ExecutionJobService{
+		db:                db,
+		executionService:  executionService,
+		submissionService: submissionService,
+	}
+
+	now := time.Now()
+	result := db.Model(&models.ExecutionJob{}).
+		Where("status IN ?", []models.ExecutionJobStatus{models.ExecutionJobQueued, models.ExecutionJobCompiling, models.ExecutionJobRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.ExecutionJobFailed,
+			"error":       "Execution interrupted by a server restart",
+			"finished_at": now,
+		})
+	if result.Error != nil {
+		log.Printf("Failed to fail interrupted execution jobs: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Marked %d interrupted execution jobs as failed", result.RowsAffected)
+	}
+
+	return s
+}
+
+// Submit records a queued job and starts it in the background.
+// ErrExecutionQueueFull is returned, and no job created, when the execution
+// pool is saturated.
+func (s *ExecutionJobService) Submit(userID uuid.UUID, spec ExecutionJobSpec) (*models.ExecutionJob, error) {
+	if s.executionService.QueueFull() {
+		return nil, ErrExecutionQueueFull
+	}
+
+	job := models.ExecutionJob{
+		UserID:    userID,
+		ProblemID: spec.ProblemID,
+		Language:  spec.Language,
+		Mode:      spec.Mode,
+		Status:    models.ExecutionJobQueued,
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create execution job: %w", err)
+	}
+
+	go s.run(job.ID, spec)
+
+	return &job, nil
+}
+
+// Get returns a job owned by the user
+func (s *ExecutionJobService) Get(jobID uuid.UUID, userID uuid.UUID) (*models.ExecutionJob, error) {
+	var job models.ExecutionJob
+	err := s.db.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExecutionJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution job: %w", err)
+	}
+	return &job, nil
+}
+
+// run executes the job detached from the request that created it
+func (s *ExecutionJobService) run(jobID uuid.UUID, spec ExecutionJobSpec) {
+	ctx, cancel := context.WithTimeout(context.Background(), EXECUTION_JOB_TIMEOUT)
+	defer cancel()
+
+	spec.Options.OnCompiled = func(int) {
+		s.setStatus(jobID, models.ExecutionJobRunning)
+	}
+
+	var response *models.ExecutionResponse
+	var err error
+	for {
+		s.setStatus(jobID, models.ExecutionJobCompiling)
+		response, err = s.executionService.Execute(ctx, spec.Code, spec.TestCases, spec.Language, spec.Options)
+		if !errors.Is(err, ErrExecutionQueueFull) {
+			break
+		}
+
+		// The job was accepted, so it stays queued until the pool has room
+		s.setStatus(jobID, models.ExecutionJobQueued)
+		select {
+		case <-time.After(EXECUTION_JOB_RETRY_INTERVAL):
+		case <-ctx.Done():
+			err = fmt.Errorf("execution queue stayed full: %w", ctx.Err())
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	updates := map[string]interface{}{
+		"finished_at": time.Now(),
+	}
+	if err != nil {
+		log.Printf("Execution job %s failed: %v", jobID, err)
+		updates["status"] = models.ExecutionJobFailed
+		updates["error"] = err.Error()
+	} else {
+		if spec.Submission != nil {
+			if _, err := s.submissionService.Record(*spec.Submission, response); err != nil {
+				log.Printf("Failed to record submission of execution job %s: %v", jobID, err)
+			}
+		}
+		updates["status"] = models.ExecutionJobDone
+		updates["result"] = response
+	}
+
+	if err := s.db.Model(&models.ExecutionJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to save execution job %s: %v", jobID, err)
+	}
+}
+
+func (s *ExecutionJobService) setStatus(jobID uuid.UUID, status models.ExecutionJobStatus) {
+	if err := s.db.Model(&models.ExecutionJob{}).Where("id = ?", jobID).Update("status", status).Error; err != nil {
+		log.Printf("Failed to update execution job %s: %v", jobID, err)
+	}
+}
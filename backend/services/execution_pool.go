@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrExecutionQueueFull is returned when the pool has too many queued tasks to accept more work
+var ErrExecutionQueueFull = errors.New("execution queue is full, try again later")
+
+// poolTask is a unit of work (one compilation or one test case) owned by a batch
+type poolTask struct {
+	fn    func()
+	batch *poolBatch
+}
+
+// poolBatch groups the tasks submitted by one Run call
+type poolBatch struct {
+	wg        sync.WaitGroup
+	cancelled bool
+}
+
+// ExecutionPool runs compilations and test cases on a fixed number of workers
+// shared by the whole process. Each user has their own FIFO queue and workers
+// take tasks from the users in round-robin order, so one large submission
+// cannot starve everybody else.
+type ExecutionPool struct {
+	mu         sync.Mutex
+	wake       *sync.Cond
+	queues     map[string][]poolTask
+	users      []string // users with queued tasks, in round-robin order
+	next       int
+	pending    int
+	maxPending int
+}
+
+// NewExecutionPool starts a pool with the given number of workers that holds at
+// most maxPending queued tasks
+func NewExecutionPool(workers int, maxPending int) *ExecutionPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &ExecutionPool{
+		queues:     make(map[string][]poolTask),
+		maxPending: maxPending,
+	}
+	p.wake = sync.NewCond(&p.mu)
+
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+
+	log.Printf("Execution pool ready: workers=%d max_queue=%d", workers, maxPending)
+	return p
+}
+
+// Run queues the tasks for a user and blocks until all of them finished.
+// Tasks are queued atomically: either all of them are accepted or
+// ErrExecutionQueueFull is returned. When ctx is cancelled, tasks that have not
+// started are dropped; running tasks are expected to observe ctx themselves.
+func (p *ExecutionPool) Run(ctx context.Context, userID string, tasks []func()) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	batch := &poolBatch{}
+	batch.wg.Add(len(tasks))
+
+	p.mu.Lock()
+	// A batch larger than the whole queue is still accepted when the queue is empty
+	if p.pending > 0 && p.pending+len(tasks) > p.maxPending {
+		p.mu.Unlock()
+		return ErrExecutionQueueFull
+	}
+	if len(p.queues[userID]) == 0 {
+		p.users = append(p.users, userID)
+	}
+	for _, fn := range tasks {
+		p.queues[userID] = append(p.queues[userID], poolTask{fn: fn, batch: batch})
+	}
+	p.pending += len(tasks)
+	p.mu.Unlock()
+	p.wake.Broadcast()
+
+	done := make(chan struct{})
+	go func() {
+		batch.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		batch.cancelled = true
+		p.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Full reports whether the queue is too full to accept even a single task.
+// Run can still reject a batch afterwards, as other users keep submitting.
+func (p *ExecutionPool) Full() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending > 0 && p.pending+1 > p.maxPending
+}
+
+// worker takes tasks from the per-user queues in round-robin order
+func (p *ExecutionPool) worker() {
+	for {
+		p.mu.Lock()
+		for p.pending == 0 {
+			p.wake.Wait()
+		}
+		task := p.dequeue()
+		cancelled := task.batch.cancelled
+		p.mu.Unlock()
+
+		if !cancelled {
+			task.fn()
+		}
+		task.batch.wg.Done()
+	}
+}
+
+// dequeue pops the next task. It must be called with p.mu held and pending > 0.
+func (p *ExecutionPool) dequeue() poolTask {
+	if p.next >= len(p.users) {
+		p.next = 0
+	}
+
+	userID := p.users[p.next]
+	queue := p.queues[userID]
+	task := queue[0]
+	p.pending--
+
+	if len(queue) == 1 {
+		// Drop the user from the rotation; the next user shifts into p.next
+		delete(p.queues, userID)
+		p.users = append(p.users[:p.next], p.users[p.next+1:]...)
+	} else {
+		p.queues[userID] = queue[1:]
+		p.next++
+	}
+
+	return task
+}
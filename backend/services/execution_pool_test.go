@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockWorker occupies a worker of the pool until the returned function is called
+func blockWorker(t *testing.T, pool *ExecutionPool) func() {
+	t.Helper()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go pool.Run(context.Background(), "blocker", []func(){func() {
+		close(started)
+		<-release
+	}})
+	<-started
+	return func() { close(release) }
+}
+
+// waitPending waits until the pool holds n queued tasks
+func waitPending(t *testing.T, pool *ExecutionPool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		pool.mu.Lock()
+		pending := pool.pending
+		pool.mu.Unlock()
+		if pending == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("pool never reached %d pending tasks", n)
+}
+
+func TestExecutionPoolRoundRobin(t *testing.T) {
+	pool := NewExecutionPool(1, 100)
+	release := blockWorker(t, pool)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(userID string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, userID)
+			mu.Unlock()
+		}
+	}
+
+	var wg sync.WaitGroup
+	queued := 0
+	for _, userID := range []string{"a", "b"} {
+		// One user queues much more work, the other must not wait for all of it
+		count := 4
+		if userID == "b" {
+			count = 2
+		}
+		tasks := make([]func(), count)
+		for j := range tasks {
+			tasks[j] = record(userID)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.Run(context.Background(), userID, tasks); err != nil {
+				t.Errorf("Run(%s): %v", userID, err)
+			}
+		}()
+		queued += count
+		waitPending(t, pool, queued)
+	}
+
+	release()
+	wg.Wait()
+
+	want := []string{"a", "b", "a", "b", "a", "a"}
+	if len(order) != len(want) {
+		t.Fatalf("ran %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("ran %v, want %v", order, want)
+		}
+	}
+}
+
+func TestExecutionPoolQueueFull(t *testing.T) {
+	pool := NewExecutionPool(1, 2)
+	release := blockWorker(t, pool)
+
+	queued := make(chan error, 1)
+	go func() {
+		queued <- pool.Run(context.Background(), "a", []func(){func() {}, func() {}})
+	}()
+	waitPending(t, pool, 2)
+
+	if !pool.Full() {
+		t.Error("Full() = false on a full queue")
+	}
+	if err := pool.Run(context.Background(), "b", []func(){func() {}}); !errors.Is(err, ErrExecutionQueueFull) {
+		t.Errorf("Run on a full queue = %v, want ErrExecutionQueueFull", err)
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Errorf("queued Run: %v", err)
+	}
+	if pool.Full() {
+		t.Error("Full() = true on an empty queue")
+	}
+
+	// An oversized batch is accepted once the queue is empty
+	tasks := make([]func(), 5)
+	for i := range tasks {
+		tasks[i] = func() {}
+	}
+	if err := pool.Run(context.Background(), "b", tasks); err != nil {
+		t.Errorf("Run of an oversized batch on an empty queue: %v", err)
+	}
+}
+
+func TestExecutionPoolCancellation(t *testing.T) {
+	pool := NewExecutionPool(1, 100)
+	release := blockWorker(t, pool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{}, 3)
+	result := make(chan error, 1)
+	go func() {
+		task := func() { ran <- struct{}{} }
+		result <- pool.Run(ctx, "a", []func(){task, task, task})
+	}()
+	waitPending(t, pool, 3)
+
+	cancel()
+	// Release the worker only once Run has marked its batch as cancelled
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		pool.mu.Lock()
+		cancelled := pool.queues["a"][0].batch.cancelled
+		pool.mu.Unlock()
+		if cancelled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("batch never marked as cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	release()
+
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run after cancellation = %v, want context.Canceled", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("%d tasks ran after cancellation", len(ran))
+	}
+
+	// The pool keeps working for other batches
+	if err := pool.Run(context.Background(), "b", []func(){func() {}}); err != nil {
+		t.Errorf("Run after a cancelled batch: %v", err)
+	}
+}
@@ -2,312 +2,815 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/boobachad/simulate-interview/backend/config"
 	"github.com/boobachad/simulate-interview/backend/models"
-	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
+	// EXECUTION_TIMEOUT is the time limit of problems that set none, before
+	// the language's multiplier
 	EXECUTION_TIMEOUT = 2 * time.Second
 	COMPILE_TIMEOUT   = 10 * time.Second
+
+	// Problem time, memory and output limits are clamped to these bounds
+	MIN_TIME_LIMIT      = 250 * time.Millisecond
+	MAX_TIME_LIMIT      = 10 * time.Second
+	MIN_MEMORY_LIMIT_MB = 16
+	MAX_MEMORY_LIMIT_MB = 1024
+	MAX_OUTPUT_LIMIT_MB = 256
+
+	// Sanitizer builds run about twice as slow
+	DEBUG_TIME_MULTIPLIER = 2
+
+	// RESULT_OUTPUT_PREVIEW_BYTES is how much of the output and stderr of a test
+	// case is sent back to clients
+	RESULT_OUTPUT_PREVIEW_BYTES = 64 << 10
 )
 
-// ExecutionService handles C++ code compilation and execution
-type ExecutionService struct{}
+// CompilationError is returned when user code fails to compile. It is reported
+// to the client as a CE verdict rather than as a request error.
+type CompilationError struct {
+	Output string
+}
 
-// NewExecutionService creates a new execution service
-func NewExecutionService() *ExecutionService {
-	return &ExecutionService{}
+func (e *CompilationError) Error() string {
+	return fmt.Sprintf("compilation failed: %s", e.Output)
 }
 
-// Execute compiles and runs code against test cases
-func (s *ExecutionService) Execute(code string, testCases []models.TestCase, language string) ([]models.ExecutionResult, error) {
-	// Default to C++ if no language specified
-	if language == "" {
-		language = "cpp"
+// ExecutionOptions carries per-request settings for Execute
+type ExecutionOptions struct {
+	// UserID identifies the submitter for fair scheduling in the execution pool
+	UserID string
+	// Toolchain selects language options such as the standard or compiler version;
+	// unset options use their defaults
+	Toolchain map[string]string
+	// Debug builds the program with sanitizers, where the language supports them,
+	// and reports their findings on each result
+	Debug bool
+	// ComparisonMode and FloatEpsilon select how output is matched against expected output
+	ComparisonMode models.ComparisonMode
+	FloatEpsilon   float64
+	// CheckerCode is the C++ source of a special judge that replaces output comparison
+	CheckerCode string
+	// InteractorCode is the C++ source of the interactor for interactive problems
+	InteractorCode string
+	// Generator adds hidden tests that are generated at judge time
+	Generator *TestGenerator
+	// Complexity estimates the time complexity of the code once it passes every case
+	Complexity *ComplexityOptions
+	// ValidatorCode is the C++ source of a program that checks every input
+	// against the problem's constraints before the code is judged
+	ValidatorCode string
+	// OutputLimitMB caps the stdout and stderr of each test case; 0 uses the
+	// configured default
+	OutputLimitMB int
+	// TimeLimitMs and MemoryLimitMB are the problem's limits per test case; 0
+	// uses the defaults. The time limit is scaled by the language's multiplier.
+	TimeLimitMs   int
+	MemoryLimitMB int
+	// OnCompiled and OnCaseResult report progress while Execute runs. OnCompiled gets
+	// the number of test cases, including generated ones; with a remote executor
+	// a compilation error can still follow. OnCaseResult is called in
+	// completion order and may be called from several goroutines at once.
+	OnCompiled   func(totalCases int)
+	OnCaseResult func(result models.ExecutionResult)
+	// Artifacts, if set, receive the full input and outputs of each test case
+	Artifacts *ExecutionArtifacts
+
+	// checkerDir, interactorDir and validatorDir hold the compiled judge programs for this execution
+	checkerDir    string
+	interactorDir string
+	validatorDir  string
+}
+
+// ExecutionService handles code compilation and execution inside the sandbox
+type ExecutionService struct {
+	sandbox      *Sandbox
+	pool         *ExecutionPool
+	languages    *LanguageRegistry
+	compileCache *CompileCache
+	testCache    *CompileCache      // Expanded generated tests
+	judgeBuilds  singleflight.Group // Judge program compilations, keyed by cache path
+	// executor builds and runs the user code of Execute. Judge programs and
+	// stress tests always run in the local sandbox.
+	executor Executor
+}
+
+// Executor builds user programs and runs them on test inputs. ExecutionService
+// is the local executor, running programs in the sandbox on this host;
+// Judge0Executor sends them to a Judge0-compatible server instead.
+type Executor interface {
+	// Build prepares code for running. Code that does not compile is reported
+	// as a CompilationError. workDir is a private directory on this host.
+	Build(ctx context.Context, opts ExecutionOptions, lang Language, toolchain Toolchain, code string, workDir string) (Program, error)
+	// Run runs a program built by this executor on one input, stopping it once
+	// it writes more than outputLimit bytes. The error is only set when the
+	// program could not be run.
+	Run(ctx context.Context, prog Program, input string, outputLimit int64) (*ProgramRun, error)
+	// Available returns why programs in the language cannot be run, or nil
+	Available(lang Language) error
+}
+
+// ErrJudgeUnavailable is returned when a remote executor fails to run a
+// program for reasons of its own, rather than of the program
+var ErrJudgeUnavailable = errors.New("remote judge unavailable")
+
+// Program is a user program ready to run against test cases
+type Program struct {
+	executor      Executor
+	command       []string
+	env           []string
+	dir           string // Working directory of local programs
+	limits        SandboxLimits
+	memoryLimitMB int
+	// sanitized is set for sanitizer builds, whose reports are parsed from stderr
+	sanitized  bool
+	sourceFile string
+	// code and remoteLanguageID identify the program to remote executors
+	code             string
+	remoteLanguageID int
+}
+
+// ProgramRun is the output and resource usage of one run of a program.
+// Stdout holds the full output, Stderr only its start and end.
+type ProgramRun struct {
+	SandboxResult
+	Stdout *ProgramOutput
+	Stderr string
+}
+
+// NewExecutionService creates a new execution service. User code runs on the
+// remote executor if one is given, and in the local sandbox otherwise.
+func NewExecutionService(sandbox *Sandbox, pool *ExecutionPool, languages *LanguageRegistry, compileCache *CompileCache, testCache *CompileCache, remote Executor) *ExecutionService {
+	s := &ExecutionService{
+		sandbox:      sandbox,
+		pool:         pool,
+		languages:    languages,
+		compileCache: compileCache,
+		testCache:    testCache,
+		executor:     remote,
+	}
+	if s.executor == nil {
+		s.executor = s
 	}
+	return s
+}
 
-	// Validate language
-	switch language {
-	case "cpp", "python", "java", "javascript":
-		// Valid languages
-	default:
-		return nil, fmt.Errorf("unsupported language: %s", language)
+// defaultMemoryLimitMB returns the configured per-case memory limit
+func defaultMemoryLimitMB() int {
+	return config.Config.Execution.MemoryLimitMB
+}
+
+// timeLimit returns the problem's time limit before the language's multiplier
+func (o ExecutionOptions) timeLimit() time.Duration {
+	if o.TimeLimitMs <= 0 {
+		return EXECUTION_TIMEOUT
 	}
+	return min(max(time.Duration(o.TimeLimitMs)*time.Millisecond, MIN_TIME_LIMIT), MAX_TIME_LIMIT)
+}
 
-	// Generate unique identifiers
-	executionID := uuid.New().String()
+// memoryLimitMB returns the problem's memory limit per test case
+func (o ExecutionOptions) memoryLimitMB() int {
+	if o.MemoryLimitMB <= 0 {
+		return defaultMemoryLimitMB()
+	}
+	return min(max(o.MemoryLimitMB, MIN_MEMORY_LIMIT_MB), MAX_MEMORY_LIMIT_MB)
+}
 
-	var results []models.ExecutionResult
-	var err error
+// languageTimeLimit scales a time limit by the language's multiplier
+func languageTimeLimit(lang Language, timeLimit time.Duration) time.Duration {
+	return time.Duration(float64(timeLimit) * lang.TimeMultiplier())
+}
 
-	switch language {
-	case "cpp":
-		results, err = s.executeCpp(code, testCases, executionID)
-	case "python":
-		results, err = s.executePython(code, testCases, executionID)
-	case "java":
-		results, err = s.executeJava(code, testCases, executionID)
-	case "javascript":
-		results, err = s.executeJavaScript(code, testCases, executionID)
+// runLimits returns the sandbox limits for running user programs.
+// Managed runtimes (JVM, V8, Go) reserve large virtual regions up front, so they
+// skip the address space cap derived from the memory limit and rely on heap
+// flags and peak RSS instead, under a fixed generous cap if they tolerate one.
+// Native programs get twice the memory limit as address space so that runaway
+// allocations are stopped while peak RSS decides MLE.
+func runLimits(lang Language, timeLimit time.Duration, memoryLimitMB int) SandboxLimits {
+	timeout := languageTimeLimit(lang, timeLimit)
+	limits := SandboxLimits{
+		WallTime:  timeout,
+		CPUTime:   timeout,
+		FileSize:  SANDBOX_MAX_FILE_SIZE,
+		Processes: SANDBOX_MAX_PROCESSES,
+		Stack:     SANDBOX_MAX_STACK,
+	}
+	if !lang.ManagedMemory() {
+		limits.AddressSpace = int64(2*memoryLimitMB) << 20
+		if limits.AddressSpace > SANDBOX_MAX_ADDRESS_SPACE {
+			limits.AddressSpace = SANDBOX_MAX_ADDRESS_SPACE
+		}
+	} else if lang.AddressSpaceMB() > 0 {
+		limits.AddressSpace = int64(lang.AddressSpaceMB()) << 20
 	}
+	return limits
+}
 
-	return results, err
+// isOutOfMemory reports whether a run exceeded the memory limit, either by
+// peak RSS or by a managed runtime giving up on its heap
+func isOutOfMemory(runResult *SandboxResult, stderr string, memoryLimitMB int) bool {
+	if runResult.MaxRSSKB > int64(memoryLimitMB)*1024 {
+		return true
+	}
+	// Allocation failures under the address space cap count as MLE too
+	return strings.Contains(stderr, "std::bad_alloc") ||
+		strings.Contains(stderr, "java.lang.OutOfMemoryError") ||
+		strings.Contains(stderr, "JavaScript heap out of memory") ||
+		strings.Contains(stderr, "MemoryError") ||
+		strings.Contains(stderr, "fatal error: out of memory")
 }
 
-// runTestCase executes a single test case
-func (s *ExecutionService) runTestCase(binaryFile string, testCase models.TestCase, caseNumber int) models.ExecutionResult {
-	result := models.ExecutionResult{
-		CaseNumber:     caseNumber,
-		Input:          testCase.Input,
-		ExpectedOutput: testCase.ExpectedOutput,
+// compileLimits returns the sandbox limits for compilers
+func compileLimits(timeout time.Duration) SandboxLimits {
+	return SandboxLimits{
+		WallTime:  timeout,
+		CPUTime:   timeout,
+		FileSize:  SANDBOX_MAX_FILE_SIZE,
+		Processes: SANDBOX_MAX_PROCESSES,
 	}
+}
 
-	// Create command
-	cmd := exec.Command(binaryFile)
+// QueueFull reports whether the execution pool currently rejects new executions
+func (s *ExecutionService) QueueFull() bool {
+	return s.pool.Full()
+}
 
-	// Setup stdin
-	cmd.Stdin = strings.NewReader(testCase.Input)
+// Execute compiles and runs code against test cases. Compilation failures are
+// reported as a CE response; the error is only set when the code could not be judged.
+// ErrExecutionQueueFull is returned when the execution pool is saturated, and an
+// InvalidInputError when a test case fails the problem's validator.
+func (s *ExecutionService) Execute(ctx context.Context, code string, testCases []models.TestCase, language string, opts ExecutionOptions) (*models.ExecutionResponse, error) {
+	// Default to C++ if no language specified
+	if language == "" {
+		language = "cpp"
+	}
 
-	// Setup stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	lang, err := s.language(language)
+	if err != nil {
+		return nil, err
+	}
+	toolchain, err := lang.ResolveToolchain(opts.Toolchain)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create a channel to handle timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+	// Each execution gets its own private working directory
+	workDir, err := s.sandbox.CreateWorkDir()
+	if err != nil {
+		return nil, err
+	}
+	defer s.sandbox.RemoveWorkDir(workDir)
 
-	// Wait with timeout
-	select {
-	case err := <-done:
+	if opts.InteractorCode != "" {
+		interactorDir, err := s.prepareJudgeProgram(ctx, opts, opts.InteractorCode, "interactor")
 		if err != nil {
-			result.Error = fmt.Sprintf("Runtime error: %s", stderr.String())
-			result.Passed = false
-			return result
+			return nil, err
 		}
-	case <-time.After(EXECUTION_TIMEOUT):
-		cmd.Process.Kill()
-		result.Error = "Execution timeout (2s limit exceeded)"
-		result.Passed = false
-		return result
+		defer s.sandbox.RemoveWorkDir(interactorDir)
+		opts.interactorDir = interactorDir
+	} else if opts.CheckerCode != "" {
+		checkerDir, err := s.prepareJudgeProgram(ctx, opts, opts.CheckerCode, "checker")
+		if err != nil {
+			return nil, err
+		}
+		defer s.sandbox.RemoveWorkDir(checkerDir)
+		opts.checkerDir = checkerDir
 	}
 
-	// Get output and compare
-	actualOutput := strings.TrimSpace(stdout.String())
-	expectedOutput := strings.TrimSpace(testCase.ExpectedOutput)
+	// Invalid inputs are rejected before the code is compiled
+	if opts.ValidatorCode != "" {
+		validatorDir, err := s.prepareJudgeProgram(ctx, opts, opts.ValidatorCode, "validator")
+		if err != nil {
+			return nil, err
+		}
+		defer s.sandbox.RemoveWorkDir(validatorDir)
+		opts.validatorDir = validatorDir
 
-	result.ActualOutput = actualOutput
+		if err := s.validateTestCases(ctx, opts, testCases, 0); err != nil {
+			return nil, err
+		}
+	}
 
-	// If expected output is empty (e.g. custom test case without expectation),
-	// treat it as passed provided there was no runtime error (which is handled above).
-	// This allows playground execution to be "green" just by running successfully.
-	if expectedOutput == "" {
-		result.Passed = true
-	} else {
-		result.Passed = actualOutput == expectedOutput
+	var results []models.ExecutionResult
+	prog, err := s.executor.Build(ctx, opts, lang, toolchain, code, workDir)
+	// Tests are only generated for code that compiles, as far as the executor
+	// can tell before running it
+	if err == nil && opts.Generator != nil {
+		var generated []models.TestCase
+		generated, err = s.expandGeneratedTests(ctx, opts)
+		if err == nil && opts.validatorDir != "" {
+			err = s.validateTestCases(ctx, opts, generated, len(testCases))
+		}
+		testCases = append(slices.Clip(testCases), generated...)
+	}
+	if err == nil {
+		if opts.OnCompiled != nil {
+			opts.OnCompiled(len(testCases))
+		}
+		results, err = s.runTestCases(ctx, opts, prog, testCases)
+	}
+
+	var compileErr *CompilationError
+	if errors.As(err, &compileErr) {
+		return &models.ExecutionResponse{
+			Success:       false,
+			Verdict:       models.VerdictCompilationError,
+			Results:       []models.ExecutionResult{},
+			TotalCases:    len(testCases) + opts.GeneratedTestCount(),
+			CompileOutput: compileErr.Output,
+			Diagnostics:   ParseDiagnostics(compileErr.Output, lang.DiagnosticsFormat()),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return result
+	response := buildExecutionResponse(results)
+	if opts.Artifacts != nil {
+		response.ArtifactID = &opts.Artifacts.ID
+	}
+	if opts.Complexity != nil {
+		if !response.Success {
+			response.Complexity = &models.ComplexityEstimate{
+				Samples: []models.ComplexitySample{},
+				Fits:    []models.ComplexityFit{},
+				Note:    "The complexity is only estimated for solutions that pass every case",
+			}
+		} else if response.Complexity, err = s.estimateComplexity(ctx, opts, prog); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
 }
 
-// ValidateCode performs basic validation on code
-func (s *ExecutionService) ValidateCode(code string, language string) error {
-	if strings.TrimSpace(code) == "" {
-		return fmt.Errorf("code cannot be empty")
+// GeneratedTestCount returns the number of tests the generator adds
+func (o ExecutionOptions) GeneratedTestCount() int {
+	if o.Generator == nil {
+		return 0
 	}
+	return len(o.Generator.Tests)
+}
 
-	// Language-specific validation
-	switch language {
-	case "cpp":
-		if !strings.Contains(code, "int main") {
-			return fmt.Errorf("C++ code must contain a main function")
+// outputLimit returns the output limit of each test case in bytes
+func (o ExecutionOptions) outputLimit() int64 {
+	if o.OutputLimitMB > 0 {
+		return int64(min(o.OutputLimitMB, MAX_OUTPUT_LIMIT_MB)) << 20
+	}
+	return int64(config.Config.Execution.OutputLimitMB) << 20
+}
+
+// buildExecutionResponse aggregates test case results. The overall verdict is
+// the verdict of the first failing case, or AC when every case passed.
+func buildExecutionResponse(results []models.ExecutionResult) *models.ExecutionResponse {
+	response := &models.ExecutionResponse{
+		Verdict:    models.VerdictAccepted,
+		Results:    results,
+		TotalCases: len(results),
+	}
+
+	for _, result := range results {
+		if result.Passed {
+			response.TotalPassed++
+		} else if response.Verdict == models.VerdictAccepted {
+			response.Verdict = result.Verdict
+		}
+		if result.TimeMs > response.MaxTimeMs {
+			response.MaxTimeMs = result.TimeMs
 		}
-	case "python":
-		// Python doesn't require specific structure
-	case "java":
-		if !strings.Contains(code, "public static void main") {
-			return fmt.Errorf("Java code must contain a main method")
+		if result.MemoryKB > response.MaxMemoryKB {
+			response.MaxMemoryKB = result.MemoryKB
 		}
-	case "javascript":
-		// JavaScript doesn't require specific structure
 	}
+	response.Success = response.TotalPassed == len(results)
 
-	return nil
+	return response
 }
 
-// executeCpp compiles and runs C++ code
-func (s *ExecutionService) executeCpp(code string, testCases []models.TestCase, executionID string) ([]models.ExecutionResult, error) {
-	sourceFile := fmt.Sprintf("/tmp/temp_%s.cpp", executionID)
-	binaryFile := fmt.Sprintf("/tmp/bin_%s", executionID)
+// runTestCases runs all test cases in parallel on the execution pool.
+// Results keep the order of testCases regardless of completion order.
+func (s *ExecutionService) runTestCases(ctx context.Context, opts ExecutionOptions, prog Program, testCases []models.TestCase) ([]models.ExecutionResult, error) {
+	results := make([]models.ExecutionResult, len(testCases))
+	errs := make([]error, len(testCases))
 
-	err := os.WriteFile(sourceFile, []byte(code), 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write source file: %w", err)
+	tasks := make([]func(), len(testCases))
+	for i, testCase := range testCases {
+		tasks[i] = func() {
+			var output *ProgramOutput
+			results[i], output, errs[i] = s.runTestCase(ctx, opts, prog, testCase, i+1)
+			// Stored before the result is shortened to previews
+			if errs[i] == nil && opts.Artifacts != nil {
+				if err := opts.Artifacts.StoreCase(i+1, testCase, output); err != nil {
+					log.Printf("Failed to store the files of case %d: %v", i+1, err)
+				}
+			}
+			output.Close()
+			if testCase.Generated {
+				previewGeneratedResult(&results[i])
+			}
+			// Cases killed by cancellation are not real results
+			if errs[i] == nil && ctx.Err() == nil && opts.OnCaseResult != nil {
+				opts.OnCaseResult(results[i])
+			}
+		}
 	}
 
-	defer os.Remove(sourceFile)
-	defer os.Remove(binaryFile)
-
-	log.Printf("Compiling C++ code with execution ID: %s", executionID)
-	compileCmd := exec.Command("g++", "-O3", sourceFile, "-o", binaryFile)
-	compileOutput, err := compileCmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("compilation failed: %s", string(compileOutput))
+	if err := s.pool.Run(ctx, opts.UserID, tasks); err != nil {
+		return nil, err
 	}
 
-	results := make([]models.ExecutionResult, 0, len(testCases))
-	for i, testCase := range testCases {
-		result := s.runTestCase(binaryFile, testCase, i+1)
-		results = append(results, result)
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return results, nil
 }
 
-// executePython runs Python code
-func (s *ExecutionService) executePython(code string, testCases []models.TestCase, executionID string) ([]models.ExecutionResult, error) {
-	sourceFile := fmt.Sprintf("/tmp/temp_%s.py", executionID)
+// previewActualOutput keeps the first limit bytes of the actual output of a
+// result, e.g. of a generated test; OutputBytes still tells its full size
+func previewActualOutput(result *models.ExecutionResult, limit int) {
+	if len(result.ActualOutput) <= limit {
+		return
+	}
+	result.ActualOutput = strings.ToValidUTF8(result.ActualOutput[:limit], "")
+	result.OutputTruncated = true
+}
 
-	err := os.WriteFile(sourceFile, []byte(code), 0644)
+// runTestCase executes a single test case on the program's executor. The result
+// holds a preview of the output; the full output is returned too, when the
+// program ran and had its stdout captured, and the caller closes it. The error
+// is only set when the case could not be judged, e.g. because the checker failed.
+func (s *ExecutionService) runTestCase(ctx context.Context, opts ExecutionOptions, prog Program, testCase models.TestCase, caseNumber int) (models.ExecutionResult, *ProgramOutput, error) {
+	if opts.interactorDir != "" {
+		result, err := s.runInteractiveTestCase(ctx, opts, prog, testCase, caseNumber)
+		return result, nil, err
+	}
+
+	result := models.ExecutionResult{
+		CaseNumber:     caseNumber,
+		Input:          testCase.Input,
+		ExpectedOutput: testCase.ExpectedOutput,
+	}
+
+	runResult, err := prog.executor.Run(ctx, prog, testCase.Input, opts.outputLimit())
+	// Remote executors report code that does not compile when it first runs
+	var compileErr *CompilationError
+	if errors.As(err, &compileErr) {
+		return result, nil, err
+	}
+	if errors.Is(err, ErrJudgeUnavailable) || errors.Is(err, ErrExecutionQueueFull) {
+		return result, nil, fmt.Errorf("case %d: %w", caseNumber, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to write source file: %w", err)
+		log.Printf("Sandbox error on case %d: %v", caseNumber, err)
+		result.Verdict = models.VerdictRuntimeError
+		result.Error = fmt.Sprintf("Runtime error: %v", err)
+		result.Passed = false
+		return result, nil, nil
 	}
 
-	defer os.Remove(sourceFile)
+	output := runResult.Stdout
+	result.TimeMs = runResult.WallTime.Milliseconds()
+	result.CPUTimeMs = runResult.CPUTime.Milliseconds()
+	result.MemoryKB = runResult.MaxRSSKB
+	result.OutputBytes = output.Size()
+	result.ActualOutput = strings.TrimSpace(output.Preview())
+	result.OutputTruncated = output.Truncated()
 
-	log.Printf("Running Python code with execution ID: %s", executionID)
+	if prog.sanitized {
+		result.SanitizerFindings = ParseSanitizerReport(runResult.Stderr, prog.sourceFile)
+	}
 
-	results := make([]models.ExecutionResult, 0, len(testCases))
-	for i, testCase := range testCases {
-		result := s.runTestCaseWithCommand("python3", []string{sourceFile}, testCase, i+1)
-		results = append(results, result)
+	if runResult.OutputExceeded {
+		result.Verdict = models.VerdictOutputLimitExceeded
+		result.Error = fmt.Sprintf("Output limit exceeded (%d MB limit)", opts.outputLimit()>>20)
+		result.Passed = false
+		return result, output, nil
 	}
 
-	return results, nil
-}
+	if runResult.TimedOut {
+		result.Verdict = models.VerdictTimeLimitExceeded
+		result.Error = fmt.Sprintf("Execution timeout (%s limit exceeded)", prog.limits.WallTime)
+		result.Passed = false
+		return result, output, nil
+	}
 
-// executeJava compiles and runs Java code
-func (s *ExecutionService) executeJava(code string, testCases []models.TestCase, executionID string) ([]models.ExecutionResult, error) {
-	// Extract class name from code
-	className := "Main"
-	if strings.Contains(code, "public class") {
-		parts := strings.Split(code, "public class")
-		if len(parts) > 1 {
-			classPart := strings.TrimSpace(parts[1])
-			endIdx := strings.IndexAny(classPart, " {")
-			if endIdx > 0 {
-				className = classPart[:endIdx]
-			}
+	if isOutOfMemory(&runResult.SandboxResult, runResult.Stderr, prog.memoryLimitMB) {
+		result.Verdict = models.VerdictMemoryLimitExceeded
+		result.Error = fmt.Sprintf("Memory limit exceeded (%d MB limit)", prog.memoryLimitMB)
+		result.Passed = false
+		return result, output, nil
+	}
+
+	if runResult.ExitCode != 0 {
+		result.Verdict = models.VerdictRuntimeError
+		if len(result.SanitizerFindings) > 0 {
+			// The findings carry the useful part of the sanitizer's long report
+			finding := result.SanitizerFindings[0]
+			result.Error = fmt.Sprintf("Runtime error (%s: %s at %s:%d)", finding.Sanitizer, finding.Message, finding.File, finding.Line)
+		} else if runResult.Signal != 0 {
+			result.Error = fmt.Sprintf("Runtime error (%s): %s", runResult.Signal, previewText(runResult.Stderr, RESULT_OUTPUT_PREVIEW_BYTES))
+		} else {
+			result.Error = fmt.Sprintf("Runtime error (exit code %d): %s", runResult.ExitCode, previewText(runResult.Stderr, RESULT_OUTPUT_PREVIEW_BYTES))
 		}
+		result.Passed = false
+		return result, output, nil
 	}
 
-	sourceFile := fmt.Sprintf("/tmp/%s_%s.java", className, executionID)
-	classDir := fmt.Sprintf("/tmp/java_%s", executionID)
+	expectedOutput := strings.TrimSpace(testCase.ExpectedOutput)
 
-	err := os.MkdirAll(classDir, 0755)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create class directory: %w", err)
+	// If expected output is empty (e.g. custom test case without expectation),
+	// treat it as passed provided there was no runtime error (which is handled above).
+	// This allows playground execution to be "green" just by running successfully.
+	if expectedOutput == "" {
+		result.Passed = true
+	} else {
+		// Both the checker and the comparison read the full output from its
+		// file rather than from memory
+		actual, err := output.Reader()
+		if err != nil {
+			return result, output, fmt.Errorf("case %d: %w", caseNumber, err)
+		}
+		if opts.checkerDir != "" {
+			// The checker gets the raw output and decides itself how to parse it
+			passed, message, err := s.runChecker(ctx, opts.checkerDir, testCase, actual, caseNumber)
+			if err != nil {
+				return result, output, fmt.Errorf("case %d: %w", caseNumber, err)
+			}
+			result.Passed = passed
+			result.CheckerMessage = message
+		} else {
+			passed, err := CompareOutputReader(expectedOutput, actual, opts.ComparisonMode, opts.FloatEpsilon)
+			if err != nil {
+				return result, output, fmt.Errorf("case %d: failed to read program output: %w", caseNumber, err)
+			}
+			result.Passed = passed
+			// Computed on the full outputs, before generated tests are shortened
+			// to previews. Output beyond the expected one is only diffed in part.
+			if !result.Passed {
+				actualHead, cut, err := output.Head(len(expectedOutput) + RESULT_OUTPUT_PREVIEW_BYTES)
+				if err != nil {
+					return result, output, fmt.Errorf("case %d: failed to read program output: %w", caseNumber, err)
+				}
+				result.Diff = diffOutput(expectedOutput, strings.TrimSpace(actualHead), cut, opts.ComparisonMode, opts.FloatEpsilon)
+			}
+		}
 	}
 
-	err = os.WriteFile(sourceFile, []byte(code), 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write source file: %w", err)
+	if result.Passed {
+		result.Verdict = models.VerdictAccepted
+	} else {
+		result.Verdict = models.VerdictWrongAnswer
 	}
 
-	defer os.Remove(sourceFile)
-	defer os.RemoveAll(classDir)
+	return result, output, nil
+}
 
-	log.Printf("Compiling Java code with execution ID: %s", executionID)
-	compileCmd := exec.Command("javac", "-d", classDir, sourceFile)
-	compileOutput, err := compileCmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("compilation failed: %s", string(compileOutput))
+// Build writes the source file to workDir and compiles it when the language
+// needs it. Builds are reused from the compile cache when the same code was
+// compiled before.
+func (s *ExecutionService) Build(ctx context.Context, opts ExecutionOptions, lang Language, toolchain Toolchain, code string, workDir string) (Program, error) {
+	sourceFile := lang.SourceFile(code)
+	if err := os.WriteFile(filepath.Join(workDir, sourceFile), []byte(code), 0644); err != nil {
+		return Program{}, fmt.Errorf("failed to write source file: %w", err)
 	}
 
-	results := make([]models.ExecutionResult, 0, len(testCases))
-	for i, testCase := range testCases {
-		result := s.runTestCaseWithCommand("java", []string{"-cp", classDir, className}, testCase, i+1)
-		results = append(results, result)
+	memoryLimitMB := opts.memoryLimitMB()
+	env := lang.Env(toolchain, memoryLimitMB)
+	limits := runLimits(lang, opts.timeLimit(), memoryLimitMB)
+	compileCommand := lang.CompileCommand(code, toolchain)
+	sanitized := opts.Debug && compileCommand != nil && lang.DebugFlags() != nil
+	if sanitized {
+		compileCommand = append(compileCommand, lang.DebugFlags()...)
+		env = append(env, lang.DebugEnv()...)
+		// Sanitizers reserve terabytes of shadow memory
+		limits.AddressSpace = 0
+		limits.WallTime *= DEBUG_TIME_MULTIPLIER
+		limits.CPUTime *= DEBUG_TIME_MULTIPLIER
 	}
 
-	return results, nil
+	if compileCommand != nil {
+		cacheKey := s.compileCache.Key(lang.ID(), lang.Version(), compileCommand, env, code)
+		if s.compileCache.Restore(cacheKey, workDir) {
+			log.Printf("Reusing cached %s build in %s", lang.Name(), workDir)
+		} else {
+			log.Printf("Compiling %s code in %s", lang.Name(), workDir)
+			if _, err := s.compile(ctx, opts, workDir, lang.CompileTimeout(), env, compileCommand); err != nil {
+				return Program{}, err
+			}
+			if err := s.compileCache.Store(cacheKey, workDir, sourceFile); err != nil {
+				log.Printf("Failed to cache %s build: %v", lang.Name(), err)
+			}
+		}
+	} else {
+		log.Printf("Running %s code in %s", lang.Name(), workDir)
+	}
+
+	return Program{
+		executor:      s,
+		command:       lang.RunCommand(code, toolchain, memoryLimitMB),
+		env:           env,
+		dir:           workDir,
+		limits:        limits,
+		memoryLimitMB: memoryLimitMB,
+		sanitized:     sanitized,
+		sourceFile:    sourceFile,
+	}, nil
 }
 
-// executeJavaScript runs JavaScript code
-func (s *ExecutionService) executeJavaScript(code string, testCases []models.TestCase, executionID string) ([]models.ExecutionResult, error) {
-	sourceFile := fmt.Sprintf("/tmp/temp_%s.js", executionID)
+// Available reports whether the language's toolchain was found on this host
+func (s *ExecutionService) Available(lang Language) error {
+	return lang.Available()
+}
 
-	err := os.WriteFile(sourceFile, []byte(code), 0644)
+// Run runs a locally built program in the sandbox. Only a preview of stdout
+// and the start and end of stderr are kept in memory.
+func (s *ExecutionService) Run(ctx context.Context, prog Program, input string, outputLimit int64) (*ProgramRun, error) {
+	stdout := &ProgramOutput{}
+	stderr := &headTailBuffer{limit: RESULT_OUTPUT_PREVIEW_BYTES}
+	result, err := s.sandbox.Run(ctx, SandboxCommand{
+		Dir:    prog.dir,
+		Path:   prog.command[0],
+		Args:   prog.command[1:],
+		Env:    prog.env,
+		Stdin:  strings.NewReader(input),
+		Stdout: stdout,
+		Stderr: stderr,
+		Limits: prog.limits,
+		// Killed once it writes more, rather than filling the disk
+		OutputLimit: outputLimit,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to write source file: %w", err)
+		stdout.Close()
+		return nil, err
 	}
+	return &ProgramRun{SandboxResult: *result, Stdout: stdout, Stderr: stderr.String()}, nil
+}
 
-	defer os.Remove(sourceFile)
+// compile runs a compiler inside the sandbox on the execution pool and returns its combined output
+func (s *ExecutionService) compile(ctx context.Context, opts ExecutionOptions, workDir string, timeout time.Duration, env []string, command []string) (string, error) {
+	var output bytes.Buffer
+	var compileResult *SandboxResult
+	var err error
 
-	log.Printf("Running JavaScript code with execution ID: %s", executionID)
+	poolErr := s.pool.Run(ctx, opts.UserID, []func(){func() {
+		compileResult, err = s.sandbox.Run(ctx, SandboxCommand{
+			Dir:    workDir,
+			Path:   command[0],
+			Args:   command[1:],
+			Env:    env,
+			Stdout: &output,
+			Stderr: &output,
+			Limits: compileLimits(timeout),
+		})
+	}})
+	if poolErr != nil {
+		return "", poolErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run compiler: %w", err)
+	}
 
-	results := make([]models.ExecutionResult, 0, len(testCases))
-	for i, testCase := range testCases {
-		result := s.runTestCaseWithCommand("node", []string{sourceFile}, testCase, i+1)
-		results = append(results, result)
+	if compileResult.TimedOut {
+		return output.String(), &CompilationError{
+			Output: fmt.Sprintf("Compilation timeout (%s limit exceeded)\n%s", timeout, output.String()),
+		}
+	}
+	if compileResult.ExitCode != 0 {
+		return output.String(), &CompilationError{Output: output.String()}
 	}
 
-	return results, nil
+	return output.String(), nil
 }
 
-// runTestCaseWithCommand executes a test case with a custom command
-func (s *ExecutionService) runTestCaseWithCommand(command string, args []string, testCase models.TestCase, caseNumber int) models.ExecutionResult {
-	result := models.ExecutionResult{
-		CaseNumber:     caseNumber,
-		Input:          testCase.Input,
-		ExpectedOutput: testCase.ExpectedOutput,
+// ValidateCode performs basic validation on code
+func (s *ExecutionService) ValidateCode(code string, language string) error {
+	if strings.TrimSpace(code) == "" {
+		return fmt.Errorf("code cannot be empty")
 	}
 
-	cmd := exec.Command(command, args...)
-	cmd.Stdin = strings.NewReader(testCase.Input)
+	lang, err := s.language(language)
+	if err != nil {
+		return err
+	}
+
+	// Language-specific validation
+	return lang.Validate(code)
+}
+
+// ValidateLocalCode validates code like ValidateCode for programs that always
+// run in the local sandbox, such as those of stress tests
+func (s *ExecutionService) ValidateLocalCode(code string, language string) error {
+	if strings.TrimSpace(code) == "" {
+		return fmt.Errorf("code cannot be empty")
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	lang, ok := s.languages.Get(language)
+	if !ok {
+		return fmt.Errorf("unsupported language: %s", language)
+	}
+	if err := lang.Available(); err != nil {
+		return fmt.Errorf("%s is not available on this server: %w", lang.Name(), err)
+	}
+	return lang.Validate(code)
+}
 
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+// language returns the language with the given id if the executor can run it
+func (s *ExecutionService) language(id string) (Language, error) {
+	lang, ok := s.languages.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("unsupported language: %s", id)
+	}
+	if err := s.executor.Available(lang); err != nil {
+		return nil, fmt.Errorf("%s is not available on this server: %w", lang.Name(), err)
+	}
+	return lang, nil
+}
 
-	select {
-	case err := <-done:
-		if err != nil {
-			result.Error = fmt.Sprintf("Runtime error: %s", stderr.String())
-			result.Passed = false
-			return result
-		}
-	case <-time.After(EXECUTION_TIMEOUT):
-		cmd.Process.Kill()
-		result.Error = "Execution timeout (2s limit exceeded)"
-		result.Passed = false
-		return result
+// ValidateToolchain checks that the selected toolchain options exist for the language
+func (s *ExecutionService) ValidateToolchain(language string, toolchain map[string]string) error {
+	lang, ok := s.languages.Get(language)
+	if !ok {
+		return fmt.Errorf("unsupported language: %s", language)
 	}
+	_, err := lang.ResolveToolchain(toolchain)
+	return err
+}
 
-	actualOutput := strings.TrimSpace(stdout.String())
-	expectedOutput := strings.TrimSpace(testCase.ExpectedOutput)
+// LanguageInfo describes a language and its toolchain options for clients
+type LanguageInfo struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Version string            `json:"version"`
+	Options []ToolchainOption `json:"options"`
+	// Available is false when the language cannot be run on this server, for
+	// the reason in UnavailableReason
+	Available         bool   `json:"available"`
+	UnavailableReason string `json:"unavailable_reason,omitempty"`
+	// SourceFile and RequiredSnippet hint at the shape of a program, e.g.
+	// "Main.java" and "public static void main"
+	SourceFile      string `json:"source_file"`
+	RequiredSnippet string `json:"required_snippet,omitempty"`
+}
 
-	result.ActualOutput = actualOutput
+// ProblemLimits are the effective per-case limits of a problem
+type ProblemLimits struct {
+	TimeLimitMs   int `json:"time_limit_ms"`
+	MemoryLimitMB int `json:"memory_limit_mb"`
+	OutputLimitMB int `json:"output_limit_mb"`
+	// LanguageTimeLimitsMs is the time limit of each language after its multiplier
+	LanguageTimeLimitsMs map[string]int `json:"language_time_limits_ms"`
+}
 
-	if expectedOutput == "" {
-		result.Passed = true
-	} else {
-		result.Passed = actualOutput == expectedOutput
+// Limits resolves a problem's limits the way Execute applies them, with
+// defaults for unset limits
+func (s *ExecutionService) Limits(timeLimitMs int, memoryLimitMB int, outputLimitMB int) ProblemLimits {
+	opts := ExecutionOptions{TimeLimitMs: timeLimitMs, MemoryLimitMB: memoryLimitMB, OutputLimitMB: outputLimitMB}
+	limits := ProblemLimits{
+		TimeLimitMs:          int(opts.timeLimit().Milliseconds()),
+		MemoryLimitMB:        opts.memoryLimitMB(),
+		OutputLimitMB:        int(opts.outputLimit() >> 20),
+		LanguageTimeLimitsMs: make(map[string]int),
+	}
+	for _, lang := range s.languages.List() {
+		limits.LanguageTimeLimitsMs[lang.ID()] = int(languageTimeLimit(lang, opts.timeLimit()).Milliseconds())
 	}
+	return limits
+}
 
-	return result
+// Languages lists the supported languages with the toolchains found at startup
+// and whether they can be run
+func (s *ExecutionService) Languages() []LanguageInfo {
+	languages := s.languages.List()
+	infos := make([]LanguageInfo, len(languages))
+	for i, lang := range languages {
+		infos[i] = LanguageInfo{
+			ID:              lang.ID(),
+			Name:            lang.Name(),
+			Version:         lang.Version(),
+			Options:         lang.Options(),
+			Available:       true,
+			SourceFile:      lang.SourceFile(""),
+			RequiredSnippet: lang.RequiredSnippet(),
+		}
+		if err := s.executor.Available(lang); err != nil {
+			infos[i].Available = false
+			infos[i].UnavailableReason = err.Error()
+		}
+	}
+	return infos
 }
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+	"github.com/google/uuid"
+)
+
+// countingProgram prints the numbers 1 to n
+const countingProgram = `#include <cstdio>
+int main() {
+    int n;
+    scanf("%d", &n);
+    for (int i = 1; i <= n; i++) printf("%d\n", i);
+}
+`
+
+func TestExecuteLargeOutput(t *testing.T) {
+	s := newTestExecutionService(t)
+	var expected strings.Builder
+	for i := 1; i <= 100000; i++ {
+		expected.WriteString(strconv.Itoa(i) + "\n")
+	}
+	wrong := strings.Replace(expected.String(), "\n99999\n", "\n-1\n", 1)
+
+	testCases := []models.TestCase{
+		{Input: "100000", ExpectedOutput: expected.String()},
+		{Input: "100000", ExpectedOutput: wrong},
+	}
+	for _, mode := range []models.ComparisonMode{models.ComparisonExact, models.ComparisonTokens, models.ComparisonUnordered} {
+		response, err := s.Execute(context.Background(), countingProgram, testCases, "cpp", ExecutionOptions{ComparisonMode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The full output is judged, but only its start is returned
+		passed, failed := response.Results[0], response.Results[1]
+		if passed.Verdict != models.VerdictAccepted || failed.Verdict != models.VerdictWrongAnswer {
+			t.Fatalf("%s: got %s and %s, want AC and WA", mode, passed.Verdict, failed.Verdict)
+		}
+		if passed.OutputBytes != int64(expected.Len()) || !passed.OutputTruncated || len(passed.ActualOutput) > RESULT_OUTPUT_PREVIEW_BYTES {
+			t.Errorf("%s: %d of %d bytes returned, truncated %v", mode, len(passed.ActualOutput), passed.OutputBytes, passed.OutputTruncated)
+		}
+		if failed.Diff == nil || (mode != models.ComparisonUnordered && failed.Diff.FirstMismatchLine != 99999) {
+			t.Errorf("%s: diff %+v, want the mismatch on line 99999", mode, failed.Diff)
+		}
+	}
+
+	// The full output of a failed run is stored as an artifact
+	store, err := NewArtifactStore(t.TempDir(), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := uuid.New()
+	response, err := s.Execute(context.Background(), countingProgram, testCases[1:], "cpp", ExecutionOptions{Artifacts: store.New(userID)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Success || response.ArtifactID == nil {
+		t.Fatalf("success %v with artifact %v, want a failed run with an artifact", response.Success, response.ArtifactID)
+	}
+	f, err := store.Open(userID, *response.ArtifactID, 1, ArtifactActual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	actual, err := io.ReadAll(f)
+	if err != nil || string(actual) != expected.String() {
+		t.Errorf("stored %d bytes (%v), want the %d bytes of the full output", len(actual), err, expected.Len())
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+)
+
+func TestOutputLimit(t *testing.T) {
+	defaultLimitMB := config.Config.Execution.OutputLimitMB
+	config.Config.Execution.OutputLimitMB = 64
+	defer func() { config.Config.Execution.OutputLimitMB = defaultLimitMB }()
+
+	tests := []struct {
+		name          string
+		outputLimitMB int
+		wantMB        int64
+	}{
+		{"default", 0, 64},
+		{"problem limit", 8, 8},
+		{"oversized problem limit", 100000, MAX_OUTPUT_LIMIT_MB},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := ExecutionOptions{OutputLimitMB: tt.outputLimitMB}
+			if got := opts.outputLimit(); got != tt.wantMB<<20 {
+				t.Errorf("outputLimit() = %d, want %d", got, tt.wantMB<<20)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	defaultMemoryMB, defaultOutputMB := config.Config.Execution.MemoryLimitMB, config.Config.Execution.OutputLimitMB
+	config.Config.Execution.MemoryLimitMB, config.Config.Execution.OutputLimitMB = 256, 64
+	defer func() {
+		config.Config.Execution.MemoryLimitMB, config.Config.Execution.OutputLimitMB = defaultMemoryMB, defaultOutputMB
+	}()
+
+	registry, err := NewLanguageRegistry([]config.LanguageConfig{
+		{ID: "native", SourceFile: "main.sh", Run: []string{"sh", "main.sh"}},
+		{ID: "python", SourceFile: "main.py", Run: []string{"python3", "main.py"}, TimeMultiplier: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewExecutionService(nil, nil, registry, nil, nil, nil)
+
+	tests := []struct {
+		name                            string
+		timeLimitMs, memoryMB, outputMB int
+		want                            ProblemLimits
+	}{
+		{"defaults", 0, 0, 0, ProblemLimits{2000, 256, 64, map[string]int{"native": 2000, "python": 6000}}},
+		{"problem limits", 1500, 512, 16, ProblemLimits{1500, 512, 16, map[string]int{"native": 1500, "python": 4500}}},
+		{"below minimums", 10, 1, 0, ProblemLimits{250, MIN_MEMORY_LIMIT_MB, 64, map[string]int{"native": 250, "python": 750}}},
+		{"above maximums", 60000, 100000, 100000, ProblemLimits{10000, MAX_MEMORY_LIMIT_MB, MAX_OUTPUT_LIMIT_MB, map[string]int{"native": 10000, "python": 30000}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Limits(tt.timeLimitMs, tt.memoryMB, tt.outputMB)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Limits() = %+v, want %+v", got, tt.want)
+			}
+
+			// The limits a build runs with are the ones reported
+			opts := ExecutionOptions{TimeLimitMs: tt.timeLimitMs, MemoryLimitMB: tt.memoryMB, OutputLimitMB: tt.outputMB}
+			if opts.outputLimit() != int64(got.OutputLimitMB)<<20 {
+				t.Errorf("Execute output limit %d bytes, reported %d MB", opts.outputLimit(), got.OutputLimitMB)
+			}
+			for _, lang := range registry.List() {
+				prog, err := s.Build(context.Background(), opts, lang, nil, "", t.TempDir())
+				if err != nil {
+					t.Fatalf("Build(%s) error = %v", lang.ID(), err)
+				}
+				if prog.limits.WallTime.Milliseconds() != int64(got.LanguageTimeLimitsMs[lang.ID()]) || prog.limits.CPUTime != prog.limits.WallTime {
+					t.Errorf("%s runs with time limit %v, reported %d ms", lang.ID(), prog.limits.WallTime, got.LanguageTimeLimitsMs[lang.ID()])
+				}
+				if prog.memoryLimitMB != got.MemoryLimitMB {
+					t.Errorf("%s runs with memory limit %d MB, reported %d MB", lang.ID(), prog.memoryLimitMB, got.MemoryLimitMB)
+				}
+			}
+		})
+	}
+}
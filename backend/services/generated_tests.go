@@ -0,0 +1,212 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// GENERATED_TEST_PREVIEW_BYTES is how much of the input and outputs of a
+// generated test is sent back to clients
+const GENERATED_TEST_PREVIEW_BYTES = 512
+
+// GENERATED_TEST_MAX_BYTES caps the input printed by a generator and the
+// expected output printed by the reference solution
+const GENERATED_TEST_MAX_BYTES = 32 << 20
+
+// TestGenerator describes hidden tests that are produced at judge time: the
+// generator prints the input of each test and the reference solution prints
+// its expected output. Both are C++ programs, like checkers.
+type TestGenerator struct {
+	GeneratorCode string
+	ReferenceCode string
+	Tests         []models.GeneratedTest
+}
+
+// expandGeneratedTests turns the generated tests of opts.Generator into test
+// cases. Expanded tests are cached on disk by the generator, reference and
+// arguments, so the programs only run the first time a problem is judged.
+func (s *ExecutionService) expandGeneratedTests(ctx context.Context, opts ExecutionOptions) ([]models.TestCase, error) {
+	generator := opts.Generator
+
+	testCases := make([]models.TestCase, len(generator.Tests))
+	var missing []int
+	for i, test := range generator.Tests {
+		input, expected, ok := s.readGeneratedTest(generatedTestKey(generator, test))
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+		testCases[i] = models.TestCase{Input: input, ExpectedOutput: expected, Generated: true}
+	}
+	if len(missing) == 0 {
+		return testCases, nil
+	}
+
+	generatorDir, err := s.prepareJudgeProgram(ctx, opts, generator.GeneratorCode, "generator")
+	if err != nil {
+		return nil, err
+	}
+	defer s.sandbox.RemoveWorkDir(generatorDir)
+
+	referenceDir, err := s.prepareJudgeProgram(ctx, opts, generator.ReferenceCode, "reference")
+	if err != nil {
+		return nil, err
+	}
+	defer s.sandbox.RemoveWorkDir(referenceDir)
+
+	log.Printf("Generating %d hidden tests", len(missing))
+	errs := make([]error, len(missing))
+	tasks := make([]func(), len(missing))
+	for j, i := range missing {
+		tasks[j] = func() {
+			test := generator.Tests[i]
+			input, expected, err := s.generateTest(ctx, generatorDir, referenceDir, test)
+			if err != nil {
+				errs[j] = fmt.Errorf("generated test %d: %w", i+1, err)
+				return
+			}
+			if err := s.writeGeneratedTest(generatedTestKey(generator, test), input, expected); err != nil {
+				log.Printf("Failed to cache generated test %d: %v", i+1, err)
+			}
+			testCases[i] = models.TestCase{Input: input, ExpectedOutput: expected, Generated: true}
+		}
+	}
+
+	if err := s.pool.Run(ctx, opts.UserID, tasks); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return testCases, nil
+}
+
+// generateTest runs the generator for one test and the reference solution on its input
+func (s *ExecutionService) generateTest(ctx context.Context, generatorDir string, referenceDir string, test models.GeneratedTest) (string, string, error) {
+	var input, stderr bytes.Buffer
+	generateResult, err := s.sandbox.Run(ctx, SandboxCommand{
+		Dir:         generatorDir,
+		Path:        "./generator",
+		Args:        append([]string{strconv.FormatInt(test.Seed, 10)}, test.Args...),
+		Stdout:      &input,
+		Stderr:      &stderr,
+		Limits:      checkerLimits(),
+		OutputLimit: GENERATED_TEST_MAX_BYTES,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to run generator: %w", err)
+	}
+	if generateResult.TimedOut {
+		return "", "", fmt.Errorf("generator timeout (%s limit exceeded)", CHECKER_TIMEOUT)
+	}
+	if generateResult.OutputExceeded {
+		return "", "", fmt.Errorf("generator output exceeds %dMB", GENERATED_TEST_MAX_BYTES>>20)
+	}
+	if generateResult.ExitCode != 0 {
+		return "", "", fmt.Errorf("generator failed with exit code %d: %s", generateResult.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	var expected bytes.Buffer
+	stderr.Reset()
+	referenceResult, err := s.sandbox.Run(ctx, SandboxCommand{
+		Dir:         referenceDir,
+		Path:        "./reference",
+		Stdin:       bytes.NewReader(input.Bytes()),
+		Stdout:      &expected,
+		Stderr:      &stderr,
+		Limits:      checkerLimits(),
+		OutputLimit: GENERATED_TEST_MAX_BYTES,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to run reference solution: %w", err)
+	}
+	if referenceResult.TimedOut {
+		return "", "", fmt.Errorf("reference solution timeout (%s limit exceeded)", CHECKER_TIMEOUT)
+	}
+	if referenceResult.OutputExceeded {
+		return "", "", fmt.Errorf("reference solution output exceeds %dMB", GENERATED_TEST_MAX_BYTES>>20)
+	}
+	if referenceResult.ExitCode != 0 {
+		return "", "", fmt.Errorf("reference solution failed with exit code %d: %s", referenceResult.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	return input.String(), expected.String(), nil
+}
+
+// generatedTestKey derives the cache key of a generated test
+func generatedTestKey(generator *TestGenerator, test models.GeneratedTest) string {
+	hash := sha256.New()
+	for _, part := range append([]string{generator.GeneratorCode, generator.ReferenceCode, strconv.FormatInt(test.Seed, 10)}, test.Args...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// readGeneratedTest loads a cached test
+func (s *ExecutionService) readGeneratedTest(key string) (string, string, bool) {
+	dir, err := os.MkdirTemp("", "generated-test-")
+	if err != nil {
+		return "", "", false
+	}
+	defer os.RemoveAll(dir)
+
+	if !s.testCache.Restore(key, dir) {
+		return "", "", false
+	}
+	input, err := os.ReadFile(filepath.Join(dir, "input.txt"))
+	if err != nil {
+		return "", "", false
+	}
+	expected, err := os.ReadFile(filepath.Join(dir, "expected.txt"))
+	if err != nil {
+		return "", "", false
+	}
+	return string(input), string(expected), true
+}
+
+// writeGeneratedTest caches a test
+func (s *ExecutionService) writeGeneratedTest(key string, input string, expected string) error {
+	dir, err := os.MkdirTemp("", "generated-test-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "input.txt"), []byte(input), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "expected.txt"), []byte(expected), 0644); err != nil {
+		return err
+	}
+	return s.testCache.Store(key, dir)
+}
+
+// previewGeneratedResult shortens the input and outputs of a generated test
+// result, which can be megabytes long
+func previewGeneratedResult(result *models.ExecutionResult) {
+	result.Input = previewText(result.Input, GENERATED_TEST_PREVIEW_BYTES)
+	result.ExpectedOutput = previewText(result.ExpectedOutput, GENERATED_TEST_PREVIEW_BYTES)
+	previewActualOutput(result, GENERATED_TEST_PREVIEW_BYTES)
+}
+
+// previewText keeps the first limit bytes of text and notes its full size
+func previewText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	preview := strings.ToValidUTF8(text[:limit], "")
+	return fmt.Sprintf("%s\n... (%d bytes total)", preview, len(text))
+}
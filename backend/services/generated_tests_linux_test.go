@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// countingGenerator prints n and the numbers seed to seed+n-1, or n bytes of
+// padding when called with "huge n"
+const countingGenerator = `#include <cstdio>
+#include <cstdlib>
+#include <cstring>
+int main(int argc, char** argv) {
+    long long seed = atoll(argv[1]);
+    if (argc > 3 && strcmp(argv[2], "huge") == 0) {
+        long long n = atoll(argv[3]);
+        for (long long i = 0; i < n; i++) putchar('x');
+        return 0;
+    }
+    int n = atoi(argv[2]);
+    printf("%d\n", n);
+    for (int i = 0; i < n; i++) printf("%lld ", seed + i);
+    printf("\n");
+}
+`
+
+// sumReference prints the sum of the numbers after n
+const sumReference = `#include <cstdio>
+int main() {
+    int n;
+    long long x, sum = 0;
+    scanf("%d", &n);
+    for (int i = 0; i < n; i++) {
+        scanf("%lld", &x);
+        sum += x;
+    }
+    printf("%lld\n", sum);
+}
+`
+
+func TestExpandGeneratedTests(t *testing.T) {
+	s := newTestExecutionService(t)
+	generator := &TestGenerator{
+		GeneratorCode: countingGenerator,
+		ReferenceCode: sumReference,
+		Tests: []models.GeneratedTest{
+			{Seed: 1, Args: []string{"3"}},
+			{Seed: 10, Args: []string{"2"}},
+		},
+	}
+	want := []models.TestCase{
+		{Input: "3\n1 2 3 \n", ExpectedOutput: "6\n", Generated: true},
+		{Input: "2\n10 11 \n", ExpectedOutput: "21\n", Generated: true},
+	}
+
+	testCases, err := s.expandGeneratedTests(context.Background(), ExecutionOptions{Generator: generator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(testCases, want) {
+		t.Fatalf("got %+v, want %+v", testCases, want)
+	}
+
+	// Expanded tests come from the test cache; this service has no sandbox to
+	// run the generator in
+	cached := &ExecutionService{testCache: s.testCache}
+	testCases, err = cached.expandGeneratedTests(context.Background(), ExecutionOptions{Generator: generator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(testCases, want) {
+		t.Errorf("cached: got %+v, want %+v", testCases, want)
+	}
+
+	// Generator output is capped
+	generator.Tests = []models.GeneratedTest{{Seed: 1, Args: []string{"huge", strconv.Itoa(GENERATED_TEST_MAX_BYTES + 1)}}}
+	_, err = s.expandGeneratedTests(context.Background(), ExecutionOptions{Generator: generator})
+	if err == nil || !strings.Contains(err.Error(), "generator output exceeds 32MB") {
+		t.Errorf("oversized input: got error %v", err)
+	}
+}
@@ -15,19 +15,22 @@ import (
 )
 
 type generationService struct {
-	db           *gorm.DB
-	llmProvider  LLMProvider
-	statsService *statsService
-	rateLimiter  *utils.RateLimiter
+	db               *gorm.DB
+	llmProvider      LLMProvider
+	statsService     *statsService
+	rateLimiter      *utils.RateLimiter
+	executionService *ExecutionService
 }
 
-// NewGenerationService creates a new GenerationService instance
-func NewGenerationService(db *gorm.DB, llmProvider LLMProvider, statsService *statsService, rateLimiter *utils.RateLimiter) *generationService {
+// NewGenerationService creates a new GenerationService instance. The execution
+// service runs the reference solutions that verify generated test cases.
+func NewGenerationService(db *gorm.DB, llmProvider LLMProvider, statsService *statsService, rateLimiter *utils.RateLimiter, executionService *ExecutionService) *generationService {
 	return &generationService{
-		db:           db,
-		llmProvider:  llmProvider,
-		statsService: statsService,
-		rateLimiter:  rateLimiter,
+		db:               db,
+		llmProvider:      llmProvider,
+		statsService:     statsService,
+		rateLimiter:      rateLimiter,
+		executionService: executionService,
 	}
 }
 
@@ -45,22 +48,26 @@ func normalizeRating(rating int, context string) int {
 	return rating
 }
 
-// GenerateFirstProblem generates the first problem synchronously
-func (s *generationService) GenerateFirstProblem(ctx context.Context, sessionID uuid.UUID, contextStr string, strategy string) (*models.ProblemGenerationResponse, error) {
+// GenerateFirstProblem generates and verifies the first problem synchronously
+func (s *generationService) GenerateFirstProblem(ctx context.Context, sessionID uuid.UUID, contextStr string, strategy string) (*models.ProblemGenerationResponse, ProblemVerification, error) {
 	var session models.InterviewSession
 	if err := s.db.WithContext(ctx).Where("id = ?", sessionID).First(&session).Error; err != nil {
-		return nil, fmt.Errorf("query session: %w", err)
+		return nil, ProblemVerification{}, fmt.Errorf("query session: %w", err)
 	}
 
 	focusAreas := s.selectFocusAreas(&session, 1, strategy)
 
-	problemResponse, err := s.llmProvider.GenerateProblem(ctx, focusAreas, contextStr, nil)
+	problemResponse, verification, err := s.GenerateVerifiedProblem(ctx, func() (*models.ProblemGenerationResponse, error) {
+		return s.llmProvider.GenerateProblem(ctx, focusAreas, contextStr, nil)
+	}, func(attempt int, reason string) {
+		s.reportRetry(ctx, sessionID, 1, attempt, reason)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("generate problem: %w", err)
+		return nil, ProblemVerification{}, fmt.Errorf("generate problem: %w", err)
 	}
 
 	problemResponse.Rating = normalizeRating(problemResponse.Rating, "first problem")
-	return problemResponse, nil
+	return problemResponse, verification, nil
 }
 
 // StartBackgroundQueue launches goroutine to generate remaining problems
@@ -105,12 +112,17 @@ func (s *generationService) GenerateProblemWithRetry(ctx context.Context, sessio
 
 	focusAreas := s.selectFocusAreas(&session, problemNumber, strategy)
 
-	var problemResponse *models.ProblemGenerationResponse
-	var generateErr error
-
-	err := s.rateLimiter.ExecuteWithBackoff(ctx, func() error {
-		problemResponse, generateErr = s.llmProvider.GenerateProblem(ctx, focusAreas, contextStr, nil)
-		return generateErr
+	problemResponse, verification, err := s.GenerateVerifiedProblem(ctx, func() (*models.ProblemGenerationResponse, error) {
+		var problemResponse *models.ProblemGenerationResponse
+		var generateErr error
+
+		err := s.rateLimiter.ExecuteWithBackoff(ctx, func() error {
+			problemResponse, generateErr = s.llmProvider.GenerateProblem(ctx, focusAreas, contextStr, nil)
+			return generateErr
+		})
+		return problemResponse, err
+	}, func(attempt int, reason string) {
+		s.reportRetry(ctx, sessionID, problemNumber, attempt, reason)
 	})
 
 	if err != nil {
@@ -128,19 +140,19 @@ func (s *generationService) GenerateProblemWithRetry(ctx context.Context, sessio
 	sessionProblem := models.SessionProblem{
 		SessionID:     sessionID,
 		ProblemNumber: problemNumber,
-		Status:        "ready",
+		Status:        verification.Status,
 		ProblemData:   models.ProblemData(problemData),
 		GeneratedAt:   &now,
 	}
+	if verification.Reason != "" {
+		sessionProblem.ErrorMessage = &verification.Reason
+	}
 
-	if err := s.db.WithContext(ctx).
-		Where("session_id = ? AND problem_number = ?", sessionID, problemNumber).
-		Assign(sessionProblem).
-		FirstOrCreate(&sessionProblem).Error; err != nil {
+	if err := s.storeSessionProblem(ctx, &sessionProblem); err != nil {
 		return fmt.Errorf("store session problem: %w", err)
 	}
 
-	log.Printf("Generated problem %d for session %s", problemNumber, sessionID)
+	log.Printf("Generated problem %d for session %s (%s)", problemNumber, sessionID, verification.Status)
 	return nil
 }
 
@@ -181,6 +193,33 @@ func (s *generationService) selectFocusAreas(session *models.InterviewSession, p
 	}
 }
 
+// storeSessionProblem replaces the placeholder of a session problem with the
+// generated problem. The error message is always written, so that progress
+// notes on the placeholder do not outlive it.
+func (s *generationService) storeSessionProblem(ctx context.Context, sessionProblem *models.SessionProblem) error {
+	return s.db.WithContext(ctx).
+		Where("session_id = ? AND problem_number = ?", sessionProblem.SessionID, sessionProblem.ProblemNumber).
+		Assign(map[string]interface{}{
+			"status":        sessionProblem.Status,
+			"problem_data":  sessionProblem.ProblemData,
+			"generated_at":  sessionProblem.GeneratedAt,
+			"error_message": sessionProblem.ErrorMessage,
+		}).
+		FirstOrCreate(sessionProblem).Error
+}
+
+// reportRetry notes on a generating session problem that it is generated
+// again, so that clients polling the session can show the progress
+func (s *generationService) reportRetry(ctx context.Context, sessionID uuid.UUID, problemNumber int, attempt int, reason string) {
+	message := fmt.Sprintf("Generating again (attempt %d/%d): %s", attempt, GENERATION_VERIFY_ATTEMPTS, reason)
+	if err := s.db.WithContext(ctx).
+		Model(&models.SessionProblem{}).
+		Where("session_id = ? AND problem_number = ? AND status = ?", sessionID, problemNumber, models.ProblemStatusGenerating).
+		Update("error_message", message).Error; err != nil {
+		log.Printf("Failed to report generation progress: %v", err)
+	}
+}
+
 // markProblemFailed updates session_problems status to failed
 func (s *generationService) markProblemFailed(ctx context.Context, sessionID uuid.UUID, problemNumber int, errorMessage string) {
 	if err := s.db.WithContext(ctx).
@@ -0,0 +1,537 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// harnessType is a type of function signatures in each harness language
+type harnessType struct {
+	cpp        string
+	java       string
+	python     string
+	javascript string
+	// javaConvert is the Harness method turning a parsed JSON value into the Java type
+	javaConvert string
+	// depth is 0 for scalars, 1 for arrays, printed on one line, and 2 for
+	// matrices, printed one row per line
+	depth int
+}
+
+var harnessTypes = map[string]harnessType{
+	"int":      {"int", "int", "int", "number", "toInt", 0},
+	"long":     {"long long", "long", "int", "bigint", "toLong", 0},
+	"double":   {"double", "double", "float", "number", "toDouble", 0},
+	"bool":     {"bool", "boolean", "bool", "boolean", "toBool", 0},
+	"string":   {"string", "String", "str", "string", "toStr", 0},
+	"int[]":    {"vector<int>", "int[]", "list[int]", "number[]", "toIntArray", 1},
+	"long[]":   {"vector<long long>", "long[]", "list[int]", "bigint[]", "toLongArray", 1},
+	"double[]": {"vector<double>", "double[]", "list[float]", "number[]", "toDoubleArray", 1},
+	"string[]": {"vector<string>", "String[]", "list[str]", "string[]", "toStrArray", 1},
+	"int[][]":  {"vector<vector<int>>", "int[][]", "list[list[int]]", "number[][]", "toIntMatrix", 2},
+}
+
+// HARNESS_LANGUAGES are the languages function problems can be solved in
+var HARNESS_LANGUAGES = []string{"cpp", "java", "python", "javascript"}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateSignature checks that a function signature can be turned into a harness
+func ValidateSignature(sig *models.FunctionSignature) error {
+	if sig == nil {
+		return fmt.Errorf("function problem has no signature")
+	}
+	if !identifierPattern.MatchString(sig.Name) || sig.Name == "main" {
+		return fmt.Errorf("invalid function name %q", sig.Name)
+	}
+	if _, ok := harnessTypes[sig.ReturnType]; !ok {
+		return fmt.Errorf("unsupported return type %q", sig.ReturnType)
+	}
+	names := make(map[string]bool)
+	for _, param := range sig.Params {
+		if !identifierPattern.MatchString(param.Name) || names[param.Name] {
+			return fmt.Errorf("invalid or duplicate parameter name %q", param.Name)
+		}
+		names[param.Name] = true
+		if _, ok := harnessTypes[param.Type]; !ok {
+			return fmt.Errorf("unsupported type %q of parameter %s", param.Type, param.Name)
+		}
+	}
+	return nil
+}
+
+// BuildHarness turns the function a user wrote for a function problem into a
+// program: the harness reads one JSON value per parameter from the lines of
+// stdin, calls the function and prints the result, arrays space separated and
+// matrices one row per line. Doubles are printed like printf("%.12g") in every
+// language, so that the output matches the C++ reference. JavaScript gets long
+// values as BigInt. The user's code keeps its line numbers, so compiler
+// messages point into the editor.
+func BuildHarness(language string, sig *models.FunctionSignature, code string) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return "", fmt.Errorf("code cannot be empty")
+	}
+	if err := ValidateSignature(sig); err != nil {
+		return "", err
+	}
+
+	switch language {
+	case "cpp":
+		return cppHarness(sig, code), nil
+	case "java":
+		return javaHarness(sig, code), nil
+	case "python":
+		return pythonHarness(sig, code), nil
+	case "javascript":
+		return javascriptHarness(sig, code), nil
+	}
+	return "", fmt.Errorf("function problems cannot be solved in %s, use C++, Java, Python or JavaScript", language)
+}
+
+// StarterCode returns the empty function users start from in each harness language
+func StarterCode(sig *models.FunctionSignature) map[string]string {
+	if ValidateSignature(sig) != nil {
+		return nil
+	}
+	returnType := harnessTypes[sig.ReturnType]
+
+	params := func(format func(param models.FunctionParam, t harnessType) string) string {
+		parts := make([]string, len(sig.Params))
+		for i, param := range sig.Params {
+			parts[i] = format(param, harnessTypes[param.Type])
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	cppParams := params(func(param models.FunctionParam, t harnessType) string {
+		if t.depth > 0 || param.Type == "string" {
+			return t.cpp + "& " + param.Name
+		}
+		return t.cpp + " " + param.Name
+	})
+	javaParams := params(func(param models.FunctionParam, t harnessType) string { return t.java + " " + param.Name })
+	pythonParams := params(func(param models.FunctionParam, t harnessType) string { return param.Name + ": " + t.python })
+	javascriptParams := params(func(param models.FunctionParam, t harnessType) string { return param.Name })
+
+	var jsDoc strings.Builder
+	jsDoc.WriteString("/**\n")
+	for _, param := range sig.Params {
+		fmt.Fprintf(&jsDoc, " * @param {%s} %s\n", harnessTypes[param.Type].javascript, param.Name)
+	}
+	fmt.Fprintf(&jsDoc, " * @return {%s}\n */\n", returnType.javascript)
+
+	return map[string]string{
+		"cpp": fmt.Sprintf("#include <bits/stdc++.h>\nusing namespace std;\n\n%s %s(%s) {\n    \n}\n",
+			returnType.cpp, sig.Name, cppParams),
+		"java": fmt.Sprintf("import java.util.*;\n\n// Keep the class non-public: the harness adds the public Main class\nclass Solution {\n    public %s %s(%s) {\n        \n    }\n}\n",
+			returnType.java, sig.Name, javaParams),
+		"python":     fmt.Sprintf("def %s(%s) -> %s:\n    pass\n", sig.Name, pythonParams, returnType.python),
+		"javascript": fmt.Sprintf("%sfunction %s(%s) {\n    \n}\n", jsDoc.String(), sig.Name, javascriptParams),
+	}
+}
+
+// cppHarness compiles the function as a free function. A #line directive keeps
+// the line numbers of the user's code after the prepended includes.
+func cppHarness(sig *models.FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString("#include <bits/stdc++.h>\nusing namespace std;\n#line 1\n")
+	b.WriteString(code)
+	b.WriteString(cppHarnessLibrary)
+	b.WriteString("\nint main() {\n    vector<string> lines = harness::readLines();\n")
+	fmt.Fprintf(&b, "    harness::expectLines(lines, %d);\n", len(sig.Params))
+	args := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&b, "    auto arg%d = harness::parse<%s>(lines[%d]);\n", i, harnessTypes[param.Type].cpp, i)
+	}
+	fmt.Fprintf(&b, "    auto result = %s(%s);\n", sig.Name, strings.Join(args, ", "))
+	b.WriteString("    cout << setprecision(12);\n    harness::write(cout, result);\n    cout << endl;\n    return 0;\n}\n")
+	return b.String()
+}
+
+const cppHarnessLibrary = `
+
+namespace harness {
+
+// Json is a parsed JSON value: an array with items, a string, or any other
+// literal kept as text
+struct Json {
+    bool array = false;
+    string text;
+    vector<Json> items;
+};
+
+struct Parser {
+    const string& s;
+    size_t i = 0;
+
+    void skipSpace() {
+        while (i < s.size() && isspace((unsigned char)s[i])) i++;
+    }
+
+    Json value() {
+        skipSpace();
+        if (i >= s.size()) throw runtime_error("unexpected end of input line");
+        Json v;
+        if (s[i] == '[') {
+            v.array = true;
+            i++;
+            skipSpace();
+            if (i < s.size() && s[i] == ']') {
+                i++;
+                return v;
+            }
+            while (true) {
+                v.items.push_back(value());
+                skipSpace();
+                if (i < s.size() && s[i] == ',') {
+                    i++;
+                } else if (i < s.size() && s[i] == ']') {
+                    i++;
+                    return v;
+                } else {
+                    throw runtime_error("expected , or ] in input line");
+                }
+            }
+        }
+        if (s[i] == '"') {
+            for (i++; i < s.size() && s[i] != '"'; i++) {
+                char c = s[i];
+                if (c == '\\' && i + 1 < s.size()) {
+                    c = s[++i];
+                    if (c == 'n') c = '\n';
+                    if (c == 't') c = '\t';
+                }
+                v.text += c;
+            }
+            i++;
+            return v;
+        }
+        while (i < s.size() && s[i] != ',' && s[i] != ']' && !isspace((unsigned char)s[i])) v.text += s[i++];
+        return v;
+    }
+};
+
+void read(const Json& v, int& x) { x = stoi(v.text); }
+void read(const Json& v, long long& x) { x = stoll(v.text); }
+void read(const Json& v, double& x) { x = stod(v.text); }
+void read(const Json& v, bool& x) { x = v.text == "true"; }
+void read(const Json& v, string& x) { x = v.text; }
+template <class T> void read(const Json& v, vector<T>& x) {
+    x.resize(v.items.size());
+    for (size_t k = 0; k < x.size(); k++) read(v.items[k], x[k]);
+}
+
+template <class T> T parse(const string& line) {
+    Parser parser{line};
+    T x;
+    read(parser.value(), x);
+    return x;
+}
+
+vector<string> readLines() {
+    vector<string> lines;
+    string line;
+    while (getline(cin, line)) {
+        if (!line.empty() && line.back() == '\r') line.pop_back();
+        if (!line.empty()) lines.push_back(line);
+    }
+    return lines;
+}
+
+void expectLines(const vector<string>& lines, size_t count) {
+    if (lines.size() < count) {
+        cerr << "expected " << count << " input lines, one per argument, got " << lines.size() << endl;
+        exit(1);
+    }
+}
+
+void write(ostream& out, bool x) { out << (x ? "true" : "false"); }
+void write(ostream& out, const string& x) { out << x; }
+template <class T> void write(ostream& out, const T& x) { out << x; }
+template <class T> void write(ostream& out, const vector<T>& x) {
+    for (size_t k = 0; k < x.size(); k++) {
+        if (k > 0) out << ' ';
+        write(out, x[k]);
+    }
+}
+template <class T> void write(ostream& out, const vector<vector<T>>& x) {
+    for (size_t k = 0; k < x.size(); k++) {
+        if (k > 0) out << '\n';
+        write(out, x[k]);
+    }
+}
+
+}  // namespace harness
+`
+
+// javaHarness calls the function on a new Solution. The imports share the
+// first line with the user's code, which keeps its line numbers, and the public
+// Main class after it is found as the entry class.
+func javaHarness(sig *models.FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString("import java.util.*; import java.io.*; import java.math.*; ")
+	b.WriteString(code)
+	b.WriteString(javaHarnessLibrary)
+	b.WriteString("    static void run() throws IOException {\n        List<String> lines = readLines();\n")
+	fmt.Fprintf(&b, "        expectLines(lines, %d);\n", len(sig.Params))
+	args := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		t := harnessTypes[param.Type]
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&b, "        %s arg%d = %s(new Harness(lines.get(%d)).value());\n", t.java, i, t.javaConvert, i)
+	}
+	fmt.Fprintf(&b, "        %s result = new Solution().%s(%s);\n", harnessTypes[sig.ReturnType].java, sig.Name, strings.Join(args, ", "))
+	b.WriteString("        System.out.println(format(result));\n    }\n}\n")
+	b.WriteString("\npublic class Main {\n    public static void main(String[] args) throws IOException {\n        Harness.run();\n    }\n}\n")
+	return b.String()
+}
+
+const javaHarnessLibrary = `
+
+// Harness parses JSON arguments into strings for literals and lists for arrays
+@SuppressWarnings("unchecked")
+class Harness {
+    private final String s;
+    private int i = 0;
+
+    Harness(String s) {
+        this.s = s;
+    }
+
+    private void skipSpace() {
+        while (i < s.length() && Character.isWhitespace(s.charAt(i))) i++;
+    }
+
+    Object value() {
+        skipSpace();
+        if (i >= s.length()) throw new IllegalArgumentException("unexpected end of input line");
+        if (s.charAt(i) == '[') {
+            List<Object> items = new ArrayList<>();
+            i++;
+            skipSpace();
+            if (i < s.length() && s.charAt(i) == ']') {
+                i++;
+                return items;
+            }
+            while (true) {
+                items.add(value());
+                skipSpace();
+                if (i < s.length() && s.charAt(i) == ',') {
+                    i++;
+                } else if (i < s.length() && s.charAt(i) == ']') {
+                    i++;
+                    return items;
+                } else {
+                    throw new IllegalArgumentException("expected , or ] in input line");
+                }
+            }
+        }
+        StringBuilder text = new StringBuilder();
+        if (s.charAt(i) == '"') {
+            for (i++; i < s.length() && s.charAt(i) != '"'; i++) {
+                char c = s.charAt(i);
+                if (c == '\\' && i + 1 < s.length()) {
+                    c = s.charAt(++i);
+                    if (c == 'n') c = '\n';
+                    if (c == 't') c = '\t';
+                }
+                text.append(c);
+            }
+            i++;
+            return text.toString();
+        }
+        while (i < s.length() && s.charAt(i) != ',' && s.charAt(i) != ']' && !Character.isWhitespace(s.charAt(i))) text.append(s.charAt(i++));
+        return text.toString();
+    }
+
+    static List<String> readLines() throws IOException {
+        BufferedReader reader = new BufferedReader(new InputStreamReader(System.in));
+        List<String> lines = new ArrayList<>();
+        for (String line = reader.readLine(); line != null; line = reader.readLine()) {
+            if (!line.trim().isEmpty()) lines.add(line);
+        }
+        return lines;
+    }
+
+    static void expectLines(List<String> lines, int count) {
+        if (lines.size() < count) {
+            System.err.println("expected " + count + " input lines, one per argument, got " + lines.size());
+            System.exit(1);
+        }
+    }
+
+    static int toInt(Object v) { return Integer.parseInt((String) v); }
+    static long toLong(Object v) { return Long.parseLong((String) v); }
+    static double toDouble(Object v) { return Double.parseDouble((String) v); }
+    static boolean toBool(Object v) { return v.equals("true"); }
+    static String toStr(Object v) { return (String) v; }
+
+    static int[] toIntArray(Object v) {
+        List<Object> items = (List<Object>) v;
+        int[] x = new int[items.size()];
+        for (int k = 0; k < x.length; k++) x[k] = toInt(items.get(k));
+        return x;
+    }
+
+    static long[] toLongArray(Object v) {
+        List<Object> items = (List<Object>) v;
+        long[] x = new long[items.size()];
+        for (int k = 0; k < x.length; k++) x[k] = toLong(items.get(k));
+        return x;
+    }
+
+    static double[] toDoubleArray(Object v) {
+        List<Object> items = (List<Object>) v;
+        double[] x = new double[items.size()];
+        for (int k = 0; k < x.length; k++) x[k] = toDouble(items.get(k));
+        return x;
+    }
+
+    static String[] toStrArray(Object v) {
+        List<Object> items = (List<Object>) v;
+        String[] x = new String[items.size()];
+        for (int k = 0; k < x.length; k++) x[k] = toStr(items.get(k));
+        return x;
+    }
+
+    static int[][] toIntMatrix(Object v) {
+        List<Object> items = (List<Object>) v;
+        int[][] x = new int[items.size()][];
+        for (int k = 0; k < x.length; k++) x[k] = toIntArray(items.get(k));
+        return x;
+    }
+
+    static String format(int x) { return String.valueOf(x); }
+    static String format(long x) { return String.valueOf(x); }
+    static String format(boolean x) { return String.valueOf(x); }
+    static String format(String x) { return x; }
+
+    // format prints a double like printf("%.12g"), as the C++ reference does
+    static String format(double x) {
+        if (Double.isNaN(x)) return "nan";
+        if (Double.isInfinite(x)) return x > 0 ? "inf" : "-inf";
+        if (x == 0) return 1 / x < 0 ? "-0" : "0";
+        BigDecimal d = new BigDecimal(x).round(new MathContext(12, RoundingMode.HALF_EVEN)).stripTrailingZeros();
+        String digits = d.unscaledValue().abs().toString();
+        int e = digits.length() - 1 - d.scale();
+        if (e >= -4 && e < 12) return d.toPlainString();
+        String mantissa = digits.length() > 1 ? digits.charAt(0) + "." + digits.substring(1) : digits;
+        return (x < 0 ? "-" : "") + mantissa + "e" + (e < 0 ? "-" : "+") + (Math.abs(e) < 10 ? "0" : "") + Math.abs(e);
+    }
+
+    static String format(int[] x) { return format(Arrays.stream(x).mapToObj(Harness::format).toArray(String[]::new)); }
+    static String format(long[] x) { return format(Arrays.stream(x).mapToObj(Harness::format).toArray(String[]::new)); }
+    static String format(double[] x) { return format(Arrays.stream(x).mapToObj(Harness::format).toArray(String[]::new)); }
+    static String format(String[] x) { return String.join(" ", x); }
+
+    static String format(int[][] x) {
+        StringBuilder out = new StringBuilder();
+        for (int k = 0; k < x.length; k++) {
+            if (k > 0) out.append('\n');
+            out.append(format(x[k]));
+        }
+        return out.toString();
+    }
+
+`
+
+// pythonHarness appends the harness, which leaves the user's line numbers as they are
+func pythonHarness(sig *models.FunctionSignature, code string) string {
+	return fmt.Sprintf(`%s
+
+
+# Harness: one JSON argument per input line, the result printed on stdout
+import json as _harness_json
+import sys as _harness_sys
+
+_HARNESS_DOUBLE = %s
+
+
+def _harness_format(value, depth):
+    if depth == 2:
+        return "\n".join(_harness_format(row, 1) for row in value)
+    if depth == 1:
+        return " ".join(_harness_format(item, 0) for item in value)
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    if _HARNESS_DOUBLE:
+        # Like the C++ reference
+        return "%%.12g" %% value
+    return str(value)
+
+
+if __name__ == "__main__":
+    _harness_lines = [line for line in _harness_sys.stdin.read().splitlines() if line.strip()]
+    if len(_harness_lines) < %d:
+        _harness_sys.exit("expected %d input lines, one per argument, got %%d" %% len(_harness_lines))
+    _harness_args = [_harness_json.loads(line) for line in _harness_lines[:%d]]
+    print(_harness_format(%s(*_harness_args), %d))
+`, code, pythonBool(isDouble(sig.ReturnType)), len(sig.Params), len(sig.Params), len(sig.Params), sig.Name, harnessTypes[sig.ReturnType].depth)
+}
+
+// pythonBool spells a bool in Python
+func pythonBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
+
+// javascriptHarness appends the harness in its own block, which leaves the
+// user's line numbers as they are
+func javascriptHarness(sig *models.FunctionSignature, code string) string {
+	args := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		switch param.Type {
+		case "long":
+			args[i] = fmt.Sprintf("BigInt(parseLongs(lines[%d]))", i)
+		case "long[]":
+			args[i] = fmt.Sprintf("parseLongs(lines[%d]).map(BigInt)", i)
+		default:
+			args[i] = fmt.Sprintf("JSON.parse(lines[%d])", i)
+		}
+	}
+
+	scalarFormat := "String"
+	if isDouble(sig.ReturnType) {
+		scalarFormat = "formatDouble"
+	}
+
+	return fmt.Sprintf(`%s
+
+// Harness: one JSON argument per input line, the result printed on stdout
+{
+    const lines = require("fs").readFileSync(0, "utf8").split("\n").filter((line) => line.trim() !== "");
+    if (lines.length < %d) {
+        console.error("expected %d input lines, one per argument, got " + lines.length);
+        process.exit(1);
+    }
+    // Integers are parsed as strings first, since numbers lose precision above 2^53
+    const parseLongs = (line) => JSON.parse(line.replace(/-?\d+/g, (digits) => '"' + digits + '"'));
+    // Like printf("%%.12g"), as the C++ reference prints doubles
+    const formatDouble = (x) => {
+        if (!Number.isFinite(x)) return Number.isNaN(x) ? "nan" : x > 0 ? "inf" : "-inf";
+        if (x === 0) return Object.is(x, -0) ? "-0" : "0";
+        const strip = (text) => (text.includes(".") ? text.replace(/0+$/, "").replace(/\.$/, "") : text);
+        const [mantissa, exponent] = x.toExponential(11).split("e");
+        const e = Number(exponent);
+        if (e >= -4 && e < 12) return strip(x.toFixed(11 - e));
+        return strip(mantissa) + "e" + (e < 0 ? "-" : "+") + String(Math.abs(e)).padStart(2, "0");
+    };
+    const format = (value, depth) =>
+        depth === 2 ? value.map((row) => format(row, 1)).join("\n") :
+        depth === 1 ? value.map((item) => format(item, 0)).join(" ") :
+        %s(value);
+    console.log(format(%s(%s), %d));
+}
+`, code, len(sig.Params), len(sig.Params), scalarFormat, sig.Name, strings.Join(args, ", "), harnessTypes[sig.ReturnType].depth)
+}
+
+// isDouble reports whether values of a signature type are doubles
+func isDouble(typ string) bool {
+	return strings.HasPrefix(typ, "double")
+}
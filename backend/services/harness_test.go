@@ -0,0 +1,229 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+func TestValidateSignature(t *testing.T) {
+	param := func(name, typ string) models.FunctionParam { return models.FunctionParam{Name: name, Type: typ} }
+
+	tests := []struct {
+		name string
+		sig  *models.FunctionSignature
+		ok   bool
+	}{
+		{"valid", &models.FunctionSignature{Name: "solve", Params: []models.FunctionParam{param("nums", "int[]"), param("k", "int")}, ReturnType: "long"}, true},
+		{"no params", &models.FunctionSignature{Name: "answer", ReturnType: "int[][]"}, true},
+		{"missing", nil, false},
+		{"main", &models.FunctionSignature{Name: "main", ReturnType: "int"}, false},
+		{"invalid name", &models.FunctionSignature{Name: "two sum", ReturnType: "int"}, false},
+		{"unknown return type", &models.FunctionSignature{Name: "solve", ReturnType: "map<int,int>"}, false},
+		{"unknown param type", &models.FunctionSignature{Name: "solve", Params: []models.FunctionParam{param("x", "float")}, ReturnType: "int"}, false},
+		{"duplicate param", &models.FunctionSignature{Name: "solve", Params: []models.FunctionParam{param("x", "int"), param("x", "int")}, ReturnType: "int"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSignature(tt.sig)
+			if (err == nil) != tt.ok {
+				t.Errorf("ValidateSignature() error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBuildHarness(t *testing.T) {
+	tests := []struct {
+		name      string
+		sig       *models.FunctionSignature
+		input     string
+		want      string
+		solutions map[string]string
+	}{
+		{
+			name: "arrays and strings",
+			sig: &models.FunctionSignature{
+				Name:       "scale",
+				Params:     []models.FunctionParam{{Name: "nums", Type: "int[]"}, {Name: "k", Type: "int"}, {Name: "label", Type: "string"}},
+				ReturnType: "int[][]",
+			},
+			input: "[1, -2, 3]\n\n2\n\"ab\"\n",
+			want:  "2 -4 6\n2 -4 6\n",
+			solutions: map[string]string{
+				"cpp": `vector<vector<int>> scale(vector<int>& nums, int k, string& label) {
+    vector<vector<int>> rows(label.size());
+    for (auto& row : rows) for (int x : nums) row.push_back(x * k);
+    return rows;
+}
+`,
+				"java": `class Solution {
+    public int[][] scale(int[] nums, int k, String label) {
+        int[][] rows = new int[label.length()][nums.length];
+        for (int[] row : rows) for (int i = 0; i < nums.length; i++) row[i] = nums[i] * k;
+        return rows;
+    }
+}
+`,
+				"python": `def scale(nums, k, label):
+    return [[x * k for x in nums] for _ in label]
+`,
+				"javascript": `function scale(nums, k, label) {
+    return [...label].map(() => nums.map((x) => x * k));
+}
+`,
+			},
+		},
+		{
+			// Doubles print like the C++ reference in every language
+			name: "doubles",
+			sig: &models.FunctionSignature{
+				Name:       "times",
+				Params:     []models.FunctionParam{{Name: "xs", Type: "double[]"}, {Name: "factor", Type: "double"}},
+				ReturnType: "double[]",
+			},
+			input: "[0.1, 2.9, 0.00001, 1e15, -0.25, 2]\n3\n",
+			want:  "0.3 8.7 3e-05 3e+15 -0.75 6\n",
+			solutions: map[string]string{
+				"cpp": `vector<double> times(vector<double>& xs, double factor) {
+    for (double& x : xs) x *= factor;
+    return xs;
+}
+`,
+				"java": `class Solution {
+    public double[] times(double[] xs, double factor) {
+        for (int i = 0; i < xs.length; i++) xs[i] *= factor;
+        return xs;
+    }
+}
+`,
+				"python": `def times(xs, factor):
+    return [x * factor for x in xs]
+`,
+				"javascript": `function times(xs, factor) {
+    return xs.map((x) => x * factor);
+}
+`,
+			},
+		},
+		{
+			// Longs above 2^53 keep their precision
+			name: "longs",
+			sig: &models.FunctionSignature{
+				Name:       "total",
+				Params:     []models.FunctionParam{{Name: "nums", Type: "long[]"}, {Name: "extra", Type: "long"}},
+				ReturnType: "long",
+			},
+			input: "[9007199254740993, -1]\n3\n",
+			want:  "9007199254740995\n",
+			solutions: map[string]string{
+				"cpp": `long long total(vector<long long>& nums, long long extra) {
+    return accumulate(nums.begin(), nums.end(), extra);
+}
+`,
+				"java": `class Solution {
+    public long total(long[] nums, long extra) {
+        for (long x : nums) extra += x;
+        return extra;
+    }
+}
+`,
+				"python": `def total(nums, extra):
+    return sum(nums) + extra
+`,
+				"javascript": `function total(nums, extra) {
+    return nums.reduce((sum, x) => sum + x, extra);
+}
+`,
+			},
+		},
+	}
+
+	// Source file and build command of each language, then the run command
+	toolchains := map[string]struct {
+		source string
+		build  []string
+		run    []string
+	}{
+		"cpp":        {"main.cpp", []string{"g++", "-std=c++17", "-o", "main", "main.cpp"}, []string{"./main"}},
+		"java":       {"Main.java", []string{"javac", "Main.java"}, []string{"java", "-cp", ".", "Main"}},
+		"python":     {"main.py", nil, []string{"python3", "main.py"}},
+		"javascript": {"main.js", nil, []string{"node", "main.js"}},
+	}
+
+	for _, tt := range tests {
+		for _, language := range HARNESS_LANGUAGES {
+			t.Run(tt.name+"/"+language, func(t *testing.T) {
+				program, err := BuildHarness(language, tt.sig, tt.solutions[language])
+				if err != nil {
+					t.Fatalf("BuildHarness() error = %v", err)
+				}
+
+				toolchain := toolchains[language]
+				for _, command := range [][]string{toolchain.build, toolchain.run} {
+					if len(command) > 0 && !strings.HasPrefix(command[0], ".") {
+						if _, err := exec.LookPath(command[0]); err != nil {
+							t.Skipf("%s not installed", command[0])
+						}
+					}
+				}
+				dir := t.TempDir()
+				if err := os.WriteFile(filepath.Join(dir, toolchain.source), []byte(program), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if len(toolchain.build) > 0 {
+					build := exec.Command(toolchain.build[0], toolchain.build[1:]...)
+					build.Dir = dir
+					if output, err := build.CombinedOutput(); err != nil {
+						t.Fatalf("compile failed: %v\n%s", err, output)
+					}
+				}
+
+				run := exec.Command(toolchain.run[0], toolchain.run[1:]...)
+				run.Dir = dir
+				run.Stdin = strings.NewReader(tt.input)
+				output, err := run.CombinedOutput()
+				if err != nil {
+					t.Fatalf("run failed: %v\n%s", err, output)
+				}
+				if string(output) != tt.want {
+					t.Errorf("output %q, want %q", output, tt.want)
+				}
+			})
+		}
+	}
+
+	sig := tests[0].sig
+	if _, err := BuildHarness("go", sig, "func scale() {}"); err == nil {
+		t.Error("harness built for an unsupported language")
+	}
+	if _, err := BuildHarness("cpp", sig, "  \n"); err == nil {
+		t.Error("harness built for empty code")
+	}
+}
+
+func TestStarterCode(t *testing.T) {
+	sig := &models.FunctionSignature{
+		Name:       "twoSum",
+		Params:     []models.FunctionParam{{Name: "nums", Type: "int[]"}, {Name: "target", Type: "int"}},
+		ReturnType: "int[]",
+	}
+	starter := StarterCode(sig)
+
+	want := map[string]string{
+		"cpp":        "vector<int> twoSum(vector<int>& nums, int target) {",
+		"java":       "public int[] twoSum(int[] nums, int target) {",
+		"python":     "def twoSum(nums: list[int], target: int) -> list[int]:",
+		"javascript": "function twoSum(nums, target) {",
+	}
+	for _, language := range HARNESS_LANGUAGES {
+		if !strings.Contains(starter[language], want[language]) {
+			t.Errorf("%s starter code %q does not declare %q", language, starter[language], want[language])
+		}
+	}
+}
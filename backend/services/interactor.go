@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// interactorQueriesPattern matches the "queries: N" line an interactor prints
+// on stderr to report how many queries the program made
+var interactorQueriesPattern = regexp.MustCompile(`(?m)^queries:\s*(\d+)\s*$`)
+
+// interactorLimits returns the sandbox limits for an interactor. It mostly
+// waits for the user program, so its wall time covers the program's limit.
+func interactorLimits(programLimits SandboxLimits) SandboxLimits {
+	limits := checkerLimits()
+	limits.WallTime = programLimits.WallTime + CHECKER_TIMEOUT
+	return limits
+}
+
+// runInteractiveTestCase runs the user program and the interactor with each
+// one's stdout connected to the other's stdin. The interactor is called as
+// "interactor <input>" and decides the verdict through its exit code, like a
+// checker. Resource verdicts (TLE, MLE) still come from the user program.
+func (s *ExecutionService) runInteractiveTestCase(ctx context.Context, opts ExecutionOptions, prog Program, testCase models.TestCase, caseNumber int) (models.ExecutionResult, error) {
+	result := models.ExecutionResult{
+		CaseNumber:     caseNumber,
+		Input:          testCase.Input,
+		ExpectedOutput: testCase.ExpectedOutput,
+	}
+
+	inputFile := fmt.Sprintf("input-%d.txt", caseNumber)
+	if err := os.WriteFile(filepath.Join(opts.interactorDir, inputFile), []byte(testCase.Input), 0644); err != nil {
+		return result, fmt.Errorf("failed to write interactor input: %w", err)
+	}
+
+	toInteractorReader, toInteractorWriter, err := os.Pipe()
+	if err != nil {
+		return result, fmt.Errorf("failed to create interactor pipe: %w", err)
+	}
+	toProgramReader, toProgramWriter, err := os.Pipe()
+	if err != nil {
+		toInteractorReader.Close()
+		toInteractorWriter.Close()
+		return result, fmt.Errorf("failed to create interactor pipe: %w", err)
+	}
+
+	// Each side closes the parent's copies of its pipe ends once started, so
+	// that either process sees EOF as soon as the other one exits
+	var interactorStderr bytes.Buffer
+	var interactorResult *SandboxResult
+	var interactorErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		interactorResult, interactorErr = s.sandbox.Run(ctx, SandboxCommand{
+			Dir:             opts.interactorDir,
+			Path:            "./interactor",
+			Args:            []string{inputFile},
+			Stdin:           toInteractorReader,
+			Stdout:          toProgramWriter,
+			Stderr:          &outputLimiter{w: &interactorStderr, limit: opts.outputLimit(), exceeded: func() {}},
+			Limits:          interactorLimits(prog.limits),
+			CloseAfterStart: []io.Closer{toInteractorReader, toProgramWriter},
+		})
+	}()
+
+	// Stdout goes to the interactor, which reads no more than it expects, and
+	// stderr is cut off at the output limit without killing the program; only
+	// its start and end are kept
+	stderr := &headTailBuffer{limit: RESULT_OUTPUT_PREVIEW_BYTES}
+	runResult, err := s.sandbox.Run(ctx, SandboxCommand{
+		Dir:             prog.dir,
+		Path:            prog.command[0],
+		Args:            prog.command[1:],
+		Env:             prog.env,
+		Stdin:           toProgramReader,
+		Stdout:          toInteractorWriter,
+		Stderr:          &outputLimiter{w: stderr, limit: opts.outputLimit(), exceeded: func() {}},
+		Limits:          prog.limits,
+		CloseAfterStart: []io.Closer{toProgramReader, toInteractorWriter},
+	})
+	wg.Wait()
+
+	if interactorErr != nil {
+		return result, fmt.Errorf("case %d: failed to run interactor: %w", caseNumber, interactorErr)
+	}
+	if err != nil {
+		log.Printf("Sandbox error on case %d: %v", caseNumber, err)
+		result.Verdict = models.VerdictRuntimeError
+		result.Error = fmt.Sprintf("Runtime error: %v", err)
+		return result, nil
+	}
+
+	result.TimeMs = runResult.WallTime.Milliseconds()
+	result.CPUTimeMs = runResult.CPUTime.Milliseconds()
+	result.MemoryKB = runResult.MaxRSSKB
+
+	message := interactorStderr.String()
+	if match := interactorQueriesPattern.FindStringSubmatch(message); match != nil {
+		result.QueryCount, _ = strconv.Atoi(match[1])
+		message = interactorQueriesPattern.ReplaceAllString(message, "")
+	}
+	message = strings.TrimSpace(message)
+	result.CheckerMessage = message
+
+	if runResult.TimedOut {
+		result.Verdict = models.VerdictTimeLimitExceeded
+		result.Error = fmt.Sprintf("Execution timeout (%s limit exceeded)", prog.limits.WallTime)
+		return result, nil
+	}
+
+	if isOutOfMemory(runResult, stderr.String(), prog.memoryLimitMB) {
+		result.Verdict = models.VerdictMemoryLimitExceeded
+		result.Error = fmt.Sprintf("Memory limit exceeded (%d MB limit)", prog.memoryLimitMB)
+		return result, nil
+	}
+
+	if interactorResult.TimedOut {
+		return result, fmt.Errorf("case %d: interactor timeout (%s limit exceeded)", caseNumber, interactorLimits(prog.limits).WallTime)
+	}
+
+	switch interactorResult.ExitCode {
+	case CHECKER_EXIT_WRONG_ANSWER, CHECKER_EXIT_PRESENTATION_ERROR:
+		// Takes precedence over a crash: the program usually dies of SIGPIPE
+		// when the interactor stops reading after a wrong answer
+		result.Verdict = models.VerdictWrongAnswer
+		return result, nil
+	case CHECKER_EXIT_ACCEPTED:
+	default:
+		return result, fmt.Errorf("case %d: interactor failed with exit code %d: %s", caseNumber, interactorResult.ExitCode, message)
+	}
+
+	if runResult.ExitCode != 0 {
+		result.Verdict = models.VerdictRuntimeError
+		if runResult.Signal != 0 {
+			result.Error = fmt.Sprintf("Runtime error (%s): %s", runResult.Signal, previewText(stderr.String(), RESULT_OUTPUT_PREVIEW_BYTES))
+		} else {
+			result.Error = fmt.Sprintf("Runtime error (exit code %d): %s", runResult.ExitCode, previewText(stderr.String(), RESULT_OUTPUT_PREVIEW_BYTES))
+		}
+		return result, nil
+	}
+
+	result.Passed = true
+	result.Verdict = models.VerdictAccepted
+	return result, nil
+}
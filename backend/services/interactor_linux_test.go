@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// guessInteractor hides the number from its input file. It answers "? x"
+// queries with <, > or = and checks the final "! x", and crashes when the
+// hidden number is 0.
+const guessInteractor = `#include <cstdio>
+#include <cstdlib>
+int main(int argc, char** argv) {
+    FILE* input = fopen(argv[1], "r");
+    int secret;
+    if (fscanf(input, "%d", &secret) != 1) return 3;
+    if (secret == 0) abort();
+    int queries = 0;
+    char kind;
+    int x;
+    while (scanf(" %c %d", &kind, &x) == 2) {
+        if (kind == '!') {
+            fprintf(stderr, "queries: %d\n", queries);
+            if (x != secret) {
+                fprintf(stderr, "answered %d, hidden %d", x, secret);
+                return 1;
+            }
+            return 0;
+        }
+        queries++;
+        printf("%s\n", x < secret ? "<" : x > secret ? ">" : "=");
+        fflush(stdout);
+    }
+    fprintf(stderr, "no answer");
+    return 1;
+}
+`
+
+func TestInteractor(t *testing.T) {
+	s := newTestExecutionService(t)
+	opts := ExecutionOptions{InteractorCode: guessInteractor, TimeLimitMs: 1000}
+
+	binarySearch := `#include <iostream>
+#include <string>
+int main() {
+    int lo = 1, hi = 1000;
+    while (true) {
+        int mid = (lo + hi) / 2;
+        std::cout << "? " << mid << std::endl;
+        std::string reply;
+        std::cin >> reply;
+        if (reply == "=") {
+            std::cout << "! " << mid << std::endl;
+            return 0;
+        }
+        if (reply == "<") lo = mid + 1; else hi = mid - 1;
+    }
+}
+`
+	wrongGuess := `#include <iostream>
+int main() { std::cout << "! 1" << std::endl; }
+`
+	silent := `int main() { volatile unsigned long i = 0; while (true) i++; }
+`
+
+	tests := []struct {
+		name    string
+		code    string
+		input   string
+		verdict models.Verdict
+		message string
+		error   string
+	}{
+		{"protocol", binarySearch, "700", models.VerdictAccepted, "", ""},
+		{"wrong answer", wrongGuess, "700", models.VerdictWrongAnswer, "answered 1, hidden 700", ""},
+		{"solution timeout", silent, "700", models.VerdictTimeLimitExceeded, "", ""},
+		{"interactor crash", binarySearch, "0", "", "", "interactor failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCases := []models.TestCase{{Input: tt.input}}
+			response, err := s.Execute(context.Background(), tt.code, testCases, "cpp", opts)
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Fatalf("got error %v, want %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := response.Results[0]
+			if result.Verdict != tt.verdict {
+				t.Fatalf("got %s (%s), want %s", result.Verdict, result.Error, tt.verdict)
+			}
+			if tt.message != "" && result.CheckerMessage != tt.message {
+				t.Errorf("interactor message %q, want %q", result.CheckerMessage, tt.message)
+			}
+			if tt.verdict == models.VerdictAccepted && result.QueryCount == 0 {
+				t.Error("query count not reported")
+			}
+		})
+	}
+}
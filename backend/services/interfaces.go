@@ -28,6 +28,12 @@ type StatsService interface {
 	BuildPersonalizationContext(ctx context.Context, userID uuid.UUID, focusMode string, focusTopic string, focusTopics []string) (string, error)
 }
 
+// ProblemVerifier checks generated problems with their reference solutions
+type ProblemVerifier interface {
+	GenerateVerifiedProblem(ctx context.Context, generate func() (*models.ProblemGenerationResponse, error), onRetry func(attempt int, reason string)) (*models.ProblemGenerationResponse, ProblemVerification, error)
+	VerifyProblem(ctx context.Context, problem *models.ProblemGenerationResponse) ProblemVerification
+}
+
 type SessionService interface {
 	CreateSession(ctx context.Context, userID uuid.UUID, problemCount int, focusMode, focusTopic string, focusTopics []string) (*SessionData, *models.ProblemGenerationResponse, error)
 	GetSession(ctx context.Context, sessionID uuid.UUID) (*SessionData, error)
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+)
+
+const (
+	// JUDGE0_POLL_INTERVAL is how often a pending submission is checked
+	JUDGE0_POLL_INTERVAL = 200 * time.Millisecond
+
+	// JUDGE0_REQUEST_TIMEOUT bounds each HTTP request to the judge server
+	JUDGE0_REQUEST_TIMEOUT = 30 * time.Second
+)
+
+// Judge0 submission status ids
+const (
+	judge0StatusProcessing        = 2
+	judge0StatusAccepted          = 3
+	judge0StatusTimeLimitExceeded = 5
+	judge0StatusCompilationError  = 6
+	judge0StatusSIGSEGV           = 7
+	judge0StatusSIGXFSZ           = 8
+	judge0StatusSIGFPE            = 9
+	judge0StatusSIGABRT           = 10
+	judge0StatusNZEC              = 11
+	judge0StatusOther             = 12
+)
+
+// judge0StatusSignals are the signals of the runtime error statuses, for
+// servers that do not report exit_signal
+var judge0StatusSignals = map[int]syscall.Signal{
+	judge0StatusSIGSEGV: syscall.SIGSEGV,
+	judge0StatusSIGXFSZ: syscall.SIGXFSZ,
+	judge0StatusSIGFPE:  syscall.SIGFPE,
+	judge0StatusSIGABRT: syscall.SIGABRT,
+}
+
+// judge0ResultFields are the submission fields read back from the server
+const judge0ResultFields = "token,stdout,stderr,compile_output,message,status,time,wall_time,memory,exit_code,exit_signal"
+
+// Judge0Executor runs user programs on a server speaking the Judge0 submissions
+// API, e.g. a self-hosted Judge0. Every run is a submission of the source code
+// that the server compiles and runs in its own sandbox; outputs are compared
+// here like those of local runs. The server's compilers are used whatever
+// toolchain was selected.
+type Judge0Executor struct {
+	baseURL     string
+	authToken   string
+	client      *http.Client
+	languageIDs map[string]int // Judge0 language id by language id
+}
+
+// judge0Submission is the request body of a submission. Text fields are base64 encoded.
+type judge0Submission struct {
+	SourceCode    string  `json:"source_code"`
+	LanguageID    int     `json:"language_id"`
+	Stdin         string  `json:"stdin"`
+	CPUTimeLimit  float64 `json:"cpu_time_limit"`  // Seconds
+	WallTimeLimit float64 `json:"wall_time_limit"` // Seconds
+	MemoryLimit   int     `json:"memory_limit"`    // KB
+}
+
+// judge0Result is a submission as returned by the server. Text fields are base64
+// encoded; fields the server has not filled in yet are null.
+type judge0Result struct {
+	Token         string `json:"token"`
+	Stdout        string `json:"stdout"`
+	Stderr        string `json:"stderr"`
+	CompileOutput string `json:"compile_output"`
+	Message       string `json:"message"`
+	Status        struct {
+		ID          int    `json:"id"`
+		Description string `json:"description"`
+	} `json:"status"`
+	Time       string `json:"time"`      // CPU seconds
+	WallTime   string `json:"wall_time"` // Seconds
+	Memory     int64  `json:"memory"`    // Peak KB
+	ExitCode   int    `json:"exit_code"`
+	ExitSignal int    `json:"exit_signal"`
+}
+
+// NewJudge0Executor creates an executor for the Judge0 server at baseURL.
+// Languages without a judge0_id cannot be run on it.
+func NewJudge0Executor(baseURL string, authToken string, languages []config.LanguageConfig) *Judge0Executor {
+	languageIDs := make(map[string]int)
+	for _, lang := range languages {
+		if lang.Judge0ID > 0 {
+			languageIDs[lang.ID] = lang.Judge0ID
+		}
+	}
+	return &Judge0Executor{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		authToken:   authToken,
+		client:      &http.Client{Timeout: JUDGE0_REQUEST_TIMEOUT},
+		languageIDs: languageIDs,
+	}
+}
+
+// Build only prepares the submission. Judge0 has no separate compile step, so
+// code that does not compile is reported as a CompilationError by the runs of
+// the first test cases instead.
+func (j *Judge0Executor) Build(ctx context.Context, opts ExecutionOptions, lang Language, toolchain Toolchain, code string, workDir string) (Program, error) {
+	if opts.Debug {
+		return Program{}, fmt.Errorf("debug mode is not supported by the Judge0 executor")
+	}
+	if opts.InteractorCode != "" {
+		return Program{}, fmt.Errorf("interactive problems are not supported by the Judge0 executor")
+	}
+	languageID, ok := j.languageIDs[lang.ID()]
+	if !ok {
+		return Program{}, fmt.Errorf("%s is not available on this server: no judge0_id configured", lang.Name())
+	}
+
+	timeLimit := languageTimeLimit(lang, opts.timeLimit())
+	return Program{
+		executor:         j,
+		limits:           SandboxLimits{WallTime: timeLimit, CPUTime: timeLimit},
+		memoryLimitMB:    opts.memoryLimitMB(),
+		sourceFile:       lang.SourceFile(code),
+		code:             code,
+		remoteLanguageID: languageID,
+	}, nil
+}
+
+// Available reports whether the language has a Judge0 language id
+func (j *Judge0Executor) Available(lang Language) error {
+	if _, ok := j.languageIDs[lang.ID()]; !ok {
+		return fmt.Errorf("no judge0_id configured")
+	}
+	return nil
+}
+
+// Run submits the program with one input and waits for its result. Code that
+// fails to compile is reported as a CompilationError, failures of the server
+// as ErrJudgeUnavailable and a full server queue as ErrExecutionQueueFull.
+func (j *Judge0Executor) Run(ctx context.Context, prog Program, input string, outputLimit int64) (*ProgramRun, error) {
+	token, err := j.submit(ctx, judge0Submission{
+		SourceCode:    base64.StdEncoding.EncodeToString([]byte(prog.code)),
+		LanguageID:    prog.remoteLanguageID,
+		Stdin:         base64.StdEncoding.EncodeToString([]byte(input)),
+		CPUTimeLimit:  prog.limits.CPUTime.Seconds(),
+		WallTimeLimit: prog.limits.WallTime.Seconds(),
+		MemoryLimit:   prog.memoryLimitMB * 1024,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := j.wait(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return judge0Run(result, outputLimit)
+}
+
+// submit creates a submission and returns its token
+func (j *Judge0Executor) submit(ctx context.Context, submission judge0Submission) (string, error) {
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode submission: %w", err)
+	}
+
+	var created judge0Result
+	if err := j.do(ctx, http.MethodPost, "/submissions?base64_encoded=true&wait=false", body, &created); err != nil {
+		return "", err
+	}
+	if created.Token == "" {
+		return "", fmt.Errorf("%w: no submission token in response", ErrJudgeUnavailable)
+	}
+	return created.Token, nil
+}
+
+// wait polls a submission until it is finished
+func (j *Judge0Executor) wait(ctx context.Context, token string) (*judge0Result, error) {
+	path := "/submissions/" + token + "?base64_encoded=true&fields=" + judge0ResultFields
+	for {
+		var result judge0Result
+		if err := j.do(ctx, http.MethodGet, path, nil, &result); err != nil {
+			return nil, err
+		}
+		if result.Status.ID > judge0StatusProcessing {
+			return &result, nil
+		}
+
+		select {
+		case <-time.After(JUDGE0_POLL_INTERVAL):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// do sends a request to the server and decodes the JSON response into out
+func (j *Judge0Executor) do(ctx context.Context, method string, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, j.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create judge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if j.authToken != "" {
+		req.Header.Set("X-Auth-Token", j.authToken)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrJudgeUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	// Judge0 answers 503 when its queue is full
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return ErrExecutionQueueFull
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %s %s returned %d: %s", ErrJudgeUnavailable, method, strings.SplitN(path, "?", 2)[0], resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w: invalid response: %v", ErrJudgeUnavailable, err)
+	}
+	return nil
+}
+
+// judge0Run converts a finished submission to the outcome of a local run.
+// Output beyond outputLimit is cut off and reported as exceeding it.
+func judge0Run(result *judge0Result, outputLimit int64) (*ProgramRun, error) {
+	stdout, err := decodeJudge0Text(result.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := decodeJudge0Text(result.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &ProgramRun{Stdout: &ProgramOutput{}, Stderr: stderr}
+	run.CPUTime = parseJudge0Seconds(result.Time)
+	run.WallTime = parseJudge0Seconds(result.WallTime)
+	run.MaxRSSKB = result.Memory
+
+	switch result.Status.ID {
+	case judge0StatusAccepted:
+	case judge0StatusTimeLimitExceeded:
+		run.TimedOut = true
+	case judge0StatusCompilationError:
+		compileOutput, err := decodeJudge0Text(result.CompileOutput)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &CompilationError{Output: compileOutput}
+	case judge0StatusSIGSEGV, judge0StatusSIGXFSZ, judge0StatusSIGFPE, judge0StatusSIGABRT, judge0StatusNZEC, judge0StatusOther:
+		run.ExitCode = result.ExitCode
+		if result.ExitSignal > 0 {
+			run.Signal = syscall.Signal(result.ExitSignal)
+		} else {
+			run.Signal = judge0StatusSignals[result.Status.ID]
+		}
+		// Like a process killed by a signal locally
+		if run.Signal != 0 || run.ExitCode == 0 {
+			run.ExitCode = -1
+		}
+		// Judge0 limits output through the file size limit
+		run.OutputExceeded = result.Status.ID == judge0StatusSIGXFSZ
+	default:
+		message, _ := decodeJudge0Text(result.Message)
+		return nil, fmt.Errorf("%w: %s %s", ErrJudgeUnavailable, result.Status.Description, message)
+	}
+
+	if outputLimit > 0 && int64(len(stdout)) > outputLimit {
+		stdout = stdout[:outputLimit]
+		run.OutputExceeded = true
+	}
+	io.WriteString(run.Stdout, stdout)
+	return run, nil
+}
+
+// decodeJudge0Text decodes a base64 text field. Judge0 wraps the encoded text
+// in lines of 60 characters.
+func decodeJudge0Text(text string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(text, "\n", ""))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid base64 in response: %v", ErrJudgeUnavailable, err)
+	}
+	return string(decoded), nil
+}
+
+// parseJudge0Seconds parses a duration in seconds such as "0.012"
+func parseJudge0Seconds(seconds string) time.Duration {
+	value, err := strconv.ParseFloat(seconds, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(value * float64(time.Second))
+}
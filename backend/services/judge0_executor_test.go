@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// fakeJudge0 stands in for a Judge0 server. The source code selects the outcome
+// of a submission, which is reported as processing on the first poll.
+func fakeJudge0(t *testing.T, authToken string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	submissions := make(map[string]judge0Submission)
+	polled := make(map[string]bool)
+	encode := func(text string) string { return base64.StdEncoding.EncodeToString([]byte(text)) }
+	decode := func(text string) string {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			t.Errorf("invalid base64 %q: %v", text, err)
+		}
+		return string(decoded)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != authToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodPost && r.URL.Path == "/submissions" {
+			var submission judge0Submission
+			if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if decode(submission.SourceCode) == "queue full" {
+				http.Error(w, "queue is full", http.StatusServiceUnavailable)
+				return
+			}
+			token := "token-" + string(rune('a'+len(submissions)))
+			submissions[token] = submission
+			json.NewEncoder(w).Encode(map[string]string{"token": token})
+			return
+		}
+
+		token := strings.TrimPrefix(r.URL.Path, "/submissions/")
+		submission, ok := submissions[token]
+		if r.Method != http.MethodGet || !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		result := map[string]any{"status": map[string]any{"id": 2}, "time": "0.010", "wall_time": "0.020", "memory": 3000}
+		if polled[token] {
+			stdin := decode(submission.Stdin)
+			switch decode(submission.SourceCode) {
+			case "echo":
+				result["status"] = map[string]any{"id": 3, "description": "Accepted"}
+				result["stdout"] = encode(stdin)
+			case "tle":
+				result["status"] = map[string]any{"id": 5, "description": "Time Limit Exceeded"}
+			case "ce":
+				result["status"] = map[string]any{"id": 6, "description": "Compilation Error"}
+				result["compile_output"] = encode("main.py:1: invalid syntax")
+			case "segv":
+				result["status"] = map[string]any{"id": 7, "description": "Runtime Error (SIGSEGV)"}
+			case "exit":
+				result["status"] = map[string]any{"id": 11, "description": "Runtime Error (NZEC)"}
+				result["exit_code"] = 3
+				result["stderr"] = encode("boom")
+			case "memory":
+				result["status"] = map[string]any{"id": 3, "description": "Accepted"}
+				result["memory"] = submission.MemoryLimit + 1
+			case "big":
+				result["status"] = map[string]any{"id": 3, "description": "Accepted"}
+				result["stdout"] = encode(strings.Repeat("x", 2<<20))
+			case "broken":
+				result["status"] = map[string]any{"id": 13, "description": "Internal Error"}
+			}
+		}
+		polled[token] = true
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJudge0Executor(t *testing.T) {
+	languages := []config.LanguageConfig{{ID: "python", SourceFile: "main.py", Run: []string{"python3", "main.py"}, Judge0ID: 71}}
+	registry, err := NewLanguageRegistry(languages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lang, _ := registry.Get("python")
+
+	server := fakeJudge0(t, "secret")
+	executor := NewJudge0Executor(server.URL+"/", "secret", languages)
+	opts := ExecutionOptions{TimeLimitMs: 1000, MemoryLimitMB: 64, OutputLimitMB: 1}
+	testCase := models.TestCase{Input: "1 2", ExpectedOutput: "1 2"}
+
+	tests := []struct {
+		code    string
+		verdict models.Verdict
+		error   string
+	}{
+		{"echo", models.VerdictAccepted, ""},
+		{"tle", models.VerdictTimeLimitExceeded, "Execution timeout (1s limit exceeded)"},
+		{"segv", models.VerdictRuntimeError, "Runtime error (segmentation fault): "},
+		{"exit", models.VerdictRuntimeError, "Runtime error (exit code 3): boom"},
+		{"memory", models.VerdictMemoryLimitExceeded, "Memory limit exceeded (64 MB limit)"},
+		{"big", models.VerdictOutputLimitExceeded, "Output limit exceeded (1 MB limit)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			prog, err := executor.Build(context.Background(), opts, lang, nil, tt.code, "")
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			result, output, err := (&ExecutionService{}).runTestCase(context.Background(), opts, prog, testCase, 1)
+			defer output.Close()
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if result.Verdict != tt.verdict || result.Error != tt.error {
+				t.Errorf("got %s %q, want %s %q", result.Verdict, result.Error, tt.verdict, tt.error)
+			}
+			if result.TimeMs != 20 || result.CPUTimeMs != 10 {
+				t.Errorf("got %dms wall and %dms CPU time, want 20ms and 10ms", result.TimeMs, result.CPUTimeMs)
+			}
+		})
+	}
+
+	// Build submits nothing; the outcome of the code shows once it runs
+	run := func(executor *Judge0Executor, code string) error {
+		prog, err := executor.Build(context.Background(), opts, lang, nil, code, "")
+		if err != nil {
+			t.Fatalf("build %q: %v", code, err)
+		}
+		_, _, err = (&ExecutionService{}).runTestCase(context.Background(), opts, prog, testCase, 1)
+		return err
+	}
+	var compileErr *CompilationError
+	if err := run(executor, "ce"); !errors.As(err, &compileErr) || compileErr.Output != "main.py:1: invalid syntax" {
+		t.Errorf("compilation error: got %v", err)
+	}
+	if err := run(executor, "broken"); !errors.Is(err, ErrJudgeUnavailable) {
+		t.Errorf("internal error: got %v, want ErrJudgeUnavailable", err)
+	}
+	if err := run(executor, "queue full"); !errors.Is(err, ErrExecutionQueueFull) {
+		t.Errorf("full queue: got %v, want ErrExecutionQueueFull", err)
+	}
+	if err := run(NewJudge0Executor(server.URL, "wrong", languages), "echo"); !errors.Is(err, ErrJudgeUnavailable) {
+		t.Errorf("rejected token: got %v, want ErrJudgeUnavailable", err)
+	}
+	if _, err := executor.Build(context.Background(), ExecutionOptions{Debug: true}, lang, nil, "echo", ""); err == nil {
+		t.Error("debug mode accepted")
+	}
+}
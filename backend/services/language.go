@@ -0,0 +1,527 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+)
+
+// Language describes how to build and run programs written in one language
+type Language interface {
+	ID() string
+	Name() string
+	// SourceFile returns the file name the code must be saved as
+	SourceFile(code string) string
+	// Options returns the toolchain settings users can choose, with the values
+	// found at startup
+	Options() []ToolchainOption
+	// ResolveToolchain validates a user's toolchain selection and fills in defaults
+	ResolveToolchain(selected map[string]string) (Toolchain, error)
+	// CompileCommand returns the compiler invocation, or nil for interpreted languages
+	CompileCommand(code string, toolchain Toolchain) []string
+	CompileTimeout() time.Duration
+	// RunCommand returns the command that runs the program under the given memory limit
+	RunCommand(code string, toolchain Toolchain, memoryLimitMB int) []string
+	// TimeMultiplier scales the time limit for slower runtimes
+	TimeMultiplier() float64
+	// ManagedMemory reports whether the runtime enforces its own heap limit
+	// and must not get an address space cap derived from the memory limit
+	ManagedMemory() bool
+	// AddressSpaceMB returns the fixed address space cap of a managed runtime,
+	// or 0 if it gets none
+	AddressSpaceMB() int
+	// Env returns extra environment variables for the compiler and the program
+	// under the given memory limit
+	Env(toolchain Toolchain, memoryLimitMB int) []string
+	// DebugFlags returns the compiler flags of the sanitizer build, or nil if
+	// the language has none; DebugEnv returns its runtime environment
+	DebugFlags() []string
+	DebugEnv() []string
+	// DiagnosticsFormat names the compiler output format understood by ParseDiagnostics
+	DiagnosticsFormat() string
+	// Validate performs a basic structural check of the code
+	Validate(code string) error
+	// Version returns the toolchain version, or an empty string if it is not installed
+	Version() string
+	// RequiredSnippet returns the text every program must contain, e.g. "int main"
+	RequiredSnippet() string
+	// Available returns why the language cannot be built and run on this host,
+	// or nil if its default toolchain was found at startup
+	Available() error
+}
+
+// Toolchain maps each option of a language to the chosen value id
+type Toolchain map[string]string
+
+// ToolchainOption describes a selectable toolchain setting
+type ToolchainOption struct {
+	Name    string   `json:"name"`
+	Default string   `json:"default"`
+	Values  []string `json:"values"`
+}
+
+// configLanguage is a Language defined by an entry in config.json
+type configLanguage struct {
+	cfg          config.LanguageConfig
+	entryPattern *regexp.Regexp
+	// available holds the option values whose probe succeeded and defaults the
+	// default value id, by option name
+	available map[string][]config.LanguageOptionValue
+	defaults  map[string]string
+
+	versionOnce sync.Once
+	version     string
+
+	unavailable error
+}
+
+// LanguageRegistry holds the languages user code can be written in
+type LanguageRegistry struct {
+	languages map[string]Language
+}
+
+// NewLanguageRegistry builds the registry from language config entries
+func NewLanguageRegistry(configs []config.LanguageConfig) (*LanguageRegistry, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no languages configured")
+	}
+
+	r := &LanguageRegistry{
+		languages: make(map[string]Language),
+	}
+
+	for _, cfg := range configs {
+		if cfg.ID == "" || cfg.SourceFile == "" || len(cfg.Run) == 0 {
+			return nil, fmt.Errorf("language %q must set id, source_file and run", cfg.ID)
+		}
+		if _, exists := r.languages[cfg.ID]; exists {
+			return nil, fmt.Errorf("duplicate language %q", cfg.ID)
+		}
+
+		lang := &configLanguage{cfg: cfg}
+		if cfg.EntryPattern != "" {
+			pattern, err := regexp.Compile(cfg.EntryPattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entry_pattern for language %q: %w", cfg.ID, err)
+			}
+			lang.entryPattern = pattern
+		}
+		if lang.cfg.Name == "" {
+			lang.cfg.Name = cfg.ID
+		}
+		if lang.cfg.TimeMultiplier <= 0 {
+			lang.cfg.TimeMultiplier = 1
+		}
+		lang.discoverOptions()
+		lang.checkToolchain()
+		if lang.unavailable != nil {
+			log.Printf("%s is not available: %v", lang.cfg.Name, lang.unavailable)
+		} else {
+			log.Printf("%s is available: %s", lang.cfg.Name, lang.Version())
+		}
+
+		r.languages[cfg.ID] = lang
+	}
+
+	return r, nil
+}
+
+// Get returns the language with the given id
+func (r *LanguageRegistry) Get(id string) (Language, bool) {
+	lang, ok := r.languages[id]
+	return lang, ok
+}
+
+// List returns all languages sorted by id
+func (r *LanguageRegistry) List() []Language {
+	languages := make([]Language, 0, len(r.languages))
+	for _, lang := range r.languages {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		return languages[i].ID() < languages[j].ID()
+	})
+	return languages
+}
+
+func (l *configLanguage) ID() string {
+	return l.cfg.ID
+}
+
+func (l *configLanguage) Name() string {
+	return l.cfg.Name
+}
+
+func (l *configLanguage) SourceFile(code string) string {
+	return l.expand(l.cfg.SourceFile, code, nil, defaultMemoryLimitMB())
+}
+
+// discoverOptions probes every option value and keeps the ones that work on
+// this host. An unavailable default falls back to the first available value.
+func (l *configLanguage) discoverOptions() {
+	l.available = make(map[string][]config.LanguageOptionValue)
+	l.defaults = make(map[string]string)
+
+	for name, option := range l.cfg.Options {
+		var values []config.LanguageOptionValue
+		for _, value := range option.Values {
+			if value.Path != "" {
+				path, err := resolveOptionPath(value.Path)
+				if err != nil {
+					log.Printf("%s %s %s is not available: %v", l.cfg.Name, name, value.ID, err)
+					continue
+				}
+				value.Path = path
+				value.Probe = replaceArgs(value.Probe, "{path}", path)
+			}
+			if len(value.Probe) > 0 {
+				if err := exec.Command(value.Probe[0], value.Probe[1:]...).Run(); err != nil {
+					log.Printf("%s %s %s is not available: %v", l.cfg.Name, name, value.ID, err)
+					continue
+				}
+			}
+			values = append(values, value)
+		}
+		l.available[name] = values
+
+		if len(values) == 0 {
+			log.Printf("No %s %s is available", l.cfg.Name, name)
+			continue
+		}
+		l.defaults[name] = option.Default
+		if _, ok := findOptionValue(values, option.Default); !ok {
+			log.Printf("Default %s %s %s is not available, using %s", l.cfg.Name, name, option.Default, values[0].ID)
+			l.defaults[name] = values[0].ID
+		}
+	}
+}
+
+// resolveOptionPath returns the first existing directory of a PATH-style list of
+// candidates. Candidates may use environment variables, and are skipped when
+// one is unset, and globs, of which the last match in sorted order is used.
+func resolveOptionPath(candidates string) (string, error) {
+	for _, candidate := range filepath.SplitList(candidates) {
+		unset := false
+		candidate = os.Expand(candidate, func(name string) string {
+			value := os.Getenv(name)
+			if value == "" {
+				unset = true
+			}
+			return value
+		})
+		if unset {
+			continue
+		}
+
+		matches, err := filepath.Glob(candidate)
+		if err != nil {
+			continue
+		}
+		for i := len(matches) - 1; i >= 0; i-- {
+			if info, err := os.Stat(matches[i]); err == nil && info.IsDir() {
+				return matches[i], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no directory matches %s", candidates)
+}
+
+// replaceArgs replaces a placeholder in every argument of a command
+func replaceArgs(args []string, placeholder, value string) []string {
+	replaced := make([]string, len(args))
+	for i, arg := range args {
+		replaced[i] = strings.ReplaceAll(arg, placeholder, value)
+	}
+	return replaced
+}
+
+// checkToolchain looks up the compiler and runtime of the default toolchain, so
+// that a missing binary is reported up front instead of as a failed compile.
+// Programs built in the work directory, such as "./main", are not looked up.
+func (l *configLanguage) checkToolchain() {
+	for name := range l.cfg.Options {
+		if len(l.available[name]) == 0 {
+			l.unavailable = fmt.Errorf("no %s %s is available", l.cfg.Name, name)
+			return
+		}
+	}
+
+	toolchain, err := l.ResolveToolchain(nil)
+	if err != nil {
+		l.unavailable = err
+		return
+	}
+
+	var dirs []string
+	for _, value := range l.selectedValues(toolchain) {
+		if value.Path != "" {
+			dirs = append(dirs, value.Path)
+		}
+	}
+	dirs = append(dirs, filepath.SplitList(sandboxPath())...)
+
+	commands := [][]string{
+		l.CompileCommand("", toolchain),
+		l.RunCommand("", toolchain, defaultMemoryLimitMB()),
+	}
+	for _, command := range commands {
+		if len(command) == 0 || strings.HasPrefix(command[0], ".") {
+			continue
+		}
+		if !findExecutable(command[0], dirs) {
+			l.unavailable = fmt.Errorf("%s not found", command[0])
+			return
+		}
+	}
+}
+
+// findExecutable reports whether name is an executable file, looked up in dirs
+// unless it is a path
+func findExecutable(name string, dirs []string) bool {
+	if strings.Contains(name, "/") {
+		dirs = []string{""}
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *configLanguage) Options() []ToolchainOption {
+	options := make([]ToolchainOption, 0, len(l.cfg.Options))
+	for name := range l.cfg.Options {
+		values := make([]string, 0, len(l.available[name]))
+		for _, value := range l.available[name] {
+			values = append(values, value.ID)
+		}
+		options = append(options, ToolchainOption{
+			Name:    name,
+			Default: l.defaults[name],
+			Values:  values,
+		})
+	}
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].Name < options[j].Name
+	})
+	return options
+}
+
+func (l *configLanguage) ResolveToolchain(selected map[string]string) (Toolchain, error) {
+	for name := range selected {
+		if _, ok := l.cfg.Options[name]; !ok {
+			return nil, fmt.Errorf("%s has no %s option", l.cfg.Name, name)
+		}
+	}
+
+	toolchain := make(Toolchain, len(l.cfg.Options))
+	for name := range l.cfg.Options {
+		id := selected[name]
+		if id == "" {
+			id = l.defaults[name]
+		}
+		if _, ok := findOptionValue(l.available[name], id); !ok {
+			return nil, fmt.Errorf("%s %s %q is not available", l.cfg.Name, name, id)
+		}
+		toolchain[name] = id
+	}
+	return toolchain, nil
+}
+
+func (l *configLanguage) CompileCommand(code string, toolchain Toolchain) []string {
+	if len(l.cfg.Compile) == 0 {
+		return nil
+	}
+	return l.expandAll(l.cfg.Compile, code, toolchain, defaultMemoryLimitMB())
+}
+
+func (l *configLanguage) CompileTimeout() time.Duration {
+	if l.cfg.CompileTimeoutSeconds > 0 {
+		return time.Duration(l.cfg.CompileTimeoutSeconds) * time.Second
+	}
+	return COMPILE_TIMEOUT
+}
+
+func (l *configLanguage) RunCommand(code string, toolchain Toolchain, memoryLimitMB int) []string {
+	return l.expandAll(l.cfg.Run, code, toolchain, memoryLimitMB)
+}
+
+func (l *configLanguage) TimeMultiplier() float64 {
+	return l.cfg.TimeMultiplier
+}
+
+func (l *configLanguage) ManagedMemory() bool {
+	return l.cfg.ManagedMemory
+}
+
+func (l *configLanguage) AddressSpaceMB() int {
+	return l.cfg.AddressSpaceMB
+}
+
+func (l *configLanguage) Env(toolchain Toolchain, memoryLimitMB int) []string {
+	env := make([]string, 0, len(l.cfg.Env)+1)
+	memoryMB := strconv.Itoa(memoryLimitMB)
+	for key, value := range l.cfg.Env {
+		env = append(env, key+"="+strings.ReplaceAll(value, "{memory_mb}", memoryMB))
+	}
+	sort.Strings(env)
+
+	var paths []string
+	for _, value := range l.selectedValues(toolchain) {
+		if value.Path != "" {
+			paths = append(paths, value.Path)
+		}
+	}
+	if len(paths) > 0 {
+		// Overrides the PATH of the sandbox environment
+		env = append(env, "PATH="+strings.Join(append(paths, sandboxPath()), ":"))
+	}
+
+	return env
+}
+
+func (l *configLanguage) DebugFlags() []string {
+	if l.cfg.Debug == nil {
+		return nil
+	}
+	return l.cfg.Debug.CompileFlags
+}
+
+func (l *configLanguage) DebugEnv() []string {
+	if l.cfg.Debug == nil {
+		return nil
+	}
+	env := make([]string, 0, len(l.cfg.Debug.Env))
+	for key, value := range l.cfg.Debug.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func (l *configLanguage) DiagnosticsFormat() string {
+	return l.cfg.Diagnostics
+}
+
+func (l *configLanguage) Validate(code string) error {
+	if l.cfg.RequiredSnippet != "" && !strings.Contains(code, l.cfg.RequiredSnippet) {
+		return fmt.Errorf("%s code must contain %q", l.cfg.Name, l.cfg.RequiredSnippet)
+	}
+	return nil
+}
+
+// Version runs the configured version probe once and caches the first line of its output
+func (l *configLanguage) Version() string {
+	l.versionOnce.Do(func() {
+		if len(l.cfg.Version) == 0 {
+			return
+		}
+		output, err := exec.Command(l.cfg.Version[0], l.cfg.Version[1:]...).CombinedOutput()
+		if err != nil {
+			return
+		}
+		l.version = strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	})
+	return l.version
+}
+
+func (l *configLanguage) RequiredSnippet() string {
+	return l.cfg.RequiredSnippet
+}
+
+func (l *configLanguage) Available() error {
+	return l.unavailable
+}
+
+// entry returns the entry point name declared in the code, e.g. the public Java class
+func (l *configLanguage) entry(code string) string {
+	if l.entryPattern != nil {
+		if match := l.entryPattern.FindStringSubmatch(code); len(match) > 1 {
+			return match[1]
+		}
+	}
+	return l.cfg.DefaultEntry
+}
+
+// selectedValues returns the chosen value of each option, in option name order
+func (l *configLanguage) selectedValues(toolchain Toolchain) []config.LanguageOptionValue {
+	names := make([]string, 0, len(toolchain))
+	for name := range toolchain {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]config.LanguageOptionValue, 0, len(names))
+	for _, name := range names {
+		if value, ok := findOptionValue(l.available[name], toolchain[name]); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// expand substitutes the {source}, {entry}, {memory_mb} and option placeholders
+func (l *configLanguage) expand(template string, code string, toolchain Toolchain, memoryLimitMB int) string {
+	pairs := []string{
+		"{entry}", l.entry(code),
+		"{memory_mb}", strconv.Itoa(memoryLimitMB),
+	}
+	for name, id := range toolchain {
+		if value, ok := findOptionValue(l.available[name], id); ok {
+			pairs = append(pairs, "{"+name+"}", strings.Join(value.Args, " "))
+		}
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	if strings.Contains(template, "{source}") {
+		template = strings.ReplaceAll(template, "{source}", replacer.Replace(l.cfg.SourceFile))
+	}
+	return replacer.Replace(template)
+}
+
+// expandAll expands a command. An argument that is exactly an option
+// placeholder becomes the option's arguments, so it may expand to none or several.
+func (l *configLanguage) expandAll(templates []string, code string, toolchain Toolchain, memoryLimitMB int) []string {
+	expanded := make([]string, 0, len(templates))
+	for _, template := range templates {
+		if name, ok := l.optionPlaceholder(template); ok {
+			if value, ok := findOptionValue(l.available[name], toolchain[name]); ok {
+				expanded = append(expanded, value.Args...)
+			}
+			continue
+		}
+		expanded = append(expanded, l.expand(template, code, toolchain, memoryLimitMB))
+	}
+	return expanded
+}
+
+// optionPlaceholder returns the option name when template is exactly "{<option>}"
+func (l *configLanguage) optionPlaceholder(template string) (string, bool) {
+	if !strings.HasPrefix(template, "{") || !strings.HasSuffix(template, "}") {
+		return "", false
+	}
+	name := template[1 : len(template)-1]
+	_, ok := l.cfg.Options[name]
+	return name, ok
+}
+
+func findOptionValue(values []config.LanguageOptionValue, id string) (config.LanguageOptionValue, bool) {
+	for _, value := range values {
+		if value.ID == id {
+			return value, true
+		}
+	}
+	return config.LanguageOptionValue{}, false
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+)
+
+func TestLanguageAvailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		compile []string
+		run     []string
+		missing string // Binary reported as not found, empty if available
+	}{
+		{"interpreter found", nil, []string{"sh", "{source}"}, ""},
+		{"built program is not looked up", []string{"sh", "-c", "true"}, []string{"./main"}, ""},
+		{"absolute path", nil, []string{"/bin/sh", "{source}"}, ""},
+		{"missing compiler", []string{"no-such-compiler", "{source}"}, []string{"./main"}, "no-such-compiler"},
+		{"missing runtime", []string{"sh", "-c", "true"}, []string{"no-such-runtime", "main"}, "no-such-runtime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := NewLanguageRegistry([]config.LanguageConfig{{ID: "lang", SourceFile: "main.src", Compile: tt.compile, Run: tt.run}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			lang, _ := registry.Get("lang")
+			err = lang.Available()
+			if tt.missing == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.missing != "" && (err == nil || !strings.Contains(err.Error(), tt.missing)) {
+				t.Errorf("got %v, want %s not found", err, tt.missing)
+			}
+		})
+	}
+
+	// No value of a required option passes its probe
+	registry, err := NewLanguageRegistry([]config.LanguageConfig{{
+		ID: "lang", SourceFile: "main.src", Run: []string{"{version}", "{source}"},
+		Options: map[string]config.LanguageOption{"version": {Default: "1", Values: []config.LanguageOptionValue{
+			{ID: "1", Args: []string{"sh"}, Probe: []string{"false"}},
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lang, _ := registry.Get("lang"); lang.Available() == nil {
+		t.Error("language without a usable version reported as available")
+	}
+}
+
+func TestResolveOptionPath(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"java-17-openjdk-arm64/bin", "temurin-21-jdk/bin", "temurin-21.0.4-jdk/bin", "custom/bin"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("TEST_JAVA_HOME", filepath.Join(root, "custom"))
+	t.Setenv("TEST_UNSET_HOME", "")
+
+	tests := []struct {
+		name       string
+		candidates string
+		want       string // Directory under root, empty if none is found
+	}{
+		{"glob", root + "/*-17-*/bin", "java-17-openjdk-arm64/bin"},
+		{"last glob match", root + "/*-21*/bin", "temurin-21.0.4-jdk/bin"},
+		{"environment override", "$TEST_JAVA_HOME/bin:" + root + "/*-17-*/bin", "custom/bin"},
+		{"unset variable skipped", "$TEST_UNSET_HOME/bin:" + root + "/*-17-*/bin", "java-17-openjdk-arm64/bin"},
+		{"missing", root + "/*-11-*/bin", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveOptionPath(tt.candidates)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("resolved %s, want an error", got)
+				}
+				return
+			}
+			if want := filepath.Join(root, tt.want); err != nil || got != want {
+				t.Errorf("got %q, %v; want %q", got, err, want)
+			}
+		})
+	}
+}
@@ -21,6 +21,9 @@ import (
 // LLMProvider interface for problem generation
 type LLMProvider interface {
 	GenerateProblem(ctx context.Context, focusAreas []string, personalizationContext string, targetRating *int) (*models.ProblemGenerationResponse, error)
+	// GenerateProblemStream sends the raw model output in chunks. It includes the
+	// reference solution, generator, validator and checker sources, so the
+	// chunks must never be forwarded to clients.
 	GenerateProblemStream(ctx context.Context, focusAreas []string, streamChan chan string) error
 }
 
@@ -449,7 +452,23 @@ You must respond with ONLY valid JSON in the following exact format (no markdown
     { "input": "hidden input 3", "expected_output": "hidden output 3" },
     { "input": "hidden input 4", "expected_output": "hidden output 4" },
     { "input": "hidden input 5", "expected_output": "hidden output 5" }
-  ]
+  ],
+  "time_limit_ms": 2000,
+  "memory_limit_mb": 256,
+  "comparison_mode": "tokens",
+  "float_epsilon": 0,
+  "checker_code": "",
+  "problem_type": "standard",
+  "interactor_code": "",
+  "signature": null,
+  "generator_code": "#include <bits/stdc++.h>\nint main(int argc, char** argv) { ... }",
+  "generated_tests": [
+    { "seed": 1, "args": ["100000"] },
+    { "seed": 2, "args": ["100000"] },
+    { "seed": 3, "args": ["100000"] }
+  ],
+  "reference_code": "#include <bits/stdc++.h>\nint main() { ... }",
+  "validator_code": "#include <bits/stdc++.h>\nint main() { ... }"
 }
 
 RATING ASSIGNMENT (Codeforces-style, range 800-3000):
@@ -466,11 +485,72 @@ RATING ASSIGNMENT (Codeforces-style, range 800-3000):
 - If user has weak performance: assign rating below their level for practice
 - The rating should be RELATIVE to the user's demonstrated skill level
 
+TIME AND MEMORY LIMITS (per test case, for the C++ reference solution; slower languages get more time automatically):
+- Set "time_limit_ms" between 1000 and 5000: 2000 for most problems, more when the intended solution is heavy on the maximum constraints
+- Set "memory_limit_mb" between 64 and 512: 256 for most problems, less only when memory use is part of the challenge
+
+OUTPUT COMPARISON (choose "comparison_mode" based on the output format):
+- "tokens": output is compared token by token, ignoring whitespace and line breaks (use for most problems)
+- "float": output contains real numbers; set "float_epsilon" to the allowed absolute or relative error (e.g. 1e-6) and state it in the Output Format
+- "unordered": output lines may be printed in any order; state this in the Output Format
+- "case_insensitive": output words such as YES/NO may be printed in any letter case
+- "exact": output must match exactly (only when formatting itself is part of the problem)
+- Leave "float_epsilon" as 0 unless "comparison_mode" is "float"
+
+CUSTOM CHECKER (only when several different outputs are correct, e.g. "print any valid order" or "output any shortest path"):
+- Set "checker_code" to a complete C++17 program that is run as: checker <input_file> <expected_output_file> <contestant_output_file>
+- The expected output file contains your reference answer; the checker must validate the contestant output against the input, not just compare it
+- Exit with code 0 if the answer is correct and 1 if it is wrong; print a short reason to stderr
+- Do not use testlib.h or any non-standard header
+- Leave "checker_code" empty when the output is unique
+
+INTERACTIVE PROBLEMS (only when the focus area calls for it, e.g. binary search with queries, "guess the hidden value"):
+- Set "problem_type" to "interactive" and provide "interactor_code": a complete C++17 program run as: interactor <input_file>
+- The interactor reads the hidden data from <input_file>, talks to the solution through stdin/stdout and must flush after every line
+- It exits with code 0 if the solution answered correctly within the query limit and 1 otherwise; it prints "queries: N" on its own line to stderr and may print a short reason on other stderr lines
+- Each test case "input" is the hidden data for the interactor; "expected_output" is the correct final answer
+- Describe the interaction protocol and the query limit in the description, and remind the user to flush output
+- Otherwise set "problem_type" to "standard" and leave "interactor_code" empty
+
+FUNCTION PROBLEMS (LeetCode style, when the input is a few numbers, strings or arrays and the answer is one value):
+- Set "problem_type" to "function" and "signature" to the function the user implements instead of reading stdin:
+  { "name": "maxSubarraySum", "params": [{ "name": "nums", "type": "int[]" }, { "name": "k", "type": "int" }], "return_type": "long" }
+- Types are int, long, double, bool, string, int[], long[], double[], string[] and int[][]; the name and parameter names are identifiers
+- Each test case "input" has one line per parameter holding its value as JSON, e.g. "[1,-2,3]\n2"
+- Each "expected_output" is the return value: numbers and true/false as is, arrays space separated on one line, int[][] one row per line
+- double results are printed like printf("%%.12g"), so 3.0 is "3"; use "float" comparison for them
+- Replace ## Input Format and ## Output Format in the description with a ## Function section showing the signature
+- "reference_code" is only the C++17 function with this signature using std types (vector<int>& for int[], long long for long); the server adds main
+- "generator_code" prints the input in the same one JSON value per line format, and "validator_code" reads it
+- For other problems leave "signature" null
+
+REFERENCE SOLUTION (always required, problems without one cannot be verified):
+- Set "reference_code" to a complete, correct C++17 solution with the intended complexity reading stdin and writing stdout
+- For function problems it is the C++17 function alone, without main
+- It is run against every sample and hidden case to verify the expected outputs, and produces the expected outputs of generated tests
+- For interactive problems it is a solution that talks to the interactor and must flush after every line
+
+LARGE HIDDEN TESTS (standard problems only; they make solutions with the wrong time complexity fail):
+- Set "generator_code" to a complete C++17 program run as: generator <seed> [args...]
+- It prints one valid input to stdout, seeds std::mt19937_64 with <seed>, and uses the optional args for sizes
+- The first arg MUST be the main input size n: complexity analysis runs "generator <seed> <n>" for n from small up to the largest n of "generated_tests"
+- Every generated input MUST respect the ## Constraints section; use the maximum sizes to stress the intended complexity
+- Set "generated_tests" to 3-5 entries of { "seed": <integer>, "args": [<strings>] } whose first arg is n
+- Leave "generator_code" and "generated_tests" empty for interactive problems
+
+INPUT VALIDATOR (always required; it rejects test cases, including user-written ones, that break the constraints):
+- Set "validator_code" to a complete C++17 program that reads one test input from stdin
+- It checks every value against the ## Constraints section and the exact format of the ## Input Format section: line breaks, counts of values per line and nothing after the last line
+- Exit with code 0 if the input is valid; otherwise print a short reason naming the violated constraint to stderr (e.g. "n = 200001 exceeds 200000") and exit with code 1
+- Accept a missing newline at the end of the input
+- For interactive problems it validates the hidden data given to the interactor
+- Every sample, hidden and generated input MUST pass the validator
+
 - Must be solvable in C++, Python, Java, and JavaScript
 - Provide exactly 2 sample cases in the 'sample_cases' array.
 - ALSO INCLUDE THESE SAME 2 SAMPLE CASES IN THE 'description' FIELD using the format specified above (## Example 1, ## Example 2).
 - Provide exactly 5 hidden test cases in the 'hidden_cases' array.
-- Use proper input/output format that can be read from stdin and written to stdout
+- Use proper input/output format that can be read from stdin and written to stdout, or the function format of function problems
 - Make the problem challenging but solvable in 10-15 minutes
 - Include clear constraints in the description
 - **CRITICAL**: Append a '## Solution Hints' section at the very end of the 'description'. Checkpoints or algorithmic hints to help a stuck user, but DO NOT give the full code.`, personalizationSection, targetRatingSection, focusRequirements, focusStr)
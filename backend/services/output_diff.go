@@ -0,0 +1,327 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+const (
+	// DIFF_CONTEXT_LINES surround each changed region of an output diff
+	DIFF_CONTEXT_LINES = 2
+
+	// DIFF_MAX_LINES bounds how many lines of each output the line diff compares
+	// once their common start and end are skipped
+	DIFF_MAX_LINES = 1000
+
+	// DIFF_MAX_HUNKS and DIFF_MAX_HUNK_LINES bound the diff sent to clients
+	DIFF_MAX_HUNKS      = 10
+	DIFF_MAX_HUNK_LINES = 200
+
+	// DIFF_MAX_LINE_BYTES shortens long lines and tokens quoted in a diff
+	DIFF_MAX_LINE_BYTES = 200
+)
+
+// Kinds of diff lines
+const (
+	diffContext  = "context"
+	diffExpected = "expected"
+	diffActual   = "actual"
+)
+
+// diffOp is one line of an edit script. e and a are the positions in the
+// expected and actual lines before the op.
+type diffOp struct {
+	kind string
+	e, a int
+}
+
+// DiffOutput describes where the actual output departs from the expected one.
+// Lines and tokens are matched the way the comparison mode matches them; in
+// unordered mode the normalized lines are compared in sorted order.
+func DiffOutput(expected string, actual string, mode models.ComparisonMode, epsilon float64) *models.OutputDiff {
+	return diffOutput(expected, actual, false, mode, epsilon)
+}
+
+// diffOutput is DiffOutput for an actual output that may only be the start of
+// a longer one, in which case the diff is marked as truncated
+func diffOutput(expected string, actual string, actualCut bool, mode models.ComparisonMode, epsilon float64) *models.OutputDiff {
+	tokenEqual := func(e, a string) bool { return e == a }
+	switch mode {
+	case models.ComparisonCaseInsensitive:
+		tokenEqual = strings.EqualFold
+	case models.ComparisonFloat:
+		if epsilon <= 0 {
+			epsilon = DEFAULT_FLOAT_EPSILON
+		}
+		tokenEqual = func(e, a string) bool { return floatTokensEqual(e, a, epsilon) }
+	}
+	lineEqual := func(e, a string) bool { return e == a }
+	if mode != models.ComparisonExact && mode != "" {
+		lineEqual = func(e, a string) bool { return compareTokens(e, a, tokenEqual) }
+	}
+
+	expectedLines := splitOutputLines(expected)
+	actualLines := splitOutputLines(actual)
+	unordered := mode == models.ComparisonUnordered
+	if unordered {
+		expectedLines = sortedNormalizedLines(expectedLines)
+		actualLines = sortedNormalizedLines(actualLines)
+	}
+
+	diff := &models.OutputDiff{
+		ExpectedLines: len(expectedLines),
+		ActualLines:   len(actualLines),
+	}
+
+	line := 0
+	for line < len(expectedLines) && line < len(actualLines) && lineEqual(expectedLines[line], actualLines[line]) {
+		line++
+	}
+	if line < len(expectedLines) || line < len(actualLines) {
+		diff.FirstMismatchLine = line + 1
+		var expectedLine, actualLine string
+		if line < len(expectedLines) {
+			expectedLine = expectedLines[line]
+		}
+		if line < len(actualLines) {
+			actualLine = actualLines[line]
+		}
+		diff.FirstMismatchToken, diff.ExpectedToken, diff.ActualToken = firstMismatchToken(expectedLine, actualLine, tokenEqual)
+		if len(diff.ExpectedToken) > DIFF_MAX_LINE_BYTES || len(diff.ActualToken) > DIFF_MAX_LINE_BYTES {
+			diff.ExpectedToken = shortenDiffText(diff.ExpectedToken)
+			diff.ActualToken = shortenDiffText(diff.ActualToken)
+			diff.Truncated = true
+		}
+	}
+
+	ops, opsTruncated := diffLines(expectedLines, actualLines, lineEqual)
+	hunks, hunksTruncated := buildHunks(ops, expectedLines, actualLines)
+	diff.Hunks = hunks
+	diff.Truncated = diff.Truncated || opsTruncated || hunksTruncated || actualCut
+	diff.Summary = summarizeDiff(diff, unordered)
+	return diff
+}
+
+// splitOutputLines splits output into lines; empty output has none
+func splitOutputLines(output string) []string {
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// sortedNormalizedLines normalizes whitespace and drops empty lines, like
+// unordered comparison, and sorts the result
+func sortedNormalizedLines(lines []string) []string {
+	var normalized []string
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			normalized = append(normalized, strings.Join(fields, " "))
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// firstMismatchToken returns the 1-based position of the first differing token
+// of two lines and both tokens. The position is 0 when the lines only differ in whitespace.
+func firstMismatchToken(expectedLine string, actualLine string, equal func(e, a string) bool) (int, string, string) {
+	expectedTokens := strings.Fields(expectedLine)
+	actualTokens := strings.Fields(actualLine)
+	for i := 0; i < len(expectedTokens) || i < len(actualTokens); i++ {
+		var e, a string
+		if i < len(expectedTokens) {
+			e = expectedTokens[i]
+		}
+		if i < len(actualTokens) {
+			a = actualTokens[i]
+		}
+		if i >= len(expectedTokens) || i >= len(actualTokens) || !equal(e, a) {
+			return i + 1, e, a
+		}
+	}
+	return 0, "", ""
+}
+
+// diffLines returns the edit script turning the expected lines into the actual
+// ones, limited to the changed middle and the context around it. It reports
+// true when the middle was longer than DIFF_MAX_LINES and was cut short.
+func diffLines(expected []string, actual []string, equal func(e, a string) bool) ([]diffOp, bool) {
+	prefix := 0
+	for prefix < len(expected) && prefix < len(actual) && equal(expected[prefix], actual[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(expected)-prefix && suffix < len(actual)-prefix && equal(expected[len(expected)-1-suffix], actual[len(actual)-1-suffix]) {
+		suffix++
+	}
+
+	var ops []diffOp
+	for i := max(0, prefix-DIFF_CONTEXT_LINES); i < prefix; i++ {
+		ops = append(ops, diffOp{kind: diffContext, e: i, a: i})
+	}
+
+	middleExpected := expected[prefix : len(expected)-suffix]
+	middleActual := actual[prefix : len(actual)-suffix]
+	truncated := false
+	if len(middleExpected) > DIFF_MAX_LINES || len(middleActual) > DIFF_MAX_LINES {
+		middleExpected = middleExpected[:min(len(middleExpected), DIFF_MAX_LINES)]
+		middleActual = middleActual[:min(len(middleActual), DIFF_MAX_LINES)]
+		truncated = true
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of the
+	// remaining lines middleExpected[i:] and middleActual[j:]
+	n, m := len(middleExpected), len(middleActual)
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(middleExpected[i], middleActual[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && equal(middleExpected[i], middleActual[j]):
+			ops = append(ops, diffOp{kind: diffContext, e: prefix + i, a: prefix + j})
+			i++
+			j++
+		case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: diffExpected, e: prefix + i, a: prefix + j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: diffActual, e: prefix + i, a: prefix + j})
+			j++
+		}
+	}
+
+	// A cut-short middle is not followed by the common end
+	if !truncated {
+		for k := 0; k < min(suffix, DIFF_CONTEXT_LINES); k++ {
+			ops = append(ops, diffOp{kind: diffContext, e: len(expected) - suffix + k, a: len(actual) - suffix + k})
+		}
+	}
+
+	return ops, truncated
+}
+
+// buildHunks groups an edit script into hunks of changes with DIFF_CONTEXT_LINES
+// of context, merging changes that are close together. It reports true when
+// hunks or lines were left out to respect the size limits.
+func buildHunks(ops []diffOp, expected []string, actual []string) ([]models.DiffHunk, bool) {
+	hunks := []models.DiffHunk{}
+	totalLines := 0
+	truncated := false
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffContext {
+			i++
+			continue
+		}
+		if len(hunks) == DIFF_MAX_HUNKS || totalLines == DIFF_MAX_HUNK_LINES {
+			return hunks, true
+		}
+
+		start := max(0, i-DIFF_CONTEXT_LINES)
+		last := i
+		for j := i; j < len(ops) && j-last <= 2*DIFF_CONTEXT_LINES; j++ {
+			if ops[j].kind != diffContext {
+				last = j
+			}
+		}
+		stop := min(len(ops), last+DIFF_CONTEXT_LINES+1)
+		if stop-start > DIFF_MAX_HUNK_LINES-totalLines {
+			stop = start + DIFF_MAX_HUNK_LINES - totalLines
+			truncated = true
+			// No room left for the change itself
+			if stop <= i {
+				return hunks, true
+			}
+		}
+
+		hunk := models.DiffHunk{
+			ExpectedStart: ops[start].e + 1,
+			ActualStart:   ops[start].a + 1,
+			Lines:         make([]models.DiffLine, 0, stop-start),
+		}
+		for _, op := range ops[start:stop] {
+			text := ""
+			switch op.kind {
+			case diffContext:
+				text = actual[op.a]
+				hunk.ExpectedCount++
+				hunk.ActualCount++
+			case diffExpected:
+				text = expected[op.e]
+				hunk.ExpectedCount++
+			case diffActual:
+				text = actual[op.a]
+				hunk.ActualCount++
+			}
+			if len(text) > DIFF_MAX_LINE_BYTES {
+				text = shortenDiffText(text)
+				truncated = true
+			}
+			hunk.Lines = append(hunk.Lines, models.DiffLine{Kind: op.kind, Text: text})
+		}
+		hunks = append(hunks, hunk)
+		totalLines += stop - start
+		i = stop
+	}
+
+	return hunks, truncated
+}
+
+// summarizeDiff describes the first difference in one sentence
+func summarizeDiff(diff *models.OutputDiff, unordered bool) string {
+	line := diff.FirstMismatchLine
+	var summary string
+	switch {
+	case line == 0:
+		summary = "Output differs only in whitespace"
+	case diff.ActualLines == 0:
+		summary = fmt.Sprintf("No output, expected %d lines", diff.ExpectedLines)
+	case line > diff.ActualLines:
+		summary = fmt.Sprintf("Output ends after %d lines, expected %d", diff.ActualLines, diff.ExpectedLines)
+	case line > diff.ExpectedLines:
+		summary = fmt.Sprintf("Output has %d lines, expected %d; unexpected output from line %d", diff.ActualLines, diff.ExpectedLines, line)
+	case diff.FirstMismatchToken == 0:
+		summary = fmt.Sprintf("Line %d differs only in whitespace", line)
+	case diff.ActualToken == "":
+		summary = fmt.Sprintf("Line %d ends before token %d, expected %q", line, diff.FirstMismatchToken, diff.ExpectedToken)
+	case diff.ExpectedToken == "":
+		summary = fmt.Sprintf("Line %d has unexpected token %d %q", line, diff.FirstMismatchToken, diff.ActualToken)
+	default:
+		summary = fmt.Sprintf("Line %d, token %d: expected %q, got %q", line, diff.FirstMismatchToken, diff.ExpectedToken, diff.ActualToken)
+	}
+
+	if unordered {
+		summary += " (lines compared in sorted order)"
+	}
+	if len(diff.Hunks) > 1 {
+		summary += fmt.Sprintf("; %d changed regions", len(diff.Hunks))
+	}
+	if diff.Truncated {
+		summary += " (diff truncated)"
+	}
+	return summary
+}
+
+// shortenDiffText cuts text to DIFF_MAX_LINE_BYTES
+func shortenDiffText(text string) string {
+	if len(text) <= DIFF_MAX_LINE_BYTES {
+		return text
+	}
+	return strings.ToValidUTF8(text[:DIFF_MAX_LINE_BYTES], "") + "..."
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+func TestDiffOutputFirstMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     models.ComparisonMode
+		expected string
+		actual   string
+		line     int
+		token    int
+		want     [2]string // Expected and actual token
+		summary  string
+	}{
+		{"wrong token", models.ComparisonTokens, "1 2\n3 4 5", "1 2\n3 7 5", 2, 2, [2]string{"4", "7"},
+			`Line 2, token 2: expected "4", got "7"`},
+		{"missing token", models.ComparisonTokens, "1 2 3", "1 2", 1, 3, [2]string{"3", ""},
+			`Line 1 ends before token 3, expected "3"`},
+		{"extra token", models.ComparisonTokens, "1 2", "1 2 3", 1, 3, [2]string{"", "3"},
+			`Line 1 has unexpected token 3 "3"`},
+		{"missing lines", models.ComparisonTokens, "1\n2\n3", "1", 2, 1, [2]string{"2", ""},
+			"Output ends after 1 lines, expected 3"},
+		{"extra lines", models.ComparisonTokens, "1", "1\n2", 2, 1, [2]string{"", "2"},
+			"Output has 2 lines, expected 1; unexpected output from line 2"},
+		{"no output", models.ComparisonTokens, "1\n2", "", 1, 1, [2]string{"1", ""},
+			"No output, expected 2 lines"},
+		{"exact whitespace", models.ComparisonExact, "1 2\n3", "1  2\n3", 1, 0, [2]string{"", ""},
+			"Line 1 differs only in whitespace"},
+		{"float within epsilon is equal", models.ComparisonFloat, "0.5 1.0\n2.0", "0.5000001 1.0\n2.5", 2, 1, [2]string{"2.0", "2.5"},
+			`Line 2, token 1: expected "2.0", got "2.5"`},
+		{"case insensitive", models.ComparisonCaseInsensitive, "YES\nNO", "yes\nyes", 2, 1, [2]string{"NO", "yes"},
+			`Line 2, token 1: expected "NO", got "yes"`},
+		{"unordered compares sorted lines", models.ComparisonUnordered, "3\n1\n2", "2\n4\n1", 3, 1, [2]string{"3", "4"},
+			`Line 3, token 1: expected "3", got "4" (lines compared in sorted order)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffOutput(tt.expected, tt.actual, tt.mode, 1e-6)
+			if diff.FirstMismatchLine != tt.line || diff.FirstMismatchToken != tt.token {
+				t.Errorf("first mismatch at line %d token %d, want line %d token %d", diff.FirstMismatchLine, diff.FirstMismatchToken, tt.line, tt.token)
+			}
+			if got := [2]string{diff.ExpectedToken, diff.ActualToken}; got != tt.want {
+				t.Errorf("tokens %q, want %q", got, tt.want)
+			}
+			if diff.Summary != tt.summary {
+				t.Errorf("summary %q, want %q", diff.Summary, tt.summary)
+			}
+		})
+	}
+}
+
+func TestDiffOutputHunks(t *testing.T) {
+	lines := func(from, to int, changed map[int]string) string {
+		var out []string
+		for i := from; i <= to; i++ {
+			if text, ok := changed[i]; ok {
+				out = append(out, text)
+			} else {
+				out = append(out, fmt.Sprint(i))
+			}
+		}
+		return strings.Join(out, "\n")
+	}
+
+	// One line changed in the middle, one appended at the end
+	expected := lines(1, 20, nil)
+	actual := lines(1, 20, map[int]string{10: "x"}) + "\n21"
+	diff := DiffOutput(expected, actual, models.ComparisonTokens, 0)
+
+	want := []models.DiffHunk{
+		{
+			ExpectedStart: 8, ExpectedCount: 5, ActualStart: 8, ActualCount: 5,
+			Lines: []models.DiffLine{
+				{Kind: "context", Text: "8"},
+				{Kind: "context", Text: "9"},
+				{Kind: "expected", Text: "10"},
+				{Kind: "actual", Text: "x"},
+				{Kind: "context", Text: "11"},
+				{Kind: "context", Text: "12"},
+			},
+		},
+		{
+			ExpectedStart: 19, ExpectedCount: 2, ActualStart: 19, ActualCount: 3,
+			Lines: []models.DiffLine{
+				{Kind: "context", Text: "19"},
+				{Kind: "context", Text: "20"},
+				{Kind: "actual", Text: "21"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(diff.Hunks, want) {
+		t.Errorf("hunks\n%+v\nwant\n%+v", diff.Hunks, want)
+	}
+	if diff.Truncated {
+		t.Error("small diff marked as truncated")
+	}
+	if !strings.HasSuffix(diff.Summary, "; 2 changed regions") {
+		t.Errorf("summary %q does not count the regions", diff.Summary)
+	}
+
+	// Nearby changes share a hunk
+	diff = DiffOutput(lines(1, 20, nil), lines(1, 20, map[int]string{5: "a", 9: "b"}), models.ComparisonTokens, 0)
+	if len(diff.Hunks) != 1 || diff.Hunks[0].ExpectedStart != 3 || diff.Hunks[0].ExpectedCount != 9 {
+		t.Errorf("nearby changes: %+v", diff.Hunks)
+	}
+}
+
+func TestDiffOutputLimits(t *testing.T) {
+	// Every tenth line differs, which needs more hunks than are sent
+	var expected, actual []string
+	for i := 0; i < 5000; i++ {
+		expected = append(expected, fmt.Sprint(i))
+		if i%10 == 0 {
+			actual = append(actual, "x")
+		} else {
+			actual = append(actual, fmt.Sprint(i))
+		}
+	}
+	diff := DiffOutput(strings.Join(expected, "\n"), strings.Join(actual, "\n"), models.ComparisonTokens, 0)
+	if len(diff.Hunks) != DIFF_MAX_HUNKS || !diff.Truncated {
+		t.Errorf("got %d hunks, truncated %v; want %d truncated hunks", len(diff.Hunks), diff.Truncated, DIFF_MAX_HUNKS)
+	}
+	if !strings.HasSuffix(diff.Summary, "(diff truncated)") {
+		t.Errorf("summary %q does not mention truncation", diff.Summary)
+	}
+
+	// Long lines are shortened
+	long := strings.Repeat("a", 3*DIFF_MAX_LINE_BYTES)
+	diff = DiffOutput(long, long+"b", models.ComparisonExact, 0)
+	if !diff.Truncated || len(diff.ActualToken) > DIFF_MAX_LINE_BYTES+3 {
+		t.Errorf("long token kept at %d bytes, truncated %v", len(diff.ActualToken), diff.Truncated)
+	}
+	for _, hunk := range diff.Hunks {
+		for _, line := range hunk.Lines {
+			if len(line.Text) > DIFF_MAX_LINE_BYTES+3 {
+				t.Errorf("long line kept at %d bytes", len(line.Text))
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+const (
+	// GENERATION_VERIFY_ATTEMPTS is how many times a problem is generated before
+	// one that fails verification is kept as unverified
+	GENERATION_VERIFY_ATTEMPTS = 3
+
+	// VERIFICATION_USER_ID is the execution pool queue of verification runs
+	VERIFICATION_USER_ID = "problem-verification"
+
+	// VERIFICATION_OUTPUT_PREVIEW limits the outputs quoted in verification failures
+	VERIFICATION_OUTPUT_PREVIEW = 100
+)
+
+// ProblemVerification is the outcome of checking a generated problem with its reference solution
+type ProblemVerification struct {
+	Status string // models.ProblemStatusReady or models.ProblemStatusUnverified
+	Reason string // Why the problem is unverified
+
+	// regenerate is set when the problem itself is wrong, as opposed to when
+	// it could not be verified, so generating it again may help
+	regenerate bool
+}
+
+// GenerateVerifiedProblem calls generate until it returns a problem whose
+// reference solution agrees with its test cases. After GENERATION_VERIFY_ATTEMPTS,
+// or once ctx is done, the last problem is kept as unverified. Problems without
+// a reference solution, or whose reference solution could not be run, are not
+// generated again. onRetry, if set, is called before each new attempt with why
+// the previous problem was rejected.
+func (s *generationService) GenerateVerifiedProblem(ctx context.Context, generate func() (*models.ProblemGenerationResponse, error), onRetry func(attempt int, reason string)) (*models.ProblemGenerationResponse, ProblemVerification, error) {
+	for attempt := 1; ; attempt++ {
+		problem, err := generate()
+		if err != nil {
+			return nil, ProblemVerification{}, err
+		}
+
+		verification := s.VerifyProblem(ctx, problem)
+		if !verification.regenerate {
+			return problem, verification, nil
+		}
+
+		log.Printf("Problem '%s' failed verification (attempt %d/%d): %s", problem.Title, attempt, GENERATION_VERIFY_ATTEMPTS, verification.Reason)
+		if attempt == GENERATION_VERIFY_ATTEMPTS || ctx.Err() != nil {
+			return problem, verification, nil
+		}
+		if onRetry != nil {
+			onRetry(attempt+1, verification.Reason)
+		}
+	}
+}
+
+// VerifyProblem runs the reference solution of a generated problem against its
+// sample and hidden cases, judged like a submission
+func (s *generationService) VerifyProblem(ctx context.Context, problem *models.ProblemGenerationResponse) ProblemVerification {
+	if problem.ProblemType == models.ProblemTypeFunction {
+		if err := ValidateSignature(problem.Signature); err != nil {
+			return ProblemVerification{Status: models.ProblemStatusUnverified, Reason: err.Error(), regenerate: true}
+		}
+	}
+	if strings.TrimSpace(problem.ReferenceCode) == "" {
+		log.Printf("Problem '%s' has no reference solution, keeping it unverified", problem.Title)
+		return ProblemVerification{Status: models.ProblemStatusUnverified, Reason: "no reference solution"}
+	}
+
+	reason, err := s.checkReferenceSolution(ctx, problem)
+	if err != nil {
+		// Not the problem's fault, so it is not generated again
+		log.Printf("Could not verify problem '%s': %v", problem.Title, err)
+		return ProblemVerification{
+			Status: models.ProblemStatusUnverified,
+			Reason: fmt.Sprintf("verification failed: %v", err),
+		}
+	}
+	if reason != "" {
+		return ProblemVerification{Status: models.ProblemStatusUnverified, Reason: reason, regenerate: true}
+	}
+
+	log.Printf("Problem '%s' verified by its reference solution", problem.Title)
+	return ProblemVerification{Status: models.ProblemStatusReady}
+}
+
+// checkReferenceSolution returns why the reference solution fails the problem's
+// cases, or "" when it passes every case. The error is only set when the
+// reference solution could not be run.
+func (s *generationService) checkReferenceSolution(ctx context.Context, problem *models.ProblemGenerationResponse) (string, error) {
+	var testCases []models.TestCase
+	testCases = append(testCases, problem.SampleCases...)
+	testCases = append(testCases, problem.HiddenCases...)
+
+	opts := ExecutionOptions{
+		UserID:         VERIFICATION_USER_ID,
+		ComparisonMode: problem.ComparisonMode,
+		FloatEpsilon:   problem.FloatEpsilon,
+		CheckerCode:    problem.CheckerCode,
+		ValidatorCode:  problem.ValidatorCode,
+		TimeLimitMs:    problem.TimeLimitMs,
+		MemoryLimitMB:  problem.MemoryLimitMB,
+		OutputLimitMB:  problem.OutputLimitMB,
+	}
+	if problem.ProblemType == models.ProblemTypeInteractive {
+		opts.InteractorCode = problem.InteractorCode
+	}
+
+	code := problem.ReferenceCode
+	if problem.ProblemType == models.ProblemTypeFunction {
+		// The reference solution of a function problem is the C++ function alone
+		var err error
+		if code, err = BuildHarness("cpp", problem.Signature, code); err != nil {
+			return "", err
+		}
+	}
+
+	response, err := s.executionService.Execute(ctx, code, testCases, "cpp", opts)
+	var invalidErr *InvalidInputError
+	if errors.As(err, &invalidErr) {
+		return fmt.Sprintf("%s violates the constraints: %s", caseName(problem, invalidErr.CaseNumber), firstLine(invalidErr.Message)), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if response.Verdict == models.VerdictCompilationError {
+		return fmt.Sprintf("reference solution does not compile: %s", firstLine(response.CompileOutput)), nil
+	}
+
+	for _, result := range response.Results {
+		if result.Passed {
+			continue
+		}
+
+		if result.Verdict == models.VerdictWrongAnswer {
+			return fmt.Sprintf("%s: expected %q but the reference solution printed %q", caseName(problem, result.CaseNumber),
+				truncateOutput(result.ExpectedOutput), truncateOutput(result.ActualOutput)), nil
+		}
+		return fmt.Sprintf("%s: reference solution got %s: %s", caseName(problem, result.CaseNumber), result.Verdict, firstLine(result.Error)), nil
+	}
+
+	return "", nil
+}
+
+// caseName names a case of a problem by its number among the sample and hidden cases
+func caseName(problem *models.ProblemGenerationResponse, caseNumber int) string {
+	if caseNumber > len(problem.SampleCases) {
+		return fmt.Sprintf("hidden case %d", caseNumber-len(problem.SampleCases))
+	}
+	return fmt.Sprintf("sample case %d", caseNumber)
+}
+
+// firstLine returns the first non-empty line of text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func truncateOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > VERIFICATION_OUTPUT_PREVIEW {
+		return strings.ToValidUTF8(output[:VERIFICATION_OUTPUT_PREVIEW], "") + "..."
+	}
+	return output
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// badProblems returns a generator whose first n problems are function problems
+// without a signature, which are always generated again, followed by a problem
+// without a reference solution, which is kept
+func badProblems(n int) (func() (*models.ProblemGenerationResponse, error), *int) {
+	calls := 0
+	return func() (*models.ProblemGenerationResponse, error) {
+		calls++
+		if calls <= n {
+			return &models.ProblemGenerationResponse{Title: fmt.Sprintf("bad %d", calls), ProblemType: models.ProblemTypeFunction}, nil
+		}
+		return &models.ProblemGenerationResponse{Title: "good"}, nil
+	}, &calls
+}
+
+func TestGenerateVerifiedProblem(t *testing.T) {
+	s := &generationService{}
+	const reason = "function problem has no signature"
+
+	tests := []struct {
+		name    string
+		bad     int
+		cancel  bool
+		calls   int
+		retries []int
+		title   string
+		reason  string
+	}{
+		{"first problem kept", 0, false, 1, nil, "good", "no reference solution"},
+		{"regenerated", 1, false, 2, []int{2}, "good", "no reference solution"},
+		{"attempts exhausted", GENERATION_VERIFY_ATTEMPTS, false, GENERATION_VERIFY_ATTEMPTS, []int{2, 3}, "bad 3", reason},
+		{"context done", GENERATION_VERIFY_ATTEMPTS, true, 1, nil, "bad 1", reason},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			generate, calls := badProblems(tt.bad)
+			var retries []int
+			problem, verification, err := s.GenerateVerifiedProblem(ctx, generate, func(attempt int, got string) {
+				retries = append(retries, attempt)
+				if got != reason {
+					t.Errorf("retry %d reported %q, want %q", attempt, got, reason)
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *calls != tt.calls || !reflect.DeepEqual(retries, tt.retries) {
+				t.Errorf("generated %d times with retries %v, want %d with %v", *calls, retries, tt.calls, tt.retries)
+			}
+			if problem.Title != tt.title || verification.Reason != tt.reason {
+				t.Errorf("kept %q (%s), want %q (%s)", problem.Title, verification.Reason, tt.title, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ProgramOutput is the stdout of one program run. Its first
+// RESULT_OUTPUT_PREVIEW_BYTES stay in memory; longer output is also written to
+// a temporary file as it arrives, so that a run holds no more than the preview
+// in memory whatever the output limit. Close removes the file.
+type ProgramOutput struct {
+	preview []byte
+	file    *os.File
+	size    int64
+	// err is the first failure to store the output, reported when it is read
+	err error
+}
+
+// Write implements io.Writer. Failures to store the output are reported by
+// Reader rather than to the program writing it.
+func (o *ProgramOutput) Write(p []byte) (int, error) {
+	o.size += int64(len(p))
+	if o.err != nil {
+		return len(p), nil
+	}
+	if o.file == nil && len(o.preview)+len(p) <= RESULT_OUTPUT_PREVIEW_BYTES {
+		o.preview = append(o.preview, p...)
+		return len(p), nil
+	}
+
+	if o.file == nil {
+		file, err := os.CreateTemp("", "program-output-")
+		if err != nil {
+			o.err = fmt.Errorf("failed to store program output: %w", err)
+			return len(p), nil
+		}
+		o.file = file
+		if _, err := file.Write(o.preview); err != nil {
+			o.err = fmt.Errorf("failed to store program output: %w", err)
+			return len(p), nil
+		}
+		room := RESULT_OUTPUT_PREVIEW_BYTES - len(o.preview)
+		o.preview = append(o.preview, p[:room]...)
+	}
+	if _, err := o.file.Write(p); err != nil {
+		o.err = fmt.Errorf("failed to store program output: %w", err)
+	}
+	return len(p), nil
+}
+
+// Size returns the number of bytes written
+func (o *ProgramOutput) Size() int64 {
+	return o.size
+}
+
+// Preview returns the first RESULT_OUTPUT_PREVIEW_BYTES of the output
+func (o *ProgramOutput) Preview() string {
+	return strings.ToValidUTF8(string(o.preview), "")
+}
+
+// Truncated reports whether Preview only holds the start of the output
+func (o *ProgramOutput) Truncated() bool {
+	return o.size > int64(len(o.preview))
+}
+
+// Reader returns a reader of the full output
+func (o *ProgramOutput) Reader() (io.Reader, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	if o.file == nil {
+		return bytes.NewReader(o.preview), nil
+	}
+	return io.NewSectionReader(o.file, 0, o.size), nil
+}
+
+// ReadAll returns the full output
+func (o *ProgramOutput) ReadAll() (string, error) {
+	r, err := o.Reader()
+	if err != nil {
+		return "", err
+	}
+	output, err := io.ReadAll(r)
+	return string(output), err
+}
+
+// Head returns up to limit bytes from the start of the output. Longer output
+// is cut after its last full line within the limit, and reported as cut.
+func (o *ProgramOutput) Head(limit int) (string, bool, error) {
+	r, err := o.Reader()
+	if err != nil {
+		return "", false, err
+	}
+	head, err := io.ReadAll(io.LimitReader(r, int64(limit)))
+	if err != nil {
+		return "", false, err
+	}
+	if o.size <= int64(limit) {
+		return string(head), false, nil
+	}
+	if end := bytes.LastIndexByte(head, '\n'); end >= 0 {
+		head = head[:end]
+	}
+	return strings.ToValidUTF8(string(head), ""), true, nil
+}
+
+// Close removes the file holding the output. It may be called on a nil output.
+func (o *ProgramOutput) Close() error {
+	if o == nil || o.file == nil {
+		return nil
+	}
+	o.file.Close()
+	return os.Remove(o.file.Name())
+}
+
+// headTailBuffer keeps the first and the last limit bytes written to it, e.g.
+// of stderr, where sanitizers and runtimes report at the end
+type headTailBuffer struct {
+	limit   int
+	head    []byte
+	tail    []byte
+	omitted int64
+}
+
+func (b *headTailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - len(b.head); room > 0 {
+		room = min(room, len(p))
+		b.head = append(b.head, p[:room]...)
+		p = p[room:]
+	}
+	b.tail = append(b.tail, p...)
+	if over := len(b.tail) - b.limit; over > 0 {
+		b.omitted += int64(over)
+		b.tail = append(b.tail[:0], b.tail[over:]...)
+	}
+	return n, nil
+}
+
+// String returns the head and tail, noting how much was left out between them
+func (b *headTailBuffer) String() string {
+	if b.omitted == 0 {
+		return string(b.head) + string(b.tail)
+	}
+	return fmt.Sprintf("%s\n... (%d bytes omitted) ...\n%s", b.head, b.omitted, b.tail)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProgramOutput(t *testing.T) {
+	// Written in pieces, like a program's output through a pipe
+	line := strings.Repeat("x", 999) + "\n"
+	full := strings.Repeat(line, 3*RESULT_OUTPUT_PREVIEW_BYTES/len(line))
+	output := &ProgramOutput{}
+	for i := 0; i < len(full); i += 4096 {
+		output.Write([]byte(full[i:min(i+4096, len(full))]))
+	}
+
+	if output.Size() != int64(len(full)) || !output.Truncated() || output.Preview() != full[:RESULT_OUTPUT_PREVIEW_BYTES] {
+		t.Errorf("size %d, truncated %v, preview of %d bytes", output.Size(), output.Truncated(), len(output.Preview()))
+	}
+	if got, err := output.ReadAll(); err != nil || got != full {
+		t.Errorf("ReadAll() returned %d bytes, error %v; want %d bytes", len(got), err, len(full))
+	}
+	head, cut, err := output.Head(2*len(line) + 10)
+	if err != nil || !cut || head != line+line[:len(line)-1] {
+		t.Errorf("Head() = %d bytes, cut %v, error %v; want two lines", len(head), cut, err)
+	}
+
+	path := output.file.Name()
+	if err := output.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("output file %s left behind: %v", path, err)
+	}
+
+	// Short output stays in memory
+	output = &ProgramOutput{}
+	output.Write([]byte("42\n"))
+	if output.file != nil || output.Truncated() || output.Preview() != "42\n" {
+		t.Errorf("short output: file %v, truncated %v, preview %q", output.file, output.Truncated(), output.Preview())
+	}
+	if head, cut, err := output.Head(100); err != nil || cut || head != "42\n" {
+		t.Errorf("short output: Head() = %q, cut %v, error %v", head, cut, err)
+	}
+}
+
+func TestHeadTailBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"short", []string{"ab", "c"}, "abc"},
+		{"at twice the limit", []string{"abcd", "efgh"}, "abcdefgh"},
+		{"long", []string{"abc", "defghij", "kl"}, "abcd\n... (4 bytes omitted) ...\nijkl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &headTailBuffer{limit: 4}
+			for _, write := range tt.writes {
+				buffer.Write([]byte(write))
+			}
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
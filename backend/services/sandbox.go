@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+)
+
+const (
+	SANDBOX_MAX_PROCESSES     = 64
+	SANDBOX_MAX_FILE_SIZE     = 64 << 20
+	SANDBOX_MAX_ADDRESS_SPACE = 1 << 30
+	SANDBOX_MAX_STACK         = 256 << 20
+	SANDBOX_TMPFS_SIZE        = 64 << 20
+)
+
+// sandboxNobodyID is the host uid/gid of sandboxed processes when the server runs as root
+const sandboxNobodyID = 65534
+
+// SandboxLimits describes the resource caps applied to a sandboxed process.
+// Zero values leave the corresponding limit unset.
+type SandboxLimits struct {
+	WallTime     time.Duration `json:"wall_time"`
+	CPUTime      time.Duration `json:"cpu_time"`
+	AddressSpace int64         `json:"address_space"`
+	FileSize     int64         `json:"file_size"`
+	Processes    int64         `json:"processes"`
+	Stack        int64         `json:"stack"`
+}
+
+// SandboxCommand describes a process to run inside the sandbox
+type SandboxCommand struct {
+	Dir    string
+	Path   string
+	Args   []string
+	Env    []string // Added to the minimal sandbox environment
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Limits SandboxLimits
+	// OutputLimit caps the bytes written to Stdout and to Stderr each. The
+	// process is killed once it writes more. Zero leaves output unbounded.
+	OutputLimit int64
+	// CloseAfterStart are closed once the process has started (or failed to),
+	// e.g. the parent's copies of pipe ends handed to the process
+	CloseAfterStart []io.Closer
+}
+
+// SandboxResult holds the outcome and resource usage of a sandboxed process
+type SandboxResult struct {
+	ExitCode       int
+	Signal         syscall.Signal
+	TimedOut       bool
+	OutputExceeded bool // Killed for writing more than the output limit
+	WallTime       time.Duration
+	CPUTime        time.Duration
+	MaxRSSKB       int64
+}
+
+// Sandbox runs untrusted programs in isolated working directories.
+// On Linux each process runs as nobody in its own user, PID, mount, network,
+// IPC and UTS namespaces, on a minimal read-only root filesystem, with rlimits
+// and a seccomp syscall allowlist.
+type Sandbox struct {
+	root          string
+	enabled       bool
+	readOnlyPaths []string
+	initPath      string // Copy of the server binary that sets up isolation
+	helperPath    string // Exec helper measuring the program alone, "" without one
+	uid           int
+	gid           int
+}
+
+// NewSandbox creates the sandbox root and checks that the host supports
+// isolation. It fails rather than running user code unisolated unless the
+// sandbox is explicitly disabled.
+func NewSandbox() (*Sandbox, error) {
+	cfg := config.Config.Sandbox
+
+	if err := os.MkdirAll(cfg.Root, 0711); err != nil {
+		return nil, fmt.Errorf("failed to create sandbox root: %w", err)
+	}
+
+	s := &Sandbox{
+		root:    cfg.Root,
+		enabled: cfg.Enabled,
+		uid:     os.Getuid(),
+		gid:     os.Getgid(),
+	}
+
+	// Programs never run as root. Isolation also requires it, so that they do
+	// not run as the server user that owns its secrets either.
+	if os.Getuid() == 0 {
+		s.uid = sandboxNobodyID
+		s.gid = sandboxNobodyID
+	}
+
+	if s.enabled {
+		if err := s.configure(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// CreateWorkDir creates a private working directory for one execution
+func (s *Sandbox) CreateWorkDir() (string, error) {
+	dir, err := os.MkdirTemp(s.root, "exec-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create work directory: %w", err)
+	}
+
+	// The sandboxed process may run as a different host user
+	if s.uid != os.Getuid() || s.gid != os.Getgid() {
+		if err := os.Chown(dir, s.uid, s.gid); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("failed to chown work directory: %w", err)
+		}
+	}
+
+	return dir, nil
+}
+
+// RemoveWorkDir deletes a working directory created by CreateWorkDir
+func (s *Sandbox) RemoveWorkDir(dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("Failed to remove work directory %s: %v", dir, err)
+	}
+}
+
+// Run executes a command inside the sandbox, killing it when the wall time
+// limit is exceeded or the context is cancelled
+func (s *Sandbox) Run(ctx context.Context, command SandboxCommand) (*SandboxResult, error) {
+	closeAfterStart := func() {
+		for _, closer := range command.CloseAfterStart {
+			closer.Close()
+		}
+	}
+
+	var cmd *exec.Cmd
+	var pipes *isolationPipes
+	var err error
+
+	if s.enabled {
+		cmd, pipes, err = s.isolatedCommand(command)
+		if err != nil {
+			closeAfterStart()
+			return nil, err
+		}
+		defer pipes.close()
+	} else {
+		cmd = exec.Command(command.Path, command.Args...)
+		cmd.Dir = command.Dir
+		cmd.Env = append(sandboxEnv(), command.Env...)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		if s.uid != os.Getuid() {
+			cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(s.uid), Gid: uint32(s.gid)}
+		}
+	}
+
+	cmd.Stdin = command.Stdin
+	cmd.Stdout = command.Stdout
+	cmd.Stderr = command.Stderr
+
+	var outputExceeded chan struct{}
+	if command.OutputLimit > 0 {
+		outputExceeded = make(chan struct{})
+		var once sync.Once
+		exceeded := func() { once.Do(func() { close(outputExceeded) }) }
+		if cmd.Stdout != nil {
+			cmd.Stdout = &outputLimiter{w: cmd.Stdout, limit: command.OutputLimit, exceeded: exceeded}
+		}
+		if cmd.Stderr != nil {
+			cmd.Stderr = &outputLimiter{w: cmd.Stderr, limit: command.OutputLimit, exceeded: exceeded}
+		}
+	}
+
+	err = cmd.Start()
+	closeAfterStart()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sandboxed process: %w", err)
+	}
+
+	// Surface failures that happen between fork and exec of the target program
+	if pipes != nil {
+		if err := pipes.setupErr(); err != nil {
+			cmd.Wait()
+			return nil, err
+		}
+	}
+
+	startTime := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var timeout <-chan time.Time
+	if command.Limits.WallTime > 0 {
+		timer := time.NewTimer(command.Limits.WallTime)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	result := &SandboxResult{}
+
+	select {
+	case <-done:
+	case <-timeout:
+		killProcessGroup(cmd)
+		<-done
+		result.TimedOut = true
+	case <-outputExceeded:
+		killProcessGroup(cmd)
+		<-done
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-done
+		return nil, ctx.Err()
+	}
+
+	// Wait returns once all output was copied, so this also catches a process
+	// that exited right after writing too much
+	select {
+	case <-outputExceeded:
+		result.OutputExceeded = true
+	default:
+	}
+
+	result.WallTime = time.Since(startTime)
+
+	// Rusage of the exec'd program. Without a report from the exec helper it
+	// also includes the milliseconds and megabytes of the sandbox init process,
+	// which becomes the program via exec.
+	if usage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+		result.CPUTime = time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+		result.MaxRSSKB = int64(usage.Maxrss)
+	}
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		result.Signal = status.Signal()
+	}
+
+	if pipes != nil {
+		if report := pipes.report(); report != nil {
+			result.ExitCode = report.exitCode
+			result.Signal = report.signal
+			result.CPUTime = report.cpuTime
+			result.MaxRSSKB = report.maxRSSKB
+		}
+	}
+
+	// RLIMIT_CPU only has second granularity
+	if command.Limits.CPUTime > 0 && result.CPUTime > command.Limits.CPUTime {
+		result.TimedOut = true
+	}
+
+	// RLIMIT_CPU delivers SIGXCPU first and SIGKILL at the hard limit
+	if result.Signal == syscall.SIGXCPU {
+		result.TimedOut = true
+	}
+
+	return result, nil
+}
+
+// isolationPipes connect the server to the sandbox init process: it reports
+// setup failures on the error pipe, and the exec helper reports how the
+// program ended and what it used on the report pipe
+type isolationPipes struct {
+	errReader    *os.File
+	errWriter    *os.File
+	reportReader *os.File
+	reportWriter *os.File
+}
+
+// sandboxReport is the exec helper's account of the program, which unlike the
+// rusage of the init process leaves out the init process itself
+type sandboxReport struct {
+	exitCode int
+	signal   syscall.Signal
+	cpuTime  time.Duration
+	maxRSSKB int64
+}
+
+// setupErr waits until the target program was exec'd and reports failures before that
+func (p *isolationPipes) setupErr() error {
+	p.errWriter.Close()
+	p.reportWriter.Close()
+
+	// The pipe is close-on-exec in the init process, so EOF without data means exec succeeded
+	msg, _ := io.ReadAll(p.errReader)
+	if len(msg) > 0 {
+		return fmt.Errorf("sandbox setup failed: %s", strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// report reads the exec helper's report once the program has exited. It is
+// nil without a helper, or when the helper was killed, e.g. on timeout.
+func (p *isolationPipes) report() *sandboxReport {
+	data, err := io.ReadAll(p.reportReader)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	var report sandboxReport
+	var signal int
+	var cpuMicros int64
+	if _, err := fmt.Sscan(string(data), &report.exitCode, &signal, &report.maxRSSKB, &cpuMicros); err != nil {
+		log.Printf("Invalid sandbox report %q: %v", data, err)
+		return nil
+	}
+	report.signal = syscall.Signal(signal)
+	report.cpuTime = time.Duration(cpuMicros) * time.Microsecond
+	return &report
+}
+
+// close releases the pipes; it is safe to call after setupErr and report
+func (p *isolationPipes) close() {
+	for _, file := range []*os.File{p.errReader, p.errWriter, p.reportReader, p.reportWriter} {
+		file.Close()
+	}
+}
+
+// outputLimiter passes at most limit bytes on to w and calls exceeded when the
+// process writes more. Further output is discarded rather than rejected, so the
+// process does not block on a full pipe until it is killed.
+type outputLimiter struct {
+	w        io.Writer
+	limit    int64
+	written  int64
+	exceeded func()
+}
+
+func (l *outputLimiter) Write(p []byte) (int, error) {
+	if room := l.limit - l.written; int64(len(p)) > room {
+		if room > 0 {
+			l.w.Write(p[:room])
+			l.written = l.limit
+		}
+		l.exceeded()
+		return len(p), nil
+	}
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+	return n, err
+}
+
+// killProcessGroup kills the sandboxed process and everything it spawned
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
+
+// sandboxPath returns the PATH of sandboxed processes
+func sandboxPath() string {
+	if path := os.Getenv("PATH"); path != "" {
+		return path
+	}
+	return "/usr/local/bin:/usr/bin:/bin"
+}
+
+// sandboxEnv returns the minimal environment passed to sandboxed processes
+func sandboxEnv() []string {
+	return []string{
+		"PATH=" + sandboxPath(),
+		"HOME=/tmp",
+		"TMPDIR=/tmp",
+		"LANG=C.UTF-8",
+	}
+}
@@ -0,0 +1,515 @@
+//go:build linux
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+	"golang.org/x/sys/unix"
+)
+
+// sandboxInitArg is argv[0] of the re-executed server binary that sets up
+// isolation inside the new namespaces before exec'ing the target program
+const sandboxInitArg = "sandbox-init"
+
+// sandboxReadOnlyPaths are the host paths visible to sandboxed programs besides
+// their work directory: the toolchains and the files the dynamic linker and the
+// JDK read. Patterns are expanded with filepath.Glob.
+var sandboxReadOnlyPaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+	"/etc/java-*",
+}
+
+// sandboxDevices are bind-mounted into the sandbox's /dev
+var sandboxDevices = []string{"null", "zero", "full", "random", "urandom"}
+
+// sandboxSpec is passed to the init process through argv
+type sandboxSpec struct {
+	Root          string        `json:"root"`
+	Dir           string        `json:"dir"`
+	Path          string        `json:"path"`
+	Args          []string      `json:"args"`
+	Limits        SandboxLimits `json:"limits"`
+	ReadOnlyPaths []string      `json:"read_only_paths"`
+	Helper        string        `json:"helper"`
+}
+
+func init() {
+	if len(os.Args) > 1 && os.Args[0] == sandboxInitArg {
+		runSandboxInit(os.Args[1])
+	}
+}
+
+// configure resolves the paths visible to sandboxed processes and probes namespace support
+func (s *Sandbox) configure(cfg config.SandboxConfig) error {
+	// Unprivileged user namespaces can only map the server's own uid, which
+	// would give programs access to everything the server owns
+	if os.Getuid() != 0 {
+		return fmt.Errorf("sandbox requires running as root to isolate user code as uid %d; set SANDBOX_ENABLED=false to run it without isolation", sandboxNobodyID)
+	}
+
+	for _, pattern := range append(slices.Clone(sandboxReadOnlyPaths), cfg.ReadOnlyPaths...) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid sandbox path %q: %w", pattern, err)
+		}
+		s.readOnlyPaths = append(s.readOnlyPaths, matches...)
+	}
+
+	initPath, err := installSandboxInit(s.root)
+	if err != nil {
+		return err
+	}
+	s.initPath = initPath
+
+	helperPath, err := installSandboxHelper(s.root)
+	if err != nil {
+		return err
+	}
+	s.helperPath = helperPath
+
+	if err := s.probe(); err != nil {
+		return fmt.Errorf("namespace isolation unavailable: %w; set SANDBOX_ENABLED=false to run user code without isolation", err)
+	}
+
+	if !seccompSupported {
+		log.Printf("WARNING: Seccomp filtering not supported on %s", runtime.GOARCH)
+	}
+
+	log.Printf("Sandbox ready: root=%s uid=%d seccomp=%v read-only paths=%v", s.root, s.uid, seccompSupported, s.readOnlyPaths)
+	return nil
+}
+
+// installSandboxInit copies the server binary into the sandbox root. The init
+// process starts as nobody, which can neither traverse the directory the server
+// may be installed in nor exec /proc/self/exe of a process that changed uid.
+func installSandboxInit(root string) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate server binary: %w", err)
+	}
+
+	path := filepath.Join(root, sandboxInitArg)
+	if err := installExecutable(executable, path); err != nil {
+		return "", fmt.Errorf("failed to install sandbox init binary: %w", err)
+	}
+	return path, nil
+}
+
+// probe runs a trivial program to check that namespaces can be created
+func (s *Sandbox) probe() error {
+	dir, err := s.CreateWorkDir()
+	if err != nil {
+		return err
+	}
+	defer s.RemoveWorkDir(dir)
+
+	truePath, err := exec.LookPath("true")
+	if err != nil {
+		return fmt.Errorf("probe binary not found: %w", err)
+	}
+
+	cmd, pipes, err := s.isolatedCommand(SandboxCommand{Dir: dir, Path: truePath})
+	if err != nil {
+		return err
+	}
+	defer pipes.close()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := pipes.setupErr(); err != nil {
+		cmd.Wait()
+		return err
+	}
+	return cmd.Wait()
+}
+
+// isolatedCommand builds a command that re-executes the server binary as the
+// sandbox init process, along with the pipes it reports through
+func (s *Sandbox) isolatedCommand(command SandboxCommand) (*exec.Cmd, *isolationPipes, error) {
+	spec, err := json.Marshal(sandboxSpec{
+		Root:          s.root,
+		Dir:           command.Dir,
+		Path:          command.Path,
+		Args:          command.Args,
+		Limits:        command.Limits,
+		ReadOnlyPaths: s.readOnlyPaths,
+		Helper:        s.helperPath,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal sandbox spec: %w", err)
+	}
+
+	pipes := &isolationPipes{}
+	pipes.errReader, pipes.errWriter, err = os.Pipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create sandbox pipe: %w", err)
+	}
+	pipes.reportReader, pipes.reportWriter, err = os.Pipe()
+	if err != nil {
+		pipes.close()
+		return nil, nil, fmt.Errorf("failed to create sandbox pipe: %w", err)
+	}
+
+	cmd := &exec.Cmd{
+		Path:       s.initPath,
+		Args:       []string{sandboxInitArg, string(spec)},
+		Dir:        command.Dir,
+		Env:        append(sandboxEnv(), command.Env...),
+		ExtraFiles: []*os.File{pipes.errWriter, pipes.reportWriter},
+		SysProcAttr: &syscall.SysProcAttr{
+			Setpgid:   true,
+			Pdeathsig: syscall.SIGKILL,
+			Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNS |
+				syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+			UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: s.uid, Size: 1}},
+			GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: s.gid, Size: 1}},
+			// Allowed so that the server's supplementary groups can be cleared
+			GidMappingsEnableSetgroups: true,
+			// Switch to the mapped ids; otherwise the process keeps its original host uid
+			Credential: &syscall.Credential{Uid: 0, Gid: 0},
+		},
+	}
+
+	return cmd, pipes, nil
+}
+
+// runSandboxInit runs inside the new namespaces. It never returns: it either
+// execs the target program, through the exec helper when there is one, or
+// exits after reporting the failure on fd 3.
+func runSandboxInit(rawSpec string) {
+	runtime.LockOSThread()
+
+	errPipe := os.NewFile(3, "sandbox-err")
+	syscall.CloseOnExec(3)
+
+	fail := func(format string, args ...interface{}) {
+		fmt.Fprintf(errPipe, format, args...)
+		os.Exit(1)
+	}
+
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(rawSpec), &spec); err != nil {
+		fail("invalid spec: %v", err)
+	}
+
+	if err := setupMounts(spec); err != nil {
+		fail("%v", err)
+	}
+
+	if err := os.Chdir(spec.Dir); err != nil {
+		fail("chdir: %v", err)
+	}
+
+	path := spec.Path
+	if !strings.Contains(path, "/") {
+		resolved, err := exec.LookPath(path)
+		if err != nil {
+			fail("%s: command not found", path)
+		}
+		path = resolved
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		fail("no_new_privs: %v", err)
+	}
+
+	if err := installSeccompFilter(); err != nil {
+		fail("seccomp: %v", err)
+	}
+
+	// Limits go last: the address space and process caps would otherwise
+	// starve the Go runtime of this init process
+	if err := applyRlimits(spec.Limits); err != nil {
+		fail("%v", err)
+	}
+
+	argv := append([]string{filepath.Base(path)}, spec.Args...)
+	if spec.Helper != "" {
+		argv = append([]string{sandboxHelperName, path}, argv...)
+		path = spec.Helper
+	} else {
+		syscall.CloseOnExec(sandboxReportFd)
+	}
+	err := syscall.Exec(path, argv, os.Environ())
+	fail("exec %s: %v", spec.Path, err)
+}
+
+// setupMounts builds a minimal root filesystem on a tmpfs and pivots into it.
+// It holds read-only bind mounts of the toolchain paths, the work directory at
+// its original path, a fresh /tmp, a few devices and a fresh /proc; the rest
+// of the host filesystem is unreachable.
+func setupMounts(spec sandboxSpec) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+
+	// Keep a handle on the work directory before the new root hides it
+	workDir, err := os.Open(spec.Dir)
+	if err != nil {
+		return fmt.Errorf("open work directory: %w", err)
+	}
+	defer workDir.Close()
+
+	var helper *os.File
+	if spec.Helper != "" {
+		helper, err = os.Open(spec.Helper)
+		if err != nil {
+			return fmt.Errorf("open exec helper: %w", err)
+		}
+		defer helper.Close()
+	}
+
+	newRoot := spec.Root
+	if err := unix.Mount("tmpfs", newRoot, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=1m,mode=0755"); err != nil {
+		return fmt.Errorf("mount root tmpfs: %w", err)
+	}
+
+	for _, path := range spec.ReadOnlyPaths {
+		if err := bindReadOnly(path, filepath.Join(newRoot, path)); err != nil {
+			return err
+		}
+	}
+
+	tmpDir := filepath.Join(newRoot, os.TempDir())
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return fmt.Errorf("create /tmp: %w", err)
+	}
+	tmpfsOpts := fmt.Sprintf("size=%d,mode=1777", SANDBOX_TMPFS_SIZE)
+	if err := unix.Mount("tmpfs", tmpDir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, tmpfsOpts); err != nil {
+		return fmt.Errorf("mount tmpfs on /tmp: %w", err)
+	}
+
+	// The helper and the work directory keep their host paths, so they go
+	// after /tmp, which usually contains them
+	if helper != nil {
+		target := filepath.Join(newRoot, spec.Helper)
+		if err := createMountPoint(target, false); err != nil {
+			return fmt.Errorf("create exec helper mount point: %w", err)
+		}
+		if err := mountReadOnly(fmt.Sprintf("/proc/self/fd/%d", helper.Fd()), target); err != nil {
+			return fmt.Errorf("bind exec helper: %w", err)
+		}
+	}
+
+	// Same path as on the host so absolute paths derived from getcwd keep working
+	workTarget := filepath.Join(newRoot, spec.Dir)
+	if err := os.MkdirAll(workTarget, 0755); err != nil {
+		return fmt.Errorf("create work directory mount point: %w", err)
+	}
+	source := fmt.Sprintf("/proc/self/fd/%d", workDir.Fd())
+	if err := unix.Mount(source, workTarget, "", unix.MS_BIND|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("bind work directory: %w", err)
+	}
+
+	if err := setupDevices(filepath.Join(newRoot, "dev")); err != nil {
+		return err
+	}
+
+	// Some container runtimes lock /proc; the PID namespace still isolates signals
+	procDir := filepath.Join(newRoot, "proc")
+	if err := os.Mkdir(procDir, 0555); err != nil {
+		return fmt.Errorf("create /proc: %w", err)
+	}
+	unix.Mount("proc", procDir, "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+
+	oldRoot := filepath.Join(newRoot, ".old-root")
+	if err := os.Mkdir(oldRoot, 0700); err != nil {
+		return fmt.Errorf("create old root: %w", err)
+	}
+	if err := unix.PivotRoot(newRoot, oldRoot); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return fmt.Errorf("chdir to new root: %w", err)
+	}
+	if err := unix.Unmount("/.old-root", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+	if err := os.Remove("/.old-root"); err != nil {
+		return fmt.Errorf("remove old root: %w", err)
+	}
+
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+
+	return nil
+}
+
+// bindReadOnly mirrors a host path at target: symlinks are recreated and
+// files and directories are bind-mounted read-only. Missing paths are skipped.
+func bindReadOnly(source string, target string) error {
+	info, err := os.Lstat(source)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", source, err)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(source)
+		if err != nil {
+			return fmt.Errorf("read link %s: %w", source, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("create parent of %s: %w", source, err)
+		}
+		if err := os.Symlink(link, target); err != nil && !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("create link %s: %w", source, err)
+		}
+		return nil
+	}
+
+	if err := createMountPoint(target, info.IsDir()); err != nil {
+		return fmt.Errorf("create mount point %s: %w", source, err)
+	}
+	if err := mountReadOnly(source, target); err != nil {
+		return fmt.Errorf("bind %s: %w", source, err)
+	}
+	return nil
+}
+
+// createMountPoint creates an empty directory or file to mount over, and its parents
+func createMountPoint(target string, dir bool) error {
+	if dir {
+		return os.MkdirAll(target, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// mountReadOnly bind-mounts source on target and makes the mount read-only
+func mountReadOnly(source string, target string) error {
+	if err := unix.Mount(source, target, "", unix.MS_BIND, ""); err != nil {
+		return err
+	}
+
+	// A bind mount only becomes read-only on remount, which must keep the
+	// flags the host mount locks in a user namespace
+	var stat unix.Statfs_t
+	if err := unix.Statfs(source, &stat); err != nil {
+		return fmt.Errorf("statfs: %w", err)
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY | unix.MS_NOSUID | unix.MS_NODEV)
+	for statFlag, mountFlag := range map[int64]uintptr{
+		unix.ST_NOEXEC:     unix.MS_NOEXEC,
+		unix.ST_NOATIME:    unix.MS_NOATIME,
+		unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+		unix.ST_RELATIME:   unix.MS_RELATIME,
+	} {
+		if int64(stat.Flags)&statFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	if err := unix.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("remount read-only: %w", err)
+	}
+	return nil
+}
+
+// setupDevices populates a tmpfs /dev with the host's harmless devices
+func setupDevices(devDir string) error {
+	if err := os.Mkdir(devDir, 0755); err != nil {
+		return fmt.Errorf("create /dev: %w", err)
+	}
+	if err := unix.Mount("tmpfs", devDir, "tmpfs", unix.MS_NOSUID|unix.MS_NOEXEC, "size=64k,mode=0755"); err != nil {
+		return fmt.Errorf("mount tmpfs on /dev: %w", err)
+	}
+
+	for _, device := range sandboxDevices {
+		target := filepath.Join(devDir, device)
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0666)
+		if err != nil {
+			return fmt.Errorf("create /dev/%s: %w", device, err)
+		}
+		file.Close()
+		if err := unix.Mount(filepath.Join("/dev", device), target, "", unix.MS_BIND, ""); err != nil {
+			return fmt.Errorf("bind /dev/%s: %w", device, err)
+		}
+	}
+
+	for name, link := range map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(link, filepath.Join(devDir, name)); err != nil {
+			return fmt.Errorf("create /dev/%s: %w", name, err)
+		}
+	}
+
+	shmDir := filepath.Join(devDir, "shm")
+	if err := os.Mkdir(shmDir, 01777); err != nil {
+		return fmt.Errorf("create /dev/shm: %w", err)
+	}
+	tmpfsOpts := fmt.Sprintf("size=%d,mode=1777", SANDBOX_TMPFS_SIZE)
+	if err := unix.Mount("tmpfs", shmDir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, tmpfsOpts); err != nil {
+		return fmt.Errorf("mount tmpfs on /dev/shm: %w", err)
+	}
+	return nil
+}
+
+// applyRlimits sets the resource limits inherited by the target program
+func applyRlimits(limits SandboxLimits) error {
+	set := func(resource int, value uint64, name string) error {
+		if value == 0 {
+			return nil
+		}
+		if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("setrlimit %s: %w", name, err)
+		}
+		return nil
+	}
+
+	cpuSeconds := uint64(0)
+	if limits.CPUTime > 0 {
+		// Round up so sub-second limits still get a cap; the wall clock enforces precision
+		cpuSeconds = uint64((limits.CPUTime + time.Second - 1) / time.Second)
+	}
+
+	// Core dumps would be written into the work directory
+	unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{Cur: 0, Max: 0})
+
+	// The hard limit is a second later, so the program first gets SIGXCPU and
+	// is reported as timed out; at equal limits the kernel sends SIGKILL only
+	if cpuSeconds > 0 {
+		if err := unix.Setrlimit(unix.RLIMIT_CPU, &unix.Rlimit{Cur: cpuSeconds, Max: cpuSeconds + 1}); err != nil {
+			return fmt.Errorf("setrlimit cpu: %w", err)
+		}
+	}
+	if err := set(unix.RLIMIT_AS, uint64(limits.AddressSpace), "as"); err != nil {
+		return err
+	}
+	if err := set(unix.RLIMIT_FSIZE, uint64(limits.FileSize), "fsize"); err != nil {
+		return err
+	}
+	if err := set(unix.RLIMIT_NPROC, uint64(limits.Processes), "nproc"); err != nil {
+		return err
+	}
+	if err := set(unix.RLIMIT_STACK, uint64(limits.Stack), "stack"); err != nil {
+		return err
+	}
+	return nil
+}
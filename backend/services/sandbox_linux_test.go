@@ -0,0 +1,317 @@
+//go:build linux
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+)
+
+// newTestSandbox creates an isolating sandbox, skipping the test on hosts that cannot provide one
+func newTestSandbox(t *testing.T) *Sandbox {
+	t.Helper()
+	if os.Getuid() != 0 {
+		t.Skip("sandbox isolation requires root")
+	}
+
+	// Unlike t.TempDir, the root must be reachable by the sandbox user
+	root, err := os.MkdirTemp("", "sandbox-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+	if err := os.Chmod(root, 0711); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Config.Sandbox = config.SandboxConfig{Enabled: true, Root: root}
+	sandbox, err := NewSandbox()
+	if err != nil {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+	return sandbox
+}
+
+// runShell runs a shell script in a fresh work directory and returns its exit code and output
+func runShell(t *testing.T, sandbox *Sandbox, dir string, script string) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	result, err := sandbox.Run(context.Background(), SandboxCommand{
+		Dir:    dir,
+		Path:   "/bin/sh",
+		Args:   []string{"-c", script},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Limits: SandboxLimits{WallTime: 10 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("run %q: %v", script, err)
+	}
+	return result.ExitCode, stdout.String() + stderr.String()
+}
+
+// newTestExecutionService creates an execution service that judges C++ in a
+// test sandbox, with empty caches
+func newTestExecutionService(t *testing.T) *ExecutionService {
+	t.Helper()
+	sandbox := newTestSandbox(t)
+	if _, err := exec.LookPath("g++"); err != nil {
+		t.Skip("g++ not installed")
+	}
+
+	config.Config.Execution.CheckerCacheDir = t.TempDir()
+	config.Config.Execution.MemoryLimitMB = 256
+	config.Config.Execution.OutputLimitMB = 64
+	registry, err := NewLanguageRegistry([]config.LanguageConfig{{
+		ID: "cpp", Name: "C++", SourceFile: "main.cpp",
+		Compile: []string{"g++", "-O2", "-std=c++17", "{source}", "-o", "main"},
+		Run:     []string{"./main"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compileCache, err := NewCompileCache(t.TempDir(), 64<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache, err := NewCompileCache(t.TempDir(), 64<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewExecutionService(sandbox, NewExecutionPool(2, 100), registry, compileCache, testCache, nil)
+}
+
+// echoProgram prints its input back
+const echoProgram = `#include <iostream>
+#include <string>
+int main() {
+    std::string token;
+    while (std::cin >> token) std::cout << token << "\n";
+}
+`
+
+func TestSandboxHidesHostFilesystem(t *testing.T) {
+	sandbox := newTestSandbox(t)
+	dir, err := sandbox.CreateWorkDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sandbox.RemoveWorkDir(dir)
+
+	secret, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/etc/passwd", "/root", secret} {
+		if code, output := runShell(t, sandbox, dir, "ls "+path); code == 0 {
+			t.Errorf("%s is visible in the sandbox: %s", path, output)
+		}
+	}
+
+	if code, output := runShell(t, sandbox, dir, "echo ok > out && cat out"); code != 0 || strings.TrimSpace(output) != "ok" {
+		t.Errorf("work directory is not writable: exit %d: %s", code, output)
+	}
+	if code, output := runShell(t, sandbox, dir, "echo ok > /tmp/out && cat /tmp/out"); code != 0 || strings.TrimSpace(output) != "ok" {
+		t.Errorf("/tmp is not writable: exit %d: %s", code, output)
+	}
+	for _, path := range []string{"/usr/x", "/x"} {
+		if code, _ := runShell(t, sandbox, dir, "touch "+path); code == 0 {
+			t.Errorf("%s could be created", path)
+		}
+	}
+}
+
+func TestSandboxRunsAsNobody(t *testing.T) {
+	sandbox := newTestSandbox(t)
+	dir, err := sandbox.CreateWorkDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sandbox.RemoveWorkDir(dir)
+
+	if code, output := runShell(t, sandbox, dir, "touch file"); code != 0 {
+		t.Fatalf("touch: exit %d: %s", code, output)
+	}
+	info, err := os.Stat(filepath.Join(dir, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner := fileOwner(info); owner != sandboxNobodyID {
+		t.Errorf("file created by uid %d, want %d", owner, sandboxNobodyID)
+	}
+}
+
+const cloneProgram = `
+#define _GNU_SOURCE
+#include <errno.h>
+#include <sched.h>
+#include <signal.h>
+#include <stdio.h>
+#include <sys/syscall.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+int main() {
+	long ret = syscall(SYS_clone, CLONE_NEWUSER | SIGCHLD, 0, 0, 0, 0);
+	if (ret == 0) _exit(0);
+	printf("newuser %s\n", ret < 0 && errno == EPERM ? "EPERM" : "allowed");
+
+	ret = syscall(SYS_clone3, 0, 0);
+	printf("clone3 %s\n", ret < 0 && errno == ENOSYS ? "ENOSYS" : "allowed");
+
+	pid_t pid = fork();
+	if (pid == 0) _exit(0);
+	printf("fork %s\n", pid > 0 && waitpid(pid, 0, 0) == pid ? "ok" : "failed");
+	return 0;
+}
+`
+
+func TestSandboxFiltersNamespaceClones(t *testing.T) {
+	sandbox := newTestSandbox(t)
+	gcc, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc not installed")
+	}
+
+	dir, err := sandbox.CreateWorkDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sandbox.RemoveWorkDir(dir)
+
+	source := filepath.Join(dir, "clone.c")
+	if err := os.WriteFile(source, []byte(cloneProgram), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(gcc, source, "-o", filepath.Join(dir, "clone")).CombinedOutput(); err != nil {
+		t.Fatalf("compile: %v: %s", err, output)
+	}
+
+	code, output := runShell(t, sandbox, dir, "./clone")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, output)
+	}
+	for _, want := range []string{"newuser EPERM", "clone3 ENOSYS", "fork ok"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output %q does not contain %q", output, want)
+		}
+	}
+}
+
+func fileOwner(info os.FileInfo) int {
+	return int(info.Sys().(*syscall.Stat_t).Uid)
+}
+
+const memoryProgram = `
+#include <signal.h>
+#include <stdlib.h>
+#include <string.h>
+
+int main(int argc, char **argv) {
+	if (argc > 1 && strcmp(argv[1], "segv") == 0) raise(SIGSEGV);
+	if (argc > 1 && strcmp(argv[1], "exit") == 0) return 3;
+	if (argc > 1 && strcmp(argv[1], "alloc") == 0) {
+		char *p = malloc(64 << 20);
+		memset(p, 1, 64 << 20);
+		return p[12345] - 1;
+	}
+	return 0;
+}
+`
+
+func TestSandboxMeasuresProgramOnly(t *testing.T) {
+	sandbox := newTestSandbox(t)
+	if sandbox.helperPath == "" {
+		t.Skip("exec helper unavailable")
+	}
+	gcc, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc not installed")
+	}
+
+	dir, err := sandbox.CreateWorkDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sandbox.RemoveWorkDir(dir)
+
+	source := filepath.Join(dir, "memory.c")
+	if err := os.WriteFile(source, []byte(memoryProgram), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(gcc, "-O0", source, "-o", filepath.Join(dir, "memory")).CombinedOutput(); err != nil {
+		t.Fatalf("compile: %v: %s", err, output)
+	}
+
+	run := func(arg string) *SandboxResult {
+		result, err := sandbox.Run(context.Background(), SandboxCommand{
+			Dir:    dir,
+			Path:   "./memory",
+			Args:   []string{arg},
+			Limits: SandboxLimits{WallTime: 10 * time.Second},
+		})
+		if err != nil {
+			t.Fatalf("run %s: %v", arg, err)
+		}
+		return result
+	}
+
+	// The Go sandbox init process alone peaks well above this
+	if result := run("none"); result.ExitCode != 0 || result.MaxRSSKB > 4<<10 {
+		t.Errorf("empty program: exit %d, peak %dKB", result.ExitCode, result.MaxRSSKB)
+	}
+	if result := run("alloc"); result.ExitCode != 0 || result.MaxRSSKB < 64<<10 || result.MaxRSSKB > 72<<10 {
+		t.Errorf("64MB program: exit %d, peak %dKB", result.ExitCode, result.MaxRSSKB)
+	}
+	if result := run("exit"); result.ExitCode != 3 || result.Signal != 0 {
+		t.Errorf("exit program: exit %d, signal %v", result.ExitCode, result.Signal)
+	}
+	if result := run("segv"); result.ExitCode != -1 || result.Signal != syscall.SIGSEGV {
+		t.Errorf("crashing program: exit %d, signal %v", result.ExitCode, result.Signal)
+	}
+}
+
+func TestSandboxOutputLimit(t *testing.T) {
+	sandbox := newTestSandbox(t)
+	dir, err := sandbox.CreateWorkDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sandbox.RemoveWorkDir(dir)
+
+	run := func(script string) (*SandboxResult, int) {
+		var stdout bytes.Buffer
+		result, err := sandbox.Run(context.Background(), SandboxCommand{
+			Dir:         dir,
+			Path:        "/bin/sh",
+			Args:        []string{"-c", script},
+			Stdout:      &stdout,
+			Limits:      SandboxLimits{WallTime: 10 * time.Second},
+			OutputLimit: 1000,
+		})
+		if err != nil {
+			t.Fatalf("run %q: %v", script, err)
+		}
+		return result, stdout.Len()
+	}
+
+	if result, size := run("yes"); !result.OutputExceeded || result.TimedOut || size != 1000 {
+		t.Errorf("endless output: exceeded %v, timed out %v, kept %d bytes", result.OutputExceeded, result.TimedOut, size)
+	}
+	if result, size := run("head -c 1001 /dev/zero"); !result.OutputExceeded || size != 1000 {
+		t.Errorf("output just over the limit: exceeded %v, kept %d bytes", result.OutputExceeded, size)
+	}
+	if result, size := run("head -c 1000 /dev/zero"); result.OutputExceeded || result.ExitCode != 0 || size != 1000 {
+		t.Errorf("output at the limit: exceeded %v, exit %d, kept %d bytes", result.OutputExceeded, result.ExitCode, size)
+	}
+}
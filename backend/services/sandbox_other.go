@@ -0,0 +1,20 @@
+//go:build !linux
+
+package services
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/boobachad/simulate-interview/backend/config"
+)
+
+// configure refuses to start: namespaces, rlimits and seccomp are Linux-only
+func (s *Sandbox) configure(cfg config.SandboxConfig) error {
+	return fmt.Errorf("sandbox isolation is only supported on Linux; set SANDBOX_ENABLED=false to run user code without isolation")
+}
+
+// isolatedCommand is never called because configure fails
+func (s *Sandbox) isolatedCommand(command SandboxCommand) (*exec.Cmd, *isolationPipes, error) {
+	return nil, nil, fmt.Errorf("sandbox isolation not supported on this platform")
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// SANITIZER_MAX_FRAMES limits the stack trace kept per finding
+const SANITIZER_MAX_FRAMES = 16
+
+var (
+	// asanErrorPattern matches "==123==ERROR: AddressSanitizer: heap-buffer-overflow on address ..."
+	asanErrorPattern = regexp.MustCompile(`^==\d+==ERROR: (\w+Sanitizer): ([\w-]+)`)
+
+	// ubsanErrorPattern matches "main.cpp:5:7: runtime error: signed integer overflow: ..."
+	ubsanErrorPattern = regexp.MustCompile(`^(.+?):(\d+):(\d+): runtime error: (.*)$`)
+
+	// stackFramePattern matches "    #0 0x55d3c in main /work/main.cpp:5:7" and
+	// "    #1 0x7f12 in __libc_start_main (/lib/libc.so.6+0x271ca)"
+	stackFramePattern = regexp.MustCompile(`^\s*#\d+ 0x[0-9a-f]+ in (.+?) (\S+?)(?::(\d+))?(?::(\d+))?$`)
+
+	// ubsanKinds maps UBSan messages to the names of their checks
+	ubsanKinds = []struct {
+		prefix string
+		kind   string
+	}{
+		{"signed integer overflow", "signed-integer-overflow"},
+		{"negation of", "signed-integer-overflow"},
+		{"division by zero", "integer-divide-by-zero"},
+		{"shift exponent", "shift"},
+		{"left shift of", "shift"},
+		{"index", "bounds"},
+		{"load of null pointer", "null"},
+		{"store to null pointer", "null"},
+		{"member access within null pointer", "null"},
+		{"load of misaligned address", "alignment"},
+		{"store to misaligned address", "alignment"},
+		{"execution reached the end of a value-returning function", "return"},
+		{"execution reached an unreachable program point", "unreachable"},
+		{"load of value", "invalid-value"},
+		{"variable length array bound", "vla-bound"},
+	}
+)
+
+// ParseSanitizerReport extracts AddressSanitizer and UndefinedBehaviorSanitizer
+// findings from the stderr of a debug run. The location of a finding is the
+// innermost stack frame in sourceFile, so that it points at the user's code
+// rather than at the standard library.
+func ParseSanitizerReport(stderr string, sourceFile string) []models.SanitizerFinding {
+	var findings []models.SanitizerFinding
+	var current *models.SanitizerFinding
+
+	flush := func() {
+		if current != nil {
+			locateFinding(current, sourceFile)
+			findings = append(findings, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if match := asanErrorPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			current = &models.SanitizerFinding{
+				Sanitizer: match[1],
+				Kind:      match[2],
+				Message:   match[2],
+			}
+			continue
+		}
+
+		if match := ubsanErrorPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			lineNumber, _ := strconv.Atoi(match[2])
+			column, _ := strconv.Atoi(match[3])
+			current = &models.SanitizerFinding{
+				Sanitizer: "UndefinedBehaviorSanitizer",
+				Kind:      ubsanKind(match[4]),
+				Message:   match[4],
+				File:      filepath.Base(match[1]),
+				Line:      lineNumber,
+				Column:    column,
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if match := stackFramePattern.FindStringSubmatch(line); match != nil {
+			if len(current.Stack) < SANITIZER_MAX_FRAMES {
+				current.Stack = append(current.Stack, parseStackFrame(match))
+			}
+			continue
+		}
+
+		// ASan prints the access ("WRITE of size 4 at ...") under the header
+		if current.Sanitizer == "AddressSanitizer" && len(current.Stack) == 0 &&
+			(strings.HasPrefix(line, "READ of size") || strings.HasPrefix(line, "WRITE of size")) {
+			if index := strings.Index(line, " at 0x"); index > 0 {
+				current.Message += ": " + line[:index]
+			}
+			continue
+		}
+
+		// The trace of the faulting access ends at the first blank line
+		if strings.TrimSpace(line) == "" && len(current.Stack) > 0 {
+			flush()
+		}
+	}
+	flush()
+
+	return findings
+}
+
+func parseStackFrame(match []string) models.StackFrame {
+	frame := models.StackFrame{Function: match[1]}
+	// Frames without debug info name the module instead, e.g. "(/lib/libc.so.6+0x271ca)"
+	if !strings.HasPrefix(match[2], "(") {
+		frame.File = filepath.Base(match[2])
+		frame.Line, _ = strconv.Atoi(match[3])
+		frame.Column, _ = strconv.Atoi(match[4])
+	}
+	return frame
+}
+
+// locateFinding sets the finding's location to the innermost frame in the user's source
+func locateFinding(finding *models.SanitizerFinding, sourceFile string) {
+	if finding.File != "" {
+		return
+	}
+	for _, frame := range finding.Stack {
+		if frame.File == sourceFile && frame.Line > 0 {
+			finding.File = frame.File
+			finding.Line = frame.Line
+			finding.Column = frame.Column
+			return
+		}
+	}
+}
+
+func ubsanKind(message string) string {
+	for _, k := range ubsanKinds {
+		if strings.HasPrefix(message, k.prefix) {
+			return k.kind
+		}
+	}
+	return "undefined-behavior"
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+func TestParseSanitizerReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   []models.SanitizerFinding
+	}{
+		{
+			name: "heap buffer overflow",
+			stderr: `=================================================================
+==29094==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000020 at pc 0x55fa73af831a bp 0x7ffec9a903f0 sp 0x7ffec9a903e8
+WRITE of size 4 at 0x602000000020 thread T0
+    #0 0x55fa73af8319 in main /work/main.cpp:6
+    #1 0x7f4011245249  (/lib/x86_64-linux-gnu/libc.so.6+0x27249)
+    #2 0x7f4011245304 in __libc_start_main (/lib/x86_64-linux-gnu/libc.so.6+0x27304)
+    #3 0x55fa73af8100 in _start (/work/main+0x1100)
+
+0x602000000020 is located 0 bytes to the right of 16-byte region [0x602000000010,0x602000000020)
+allocated by thread T0 here:
+    #0 0x7f40120b9628 in operator new[](unsigned long) ../../../../src/libsanitizer/asan/asan_new_delete.cpp:98
+    #1 0x55fa73af828e in main /work/main.cpp:5
+
+SUMMARY: AddressSanitizer: heap-buffer-overflow /work/main.cpp:6 in main`,
+			want: []models.SanitizerFinding{{
+				Sanitizer: "AddressSanitizer",
+				Kind:      "heap-buffer-overflow",
+				Message:   "heap-buffer-overflow: WRITE of size 4",
+				File:      "main.cpp",
+				Line:      6,
+				Stack: []models.StackFrame{
+					{Function: "main", File: "main.cpp", Line: 6},
+					{Function: "__libc_start_main"},
+					{Function: "_start"},
+				},
+			}},
+		},
+		{
+			name: "location skips library frames",
+			stderr: "==7==ERROR: AddressSanitizer: heap-use-after-free on address 0x6030 at pc 0x55 bp 0x7f sp 0x7f\r\n" +
+				"READ of size 4 at 0x6030 thread T0\r\n" +
+				"    #0 0x55a in std::vector<int>::operator[](unsigned long) /usr/include/c++/12/bits/stl_vector.h:1124:25\r\n" +
+				"    #1 0x55b in solve(std::vector<int>&) /work/main.cpp:9:14\r\n" +
+				"\r\n",
+			want: []models.SanitizerFinding{{
+				Sanitizer: "AddressSanitizer",
+				Kind:      "heap-use-after-free",
+				Message:   "heap-use-after-free: READ of size 4",
+				File:      "main.cpp",
+				Line:      9,
+				Column:    14,
+				Stack: []models.StackFrame{
+					{Function: "std::vector<int>::operator[](unsigned long)", File: "stl_vector.h", Line: 1124, Column: 25},
+					{Function: "solve(std::vector<int>&)", File: "main.cpp", Line: 9, Column: 14},
+				},
+			}},
+		},
+		{
+			name: "undefined behavior",
+			stderr: `main.cpp:4:7: runtime error: signed integer overflow: 1 + 2147483647 cannot be represented in type 'int'
+/work/main.cpp:7:16: runtime error: load of null pointer of type 'int'
+main.cpp:12:3: runtime error: something new`,
+			want: []models.SanitizerFinding{
+				{
+					Sanitizer: "UndefinedBehaviorSanitizer",
+					Kind:      "signed-integer-overflow",
+					Message:   "signed integer overflow: 1 + 2147483647 cannot be represented in type 'int'",
+					File:      "main.cpp",
+					Line:      4,
+					Column:    7,
+				},
+				{
+					Sanitizer: "UndefinedBehaviorSanitizer",
+					Kind:      "null",
+					Message:   "load of null pointer of type 'int'",
+					File:      "main.cpp",
+					Line:      7,
+					Column:    16,
+				},
+				{
+					Sanitizer: "UndefinedBehaviorSanitizer",
+					Kind:      "undefined-behavior",
+					Message:   "something new",
+					File:      "main.cpp",
+					Line:      12,
+					Column:    3,
+				},
+			},
+		},
+		{
+			name:   "clean run",
+			stderr: "debug: n = 5\n",
+			want:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ParseSanitizerReport(test.stderr, "main.cpp")
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseSanitizerReport() =\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+//go:build linux && (amd64 || arm64)
+
+package services
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const seccompSupported = true
+
+// seccompAllowedSyscalls is the allowlist shared by all architectures. It covers
+// what compilers and the C++, Python, Java and Node runtimes need; everything
+// else (sockets, ptrace, mount, bpf, keyrings, module loading...) fails with EPERM.
+var seccompAllowedSyscalls = []uintptr{
+	// File I/O
+	unix.SYS_READ, unix.SYS_WRITE, unix.SYS_READV, unix.SYS_WRITEV,
+	unix.SYS_PREAD64, unix.SYS_PWRITE64, unix.SYS_LSEEK, unix.SYS_OPENAT,
+	unix.SYS_CLOSE, unix.SYS_FSTAT, unix.SYS_NEWFSTATAT, unix.SYS_STATX,
+	unix.SYS_FACCESSAT, unix.SYS_FACCESSAT2, unix.SYS_READLINKAT, unix.SYS_GETDENTS64,
+	unix.SYS_GETCWD, unix.SYS_CHDIR, unix.SYS_FCHDIR, unix.SYS_MKDIRAT,
+	unix.SYS_UNLINKAT, unix.SYS_RENAMEAT, unix.SYS_RENAMEAT2, unix.SYS_LINKAT,
+	unix.SYS_SYMLINKAT, unix.SYS_FCHMOD, unix.SYS_FCHMODAT, unix.SYS_FTRUNCATE,
+	unix.SYS_FSYNC, unix.SYS_FDATASYNC, unix.SYS_FCNTL, unix.SYS_FLOCK,
+	unix.SYS_DUP, unix.SYS_DUP3, unix.SYS_PIPE2, unix.SYS_IOCTL,
+	unix.SYS_STATFS, unix.SYS_FSTATFS, unix.SYS_UTIMENSAT, unix.SYS_UMASK,
+	unix.SYS_COPY_FILE_RANGE, unix.SYS_SENDFILE,
+
+	// Memory
+	unix.SYS_MMAP, unix.SYS_MUNMAP, unix.SYS_MPROTECT, unix.SYS_MREMAP,
+	unix.SYS_MADVISE, unix.SYS_BRK, unix.SYS_MSYNC, unix.SYS_MINCORE,
+	unix.SYS_MEMBARRIER, unix.SYS_MEMFD_CREATE,
+
+	// Signals
+	unix.SYS_RT_SIGACTION, unix.SYS_RT_SIGPROCMASK, unix.SYS_RT_SIGRETURN,
+	unix.SYS_RT_SIGSUSPEND, unix.SYS_RT_SIGTIMEDWAIT, unix.SYS_SIGALTSTACK,
+	unix.SYS_KILL, unix.SYS_TGKILL, unix.SYS_TKILL,
+
+	// Processes and threads
+	unix.SYS_EXECVE, unix.SYS_EXIT,
+	unix.SYS_EXIT_GROUP, unix.SYS_WAIT4, unix.SYS_WAITID, unix.SYS_SET_TID_ADDRESS,
+	unix.SYS_SET_ROBUST_LIST, unix.SYS_GET_ROBUST_LIST, unix.SYS_FUTEX, unix.SYS_RSEQ,
+	unix.SYS_SCHED_YIELD, unix.SYS_SCHED_GETAFFINITY, unix.SYS_SCHED_GETPARAM,
+	unix.SYS_SCHED_GETSCHEDULER, unix.SYS_GETPRIORITY, unix.SYS_PRCTL,
+	unix.SYS_PRLIMIT64, unix.SYS_GETRLIMIT, unix.SYS_GETRUSAGE,
+
+	// Identity
+	unix.SYS_GETPID, unix.SYS_GETPPID, unix.SYS_GETTID, unix.SYS_GETUID,
+	unix.SYS_GETEUID, unix.SYS_GETGID, unix.SYS_GETEGID, unix.SYS_GETGROUPS,
+	unix.SYS_GETRESUID, unix.SYS_GETRESGID, unix.SYS_GETPGID, unix.SYS_GETSID,
+	unix.SYS_SETPGID, unix.SYS_CAPGET,
+
+	// Time and system info
+	unix.SYS_CLOCK_GETTIME, unix.SYS_CLOCK_GETRES, unix.SYS_CLOCK_NANOSLEEP,
+	unix.SYS_NANOSLEEP, unix.SYS_GETTIMEOFDAY, unix.SYS_TIMES, unix.SYS_UNAME,
+	unix.SYS_SYSINFO, unix.SYS_GETRANDOM, unix.SYS_GETITIMER, unix.SYS_SETITIMER,
+
+	// Event loops (libuv, JVM)
+	unix.SYS_EPOLL_CREATE1, unix.SYS_EPOLL_CTL, unix.SYS_EPOLL_PWAIT,
+	unix.SYS_PPOLL, unix.SYS_PSELECT6, unix.SYS_EVENTFD2,
+	unix.SYS_TIMERFD_CREATE, unix.SYS_TIMERFD_SETTIME, unix.SYS_TIMERFD_GETTIME,
+	unix.SYS_SOCKETPAIR,
+}
+
+// seccompCloneNamespaceFlags are the clone flags that would create new
+// namespaces, letting the program remount its view of the filesystem
+const seccompCloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWUTS |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET
+
+// seccompArgOffset is the offset of the low 32 bits of the first syscall
+// argument in struct seccomp_data, on little-endian architectures
+const seccompArgOffset = 16
+
+// installSeccompFilter installs a BPF allowlist on the calling thread. It is
+// inherited across execve, so it must run right before exec'ing the target.
+func installSeccompFilter() error {
+	allowed := append(append([]uintptr{}, seccompAllowedSyscalls...), seccompArchSyscalls...)
+
+	filter := []unix.SockFilter{
+		// Reject syscalls made through a foreign ABI
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, 4),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, seccompAuditArch, 1, 0),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_KILL_PROCESS),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, 0),
+
+		// clone3 passes its flags in memory, out of the filter's reach; libcs
+		// fall back to clone when it is missing
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE3, 0, 1),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS)),
+
+		// clone is allowed for threads and processes, not for namespaces
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE, 0, 4),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompArgOffset),
+		bpfJump(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, seccompCloneNamespaceFlags, 0, 1),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM)),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ALLOW),
+	}
+	for _, nr := range allowed {
+		filter = append(filter,
+			bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(nr), 0, 1),
+			bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ALLOW),
+		)
+	}
+	filter = append(filter, bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM)))
+
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("install filter: %w", err)
+	}
+	return nil
+}
+
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
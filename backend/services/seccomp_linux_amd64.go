@@ -0,0 +1,17 @@
+//go:build linux && amd64
+
+package services
+
+import "golang.org/x/sys/unix"
+
+const seccompAuditArch = unix.AUDIT_ARCH_X86_64
+
+// seccompArchSyscalls are legacy syscalls that only exist on x86-64
+var seccompArchSyscalls = []uintptr{
+	unix.SYS_OPEN, unix.SYS_STAT, unix.SYS_LSTAT, unix.SYS_ACCESS,
+	unix.SYS_READLINK, unix.SYS_GETDENTS, unix.SYS_PIPE, unix.SYS_DUP2,
+	unix.SYS_POLL, unix.SYS_SELECT, unix.SYS_EPOLL_WAIT, unix.SYS_EPOLL_CREATE,
+	unix.SYS_ARCH_PRCTL, unix.SYS_FORK, unix.SYS_VFORK, unix.SYS_UNLINK,
+	unix.SYS_RENAME, unix.SYS_MKDIR, unix.SYS_RMDIR, unix.SYS_CHMOD,
+	unix.SYS_TIME, unix.SYS_ALARM, unix.SYS_GETPGRP, unix.SYS_EVENTFD,
+}
@@ -0,0 +1,10 @@
+//go:build linux && arm64
+
+package services
+
+import "golang.org/x/sys/unix"
+
+const seccompAuditArch = unix.AUDIT_ARCH_AARCH64
+
+// seccompArchSyscalls is empty: arm64 only has the *at family of syscalls
+var seccompArchSyscalls = []uintptr{}
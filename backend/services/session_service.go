@@ -18,6 +18,10 @@ type sessionService struct {
 	statsService      *statsService
 }
 
+// FIRST_PROBLEM_TIMEOUT bounds the generation and verification of the first
+// problem of a session, which CreateSession waits for
+const FIRST_PROBLEM_TIMEOUT = 3 * time.Minute
+
 // NewSessionService creates a new SessionService instance
 func NewSessionService(db *gorm.DB, generationService *generationService, statsService *statsService) *sessionService {
 	return &sessionService{
@@ -85,14 +89,34 @@ func (s *sessionService) CreateSession(ctx context.Context, userID uuid.UUID, pr
 	strategy := s.generationService.getStrategy()
 	log.Printf("Using generation strategy: %s", strategy)
 
-	// Generate first problem synchronously
+	// Placeholders come first, so that progress on the first problem can be
+	// reported while it is generated
+	log.Printf("Creating placeholders for %d problems...", problemCount)
+	for i := 1; i <= problemCount; i++ {
+		placeholder := models.SessionProblem{
+			SessionID:     session.ID,
+			ProblemNumber: i,
+			Status:        models.ProblemStatusGenerating,
+		}
+		if err := s.db.WithContext(ctx).Create(&placeholder).Error; err != nil {
+			log.Printf("ERROR: Failed to create placeholder for problem %d: %v", i, err)
+			return nil, nil, fmt.Errorf("create placeholder: %w", err)
+		}
+	}
+	log.Printf("Placeholders created successfully")
+
+	// Generate first problem synchronously, within a deadline of its own since
+	// the client waits for it
 	log.Printf("Generating first problem (synchronous)...")
-	firstProblem, err := s.generationService.GenerateFirstProblem(ctx, session.ID, contextStr, strategy)
+	generateCtx, cancel := context.WithTimeout(ctx, FIRST_PROBLEM_TIMEOUT)
+	defer cancel()
+	firstProblem, verification, err := s.generationService.GenerateFirstProblem(generateCtx, session.ID, contextStr, strategy)
 	if err != nil {
 		log.Printf("ERROR: Failed to generate first problem: %v", err)
+		s.generationService.markProblemFailed(ctx, session.ID, 1, err.Error())
 		return nil, nil, fmt.Errorf("generate first problem: %w", err)
 	}
-	log.Printf("First problem generated: %s (rating: %d)", firstProblem.Title, firstProblem.Rating)
+	log.Printf("First problem generated: %s (rating: %d, %s)", firstProblem.Title, firstProblem.Rating, verification.Status)
 
 	// Store first problem
 	log.Printf("Storing first problem in database...")
@@ -105,31 +129,19 @@ func (s *sessionService) CreateSession(ctx context.Context, userID uuid.UUID, pr
 	sessionProblem := models.SessionProblem{
 		SessionID:     session.ID,
 		ProblemNumber: 1,
-		Status:        "ready",
+		Status:        verification.Status,
 		ProblemData:   models.ProblemData(problemData),
 	}
+	if verification.Reason != "" {
+		sessionProblem.ErrorMessage = &verification.Reason
+	}
 
-	if err := s.db.WithContext(ctx).Create(&sessionProblem).Error; err != nil {
+	if err := s.generationService.storeSessionProblem(ctx, &sessionProblem); err != nil {
 		log.Printf("ERROR: Failed to store first problem: %v", err)
 		return nil, nil, fmt.Errorf("store first problem: %w", err)
 	}
 	log.Printf("First problem stored with ID: %s", sessionProblem.ID)
 
-	// Create placeholder records for remaining problems
-	log.Printf("Creating placeholders for %d remaining problems...", problemCount-1)
-	for i := 2; i <= problemCount; i++ {
-		placeholder := models.SessionProblem{
-			SessionID:     session.ID,
-			ProblemNumber: i,
-			Status:        "generating",
-		}
-		if err := s.db.WithContext(ctx).Create(&placeholder).Error; err != nil {
-			log.Printf("ERROR: Failed to create placeholder for problem %d: %v", i, err)
-			return nil, nil, fmt.Errorf("create placeholder: %w", err)
-		}
-	}
-	log.Printf("Placeholders created successfully")
-
 	// Start background queue for remaining problems
 	if problemCount > 1 {
 		log.Printf("Starting background queue for %d problems...", problemCount-1)
@@ -201,7 +213,7 @@ func (s *sessionService) GetNextProblem(ctx context.Context, sessionID uuid.UUID
 
 	log.Printf("Next problem status: %s", sessionProblem.Status)
 
-	if sessionProblem.Status != "ready" {
+	if !sessionProblem.IsPlayable() {
 		log.Printf("WARNING: Problem not ready yet")
 		return nil, fmt.Errorf("problem not ready: status=%s", sessionProblem.Status)
 	}
@@ -234,7 +246,7 @@ func (s *sessionService) IsNextProblemReady(ctx context.Context, sessionID uuid.
 		return false, fmt.Errorf("query next problem: %w", err)
 	}
 
-	ready := sessionProblem.Status == "ready"
+	ready := sessionProblem.IsPlayable()
 	log.Printf("Problem #%d status: %s (ready: %v)", nextNumber, sessionProblem.Status, ready)
 	return ready, nil
 }
@@ -326,7 +338,7 @@ func (s *sessionService) ListActiveSessions(ctx context.Context, userID uuid.UUI
 		readyCount := 0
 		var firstReadyProblemID string
 		for _, p := range problems {
-			if p.Status == "ready" {
+			if p.IsPlayable() {
 				readyCount++
 				if firstReadyProblemID == "" {
 					// Extract problem ID from JSONB data
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+const (
+	STRESS_DEFAULT_ITERATIONS  = 100
+	STRESS_MAX_ITERATIONS      = 10000
+	STRESS_DEFAULT_TIME_BUDGET = 10 * time.Second
+	STRESS_MAX_TIME_BUDGET     = 60 * time.Second
+)
+
+// stressRole is one of the three programs of a stress test
+type stressRole struct {
+	name    string
+	program models.StressProgram
+}
+
+// Stress runs the generator, the brute force and the solution in a loop until
+// the outputs of the brute force and the solution differ, the iterations run
+// out or the time budget is spent. The generator gets the iteration number as
+// its seed argument, so a counterexample can be reproduced locally. Programs
+// that fail to build or crash are reported in the response; the error is only
+// set when the stress test could not run.
+func (s *ExecutionService) Stress(ctx context.Context, request models.StressRequest, userID string) (*models.StressResponse, error) {
+	iterations := request.Iterations
+	if iterations <= 0 {
+		iterations = STRESS_DEFAULT_ITERATIONS
+	}
+	iterations = min(iterations, STRESS_MAX_ITERATIONS)
+
+	budget := time.Duration(request.TimeBudgetMs) * time.Millisecond
+	if budget <= 0 {
+		budget = STRESS_DEFAULT_TIME_BUDGET
+	}
+	budget = min(budget, STRESS_MAX_TIME_BUDGET)
+
+	start := time.Now()
+	response := &models.StressResponse{}
+
+	roles := []stressRole{
+		{name: "generator", program: request.Generator},
+		{name: "brute", program: request.Brute},
+		{name: "solution", program: request.Solution},
+	}
+	programs := make([]Program, len(roles))
+	for i, role := range roles {
+		workDir, err := s.sandbox.CreateWorkDir()
+		if err != nil {
+			return nil, err
+		}
+		defer s.sandbox.RemoveWorkDir(workDir)
+
+		prog, failure, err := s.buildStressProgram(ctx, userID, role, workDir)
+		if err != nil {
+			return nil, err
+		}
+		if failure != nil {
+			response.Failure = failure
+			response.ElapsedMs = time.Since(start).Milliseconds()
+			return response, nil
+		}
+		programs[i] = prog
+	}
+	generator, brute, solution := programs[0], programs[1], programs[2]
+
+	opts := ExecutionOptions{
+		UserID:         userID,
+		ComparisonMode: request.ComparisonMode,
+		FloatEpsilon:   request.FloatEpsilon,
+	}
+
+	for seed := 1; seed <= iterations && time.Since(start) < budget; seed++ {
+		response.Iterations = seed
+
+		var generated models.ExecutionResult
+		var generatedOutput *ProgramOutput
+		var err error
+		if poolErr := s.pool.Run(ctx, userID, []func(){func() {
+			generated, generatedOutput, err = s.runTestCase(ctx, opts, generator.withArgs(strconv.Itoa(seed)), models.TestCase{}, seed)
+		}}); poolErr != nil {
+			return nil, poolErr
+		}
+		// The full output of the generator is the input of the other two
+		input, readErr := "", error(nil)
+		if generatedOutput != nil {
+			input, readErr = generatedOutput.ReadAll()
+			generatedOutput.Close()
+		}
+		if err := errors.Join(err, readErr); err != nil {
+			return nil, err
+		}
+		// Runs killed by cancellation are not failures of the programs
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if generated.Verdict != models.VerdictAccepted {
+			response.Failure = &models.StressFailure{
+				Program: "generator",
+				Verdict: generated.Verdict,
+				Error:   generated.Error,
+				Seed:    seed,
+			}
+			break
+		}
+		testCase := models.TestCase{Input: strings.TrimSpace(input) + "\n"}
+
+		// Without an expected output runTestCase only checks that the program
+		// ran, the outputs are compared once both are done
+		var bruteResult, solutionResult models.ExecutionResult
+		var bruteOutput, solutionOutput *ProgramOutput
+		var bruteErr, solutionErr error
+		if poolErr := s.pool.Run(ctx, userID, []func(){
+			func() {
+				bruteResult, bruteOutput, bruteErr = s.runTestCase(ctx, opts, brute, testCase, seed)
+			},
+			func() {
+				solutionResult, solutionOutput, solutionErr = s.runTestCase(ctx, opts, solution, testCase, seed)
+			},
+		}); poolErr != nil {
+			bruteOutput.Close()
+			solutionOutput.Close()
+			return nil, poolErr
+		}
+		verdict, compareErr := stressVerdict(bruteResult, bruteOutput, solutionResult, solutionOutput, opts)
+		bruteOutput.Close()
+		solutionOutput.Close()
+		if err := errors.Join(bruteErr, solutionErr, compareErr); err != nil {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if bruteResult.Verdict != models.VerdictAccepted {
+			response.Failure = &models.StressFailure{
+				Program: "brute",
+				Verdict: bruteResult.Verdict,
+				Error:   bruteResult.Error,
+				Seed:    seed,
+				Input:   testCase.Input,
+			}
+			break
+		}
+
+		if verdict != models.VerdictAccepted {
+			response.Found = true
+			response.Counterexample = &models.StressCounterexample{
+				Iteration:      seed,
+				Seed:           seed,
+				Input:          testCase.Input,
+				BruteOutput:    bruteResult.ActualOutput,
+				SolutionOutput: solutionResult.ActualOutput,
+				Verdict:        verdict,
+				Error:          solutionResult.Error,
+			}
+			break
+		}
+	}
+
+	response.ElapsedMs = time.Since(start).Milliseconds()
+	log.Printf("Stress test finished after %d iterations in %dms (counterexample found: %v)", response.Iterations, response.ElapsedMs, response.Found)
+	return response, nil
+}
+
+// stressVerdict returns the verdict of the solution, which is wrong when both
+// programs ran and their full outputs differ
+func stressVerdict(bruteResult models.ExecutionResult, bruteOutput *ProgramOutput, solutionResult models.ExecutionResult, solutionOutput *ProgramOutput, opts ExecutionOptions) (models.Verdict, error) {
+	if bruteResult.Verdict != models.VerdictAccepted || solutionResult.Verdict != models.VerdictAccepted {
+		return solutionResult.Verdict, nil
+	}
+	expected, err := bruteOutput.ReadAll()
+	if err != nil {
+		return "", err
+	}
+	actual, err := solutionOutput.Reader()
+	if err != nil {
+		return "", err
+	}
+	matches, err := CompareOutputReader(expected, actual, opts.ComparisonMode, opts.FloatEpsilon)
+	if err != nil || matches {
+		return solutionResult.Verdict, err
+	}
+	return models.VerdictWrongAnswer, nil
+}
+
+// buildStressProgram builds one program of a stress test. Compilation errors
+// are returned as a failure rather than as an error.
+func (s *ExecutionService) buildStressProgram(ctx context.Context, userID string, role stressRole, workDir string) (Program, *models.StressFailure, error) {
+	language := role.program.Language
+	if language == "" {
+		language = "cpp"
+	}
+
+	lang, ok := s.languages.Get(language)
+	if !ok {
+		return Program{}, nil, fmt.Errorf("%s: unsupported language: %s", role.name, language)
+	}
+	// Stress tests always run locally
+	if err := lang.Available(); err != nil {
+		return Program{}, nil, fmt.Errorf("%s: %s is not available on this server: %w", role.name, lang.Name(), err)
+	}
+	toolchain, err := lang.ResolveToolchain(role.program.Toolchain)
+	if err != nil {
+		return Program{}, nil, fmt.Errorf("%s: %w", role.name, err)
+	}
+
+	opts := ExecutionOptions{UserID: userID, Toolchain: role.program.Toolchain}
+	prog, err := s.Build(ctx, opts, lang, toolchain, role.program.Code, workDir)
+
+	var compileErr *CompilationError
+	if errors.As(err, &compileErr) {
+		return Program{}, &models.StressFailure{
+			Program:       role.name,
+			Verdict:       models.VerdictCompilationError,
+			CompileOutput: compileErr.Output,
+			Diagnostics:   ParseDiagnostics(compileErr.Output, lang.DiagnosticsFormat()),
+		}, nil
+	}
+	if err != nil {
+		return Program{}, nil, err
+	}
+
+	return prog, nil, nil
+}
+
+// withArgs returns a copy of the program that is called with extra arguments
+func (p Program) withArgs(args ...string) Program {
+	p.command = append(slices.Clone(p.command), args...)
+	return p
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SUBMISSION_LIST_LIMIT caps how many submissions are listed for a problem
+const SUBMISSION_LIST_LIMIT = 100
+
+// ErrSubmissionNotFound is returned when a submission does not exist or belongs to another user
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+// SubmissionSpec identifies the judged code of an execution. ProblemID or
+// SessionProblemID is set when the code was judged on a stored problem.
+type SubmissionSpec struct {
+	UserID           uuid.UUID
+	ProblemID        *uuid.UUID
+	SessionProblemID *uuid.UUID
+	Language         string
+	Code             string
+	Mode             string
+}
+
+// Recorded reports whether the execution is kept in the submission history.
+// Only submissions on stored problems are; runs, debug runs and analyses are not.
+func (spec SubmissionSpec) Recorded() bool {
+	return spec.Mode == "submit" && (spec.ProblemID != nil || spec.SessionProblemID != nil)
+}
+
+// SubmissionService stores judged executions so users can review their attempts
+type SubmissionService struct {
+	db        *gorm.DB
+	artifacts *ArtifactStore
+}
+
+// NewSubmissionService creates a new submission service. The test case files
+// of recorded submissions are kept longer in artifacts.
+func NewSubmissionService(db *gorm.DB, artifacts *ArtifactStore) *SubmissionService {
+	return &SubmissionService{db: db, artifacts: artifacts}
+}
+
+// Record stores the outcome of an execution and sets the response's
+// SubmissionID. Executions that are not recorded return nil.
+func (s *SubmissionService) Record(spec SubmissionSpec, response *models.ExecutionResponse) (*models.Submission, error) {
+	if !spec.Recorded() {
+		return nil, nil
+	}
+	submission := models.Submission{
+		ID:               uuid.New(),
+		UserID:           spec.UserID,
+		ProblemID:        spec.ProblemID,
+		SessionProblemID: spec.SessionProblemID,
+		Language:         spec.Language,
+		Code:             spec.Code,
+		Mode:             spec.Mode,
+		Verdict:          response.Verdict,
+		TotalPassed:      response.TotalPassed,
+		TotalCases:       response.TotalCases,
+		MaxTimeMs:        response.MaxTimeMs,
+		MaxMemoryKB:      response.MaxMemoryKB,
+		Result:           response,
+	}
+	response.SubmissionID = &submission.ID
+
+	if err := s.db.Create(&submission).Error; err != nil {
+		response.SubmissionID = nil
+		return nil, fmt.Errorf("failed to create submission: %w", err)
+	}
+	if response.ArtifactID != nil {
+		if err := s.artifacts.Keep(spec.UserID, *response.ArtifactID); err != nil {
+			log.Printf("Failed to keep the test case files of submission %s: %v", submission.ID, err)
+		}
+	}
+	return &submission, nil
+}
+
+// ListForProblem returns the user's submissions on a problem or session
+// problem, newest first, without their code and per-case results. An empty
+// mode lists submissions of every mode.
+func (s *SubmissionService) ListForProblem(userID uuid.UUID, problemID uuid.UUID, mode string) ([]models.Submission, error) {
+	query := s.db.Omit("code", "result").
+		Where("user_id = ? AND (problem_id = ? OR session_problem_id = ?)", userID, problemID, problemID)
+	if mode != "" {
+		query = query.Where("mode = ?", mode)
+	}
+
+	submissions := []models.Submission{}
+	if err := query.Order("created_at DESC").Limit(SUBMISSION_LIST_LIMIT).Find(&submissions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
+	}
+	return submissions, nil
+}
+
+// Get returns a submission owned by the user
+func (s *SubmissionService) Get(submissionID uuid.UUID, userID uuid.UUID) (*models.Submission, error) {
+	var submission models.Submission
+	err := s.db.Where("id = ? AND user_id = ?", submissionID, userID).First(&submission).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	return &submission, nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/boobachad/simulate-interview/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockSubmissionService returns a submission service on a mock database,
+// with an empty artifact store
+func newMockSubmissionService(t *testing.T) (*SubmissionService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts, err := NewArtifactStore(t.TempDir(), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSubmissionService(gormDB, artifacts), mock
+}
+
+func TestSubmissionRecord(t *testing.T) {
+	problemID := uuid.New()
+
+	tests := []struct {
+		name      string
+		mode      string
+		problemID *uuid.UUID
+		recorded  bool
+	}{
+		{"submit", "submit", &problemID, true},
+		{"run", "run", &problemID, false},
+		{"debug", "debug", &problemID, false},
+		{"analyze", "analyze", &problemID, false},
+		{"submit without stored problem", "submit", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockSubmissionService(t)
+			if tt.recorded {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "submissions"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+				mock.ExpectCommit()
+			}
+
+			spec := SubmissionSpec{UserID: uuid.New(), ProblemID: tt.problemID, Language: "cpp", Code: "int main() {}", Mode: tt.mode}
+			artifacts := s.artifacts.New(spec.UserID)
+			if err := artifacts.StoreCase(1, models.TestCase{Input: "1"}, nil); err != nil {
+				t.Fatal(err)
+			}
+			response := &models.ExecutionResponse{Verdict: models.VerdictAccepted, ArtifactID: &artifacts.ID}
+			submission, err := s.Record(spec, response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (submission != nil) != tt.recorded || (response.SubmissionID != nil) != tt.recorded {
+				t.Errorf("recorded %v with submission id %v, want recorded %v", submission != nil, response.SubmissionID, tt.recorded)
+			}
+			// The test case files of submissions are kept longer
+			if _, err := os.Stat(filepath.Join(artifacts.dir, artifactKeepFile)); (err == nil) != tt.recorded {
+				t.Errorf("artifacts kept: %v, want %v", err == nil, tt.recorded)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSubmissionGetChecksOwner(t *testing.T) {
+	s, mock := newMockSubmissionService(t)
+	submissionID, owner, other := uuid.New(), uuid.New(), uuid.New()
+	query := regexp.QuoteMeta(`SELECT * FROM "submissions" WHERE id = $1 AND user_id = $2 ORDER BY "submissions"."id" LIMIT $3`)
+
+	mock.ExpectQuery(query).WithArgs(submissionID, owner, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "mode"}).AddRow(submissionID, owner, "submit"))
+	submission, err := s.Get(submissionID, owner)
+	if err != nil || submission.ID != submissionID {
+		t.Fatalf("owner: got %+v, %v", submission, err)
+	}
+
+	// The query matches no row for another user
+	mock.ExpectQuery(query).WithArgs(submissionID, other, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if _, err := s.Get(submissionID, other); err != ErrSubmissionNotFound {
+		t.Errorf("other user: got %v, want ErrSubmissionNotFound", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// InvalidInputError is returned when a test case input violates the problem's
+// constraints according to its validator. Like a CompilationError it is the
+// fault of the submitted data rather than of the judge.
+type InvalidInputError struct {
+	CaseNumber int
+	Message    string
+}
+
+func (e *InvalidInputError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("test case %d: input violates the problem constraints", e.CaseNumber)
+	}
+	return fmt.Sprintf("test case %d: input violates the problem constraints: %s", e.CaseNumber, e.Message)
+}
+
+// validateTestCases runs the validator on the input of every test case and
+// returns an InvalidInputError for the first invalid one. Cases are numbered
+// from firstCase+1. The validator reads the input from stdin and exits with
+// code 0 if it is valid; otherwise it prints the reason to stderr.
+func (s *ExecutionService) validateTestCases(ctx context.Context, opts ExecutionOptions, testCases []models.TestCase, firstCase int) error {
+	errs := make([]error, len(testCases))
+	tasks := make([]func(), len(testCases))
+	for i, testCase := range testCases {
+		tasks[i] = func() {
+			errs[i] = s.validateInput(ctx, opts.validatorDir, testCase.Input, firstCase+i+1)
+		}
+	}
+
+	if err := s.pool.Run(ctx, opts.UserID, tasks); err != nil {
+		return err
+	}
+
+	// Report invalid cases in order, before any failure of the validator itself
+	for _, err := range errs {
+		var invalidErr *InvalidInputError
+		if errors.As(err, &invalidErr) {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateInput runs the validator on one input
+func (s *ExecutionService) validateInput(ctx context.Context, validatorDir string, input string, caseNumber int) error {
+	var stdout, stderr bytes.Buffer
+	validateResult, err := s.sandbox.Run(ctx, SandboxCommand{
+		Dir:    validatorDir,
+		Path:   "./validator",
+		Stdin:  strings.NewReader(input),
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Limits: checkerLimits(),
+	})
+	if err != nil {
+		return fmt.Errorf("case %d: failed to run validator: %w", caseNumber, err)
+	}
+	if validateResult.TimedOut {
+		return fmt.Errorf("case %d: validator timeout (%s limit exceeded)", caseNumber, CHECKER_TIMEOUT)
+	}
+	if validateResult.Signal != 0 {
+		return fmt.Errorf("case %d: validator crashed (%s)", caseNumber, validateResult.Signal)
+	}
+	if validateResult.ExitCode == 0 {
+		return nil
+	}
+
+	message := strings.TrimSpace(stderr.String())
+	if message == "" {
+		message = strings.TrimSpace(stdout.String())
+	}
+	return &InvalidInputError{CaseNumber: caseNumber, Message: strings.ToValidUTF8(message, "")}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boobachad/simulate-interview/backend/models"
+)
+
+// rangeValidator accepts a single integer from 1 to 100
+const rangeValidator = `#include <cstdio>
+int main() {
+    int n;
+    if (scanf("%d", &n) != 1) {
+        fprintf(stderr, "expected an integer");
+        return 1;
+    }
+    if (n < 1 || n > 100) {
+        fprintf(stderr, "n = %d is out of range [1, 100]", n);
+        return 1;
+    }
+    return 0;
+}
+`
+
+func TestValidatorRejectsCustomCases(t *testing.T) {
+	s := newTestExecutionService(t)
+	opts := ExecutionOptions{ValidatorCode: rangeValidator}
+	// The code does not compile, so any verdict other than a compilation
+	// error shows that the inputs were checked before the code was built
+	const brokenCode = "int main() { return }"
+
+	testCases := []models.TestCase{{Input: "5"}, {Input: "1000"}, {Input: "x"}}
+	_, err := s.Execute(context.Background(), brokenCode, testCases, "cpp", opts)
+	var invalidErr *InvalidInputError
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("got error %v, want an InvalidInputError", err)
+	}
+	if invalidErr.CaseNumber != 2 || invalidErr.Message != "n = 1000 is out of range [1, 100]" {
+		t.Errorf("got case %d %q, want the first invalid case 2", invalidErr.CaseNumber, invalidErr.Message)
+	}
+
+	// Valid inputs reach the compiler
+	response, err := s.Execute(context.Background(), brokenCode, testCases[:1], "cpp", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Verdict != models.VerdictCompilationError {
+		t.Errorf("got %s, want %s", response.Verdict, models.VerdictCompilationError)
+	}
+}
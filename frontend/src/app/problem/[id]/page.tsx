@@ -13,7 +13,7 @@ import {
 } from "lucide-react";
 import { InterviewSplitLayout } from "@/components/InterviewSplitLayout";
 import { useInterviewStore } from "@/lib/store";
-import { api } from "@/lib/api";
+import { api, isPlayableStatus } from "@/lib/api";
 import { toast } from "sonner";
 import { CodeEditor } from "@/components/CodeEditor";
 import { Skeleton } from "@/components/ui/skeleton";
@@ -26,7 +26,7 @@ import { useProblemTimer } from "@/hooks/useProblemTimer";
 import { useCodePersistence } from "@/hooks/useCodePersistence";
 import { ProblemTimer } from "@/components/ProblemTimer";
 import { getRatingColor, adjustRating } from "@/lib/rating-utils";
-import type { SessionData, SessionID } from "@/lib/api";
+import type { SessionData, SessionID, Toolchain } from "@/lib/api";
 
 export default function ProblemPage() {
   const params = useParams();
@@ -63,6 +63,7 @@ export default function ProblemPage() {
   const { problemTime } = useProblemTimer(problemId, sessionIdParam);
 
   const [language, setLanguage] = useState("cpp");
+  const [toolchain, setToolchain] = useState<Toolchain>({});
   const [isRunning, setIsRunning] = useState(false);
 
   // Code persistence hook
@@ -70,6 +71,7 @@ export default function ProblemPage() {
     problemId,
     language,
     sessionIdParam,
+    currentProblem?.id === problemId ? currentProblem.starter_code : undefined,
   );
 
   const [customTestCases, setCustomTestCases] = useState<
@@ -97,7 +99,7 @@ export default function ProblemPage() {
 
     const MAX_EXTENSIONS = 5;
     const readyCount = sessionData.problems.filter(
-      (p) => p.status === "ready",
+      (p) => isPlayableStatus(p.status),
     ).length;
 
     if (readyCount < sessionData.problem_count) {
@@ -219,7 +221,7 @@ export default function ProblemPage() {
         }
 
         // Stop polling if all problems are ready
-        if (session.problems.every((p) => p.status === "ready")) {
+        if (session.problems.every((p) => isPlayableStatus(p.status))) {
           isActive = false;
           return;
         }
@@ -252,7 +254,7 @@ export default function ProblemPage() {
       return;
 
     const nextProblem = sessionData.problems[currentProblemIndex + 1];
-    if (nextProblem?.problem && nextProblem.status === "ready") {
+    if (nextProblem?.problem && isPlayableStatus(nextProblem.status)) {
       router.push(
         `/problem/${nextProblem.problem.id}?session=${sessionData.id}`,
       );
@@ -299,8 +301,17 @@ export default function ProblemPage() {
         language,
         formattedCustomCases,
         mode,
+        toolchain,
       );
 
+      if (response.verdict === "CE") {
+        setExecutionError(
+          `Compilation Error\n${response.compile_output || ""}`,
+        );
+        toast.error("Compilation Error");
+        return;
+      }
+
       if (response.success || response.results) {
         setExecutionResults(response.results);
 
@@ -462,7 +473,7 @@ export default function ProblemPage() {
                 </Badge>
                 <Badge variant="secondary" className="text-xs">
                   {
-                    sessionData.problems.filter((p) => p.status === "ready")
+                    sessionData.problems.filter((p) => isPlayableStatus(p.status))
                       .length
                   }{" "}
                   ready
@@ -507,8 +518,9 @@ export default function ProblemPage() {
                   onClick={handleNextProblem}
                   disabled={
                     currentProblemIndex >= sessionData.problems.length - 1 ||
-                    sessionData.problems[currentProblemIndex + 1]?.status !==
-                      "ready"
+                    !isPlayableStatus(
+                      sessionData.problems[currentProblemIndex + 1]?.status,
+                    )
                   }
                   className="h-8 w-8 p-0 rounded-md hover:bg-accent disabled:opacity-40"
                 >
@@ -519,7 +531,7 @@ export default function ProblemPage() {
                     size="sm"
                     onClick={handleCompleteSession}
                     disabled={
-                      sessionData.problems.filter((p) => p.status === "ready")
+                      sessionData.problems.filter((p) => isPlayableStatus(p.status))
                         .length < sessionData.problem_count
                     }
                     className="h-8 px-3 text-xs font-semibold ml-1"
@@ -623,6 +635,8 @@ export default function ProblemPage() {
     <CodeEditor
       language={language}
       setLanguage={setLanguage}
+      toolchain={toolchain}
+      setToolchain={setToolchain}
       code={code || ""}
       setCode={saveCode}
       onRun={handleRun}
@@ -10,7 +10,7 @@ import { ClockIcon, Loader2Icon, PlayIcon, ListIcon, SearchIcon, RefreshCwIcon,
 import { InterviewSplitLayout } from "@/components/InterviewSplitLayout";
 import { useInterviewStore } from "@/lib/store";
 import { api } from "@/lib/api";
-import type { FocusSelection, SessionCreateRequest, ActiveSessionSummary } from "@/lib/api";
+import type { FocusSelection, SessionCreateRequest, ActiveSessionSummary, Toolchain } from "@/lib/api";
 import { isSingleTopicMode, isMultipleTopicsMode } from "@/lib/api";
 import { toast } from "sonner";
 import { CodeEditor } from "@/components/CodeEditor";
@@ -39,6 +39,7 @@ export default function StartPage() {
 
   // Editor & Execution State
   const [language, setLanguage] = useState("cpp");
+  const [toolchain, setToolchain] = useState<Toolchain>({});
   const [code, setCode] = useState(BOILERPLATES.cpp);
   const [isRunning, setIsRunning] = useState(false);
   const [executionResults, setExecutionResults] = useState<any[] | null>(null);
@@ -261,7 +262,13 @@ export default function StartPage() {
         expected_output: "",
       }));
 
-      const response = await api.execution.execute(code || "", "playground", language, formattedCustomCases, mode);
+      const response = await api.execution.execute(code || "", "playground", language, formattedCustomCases, mode, toolchain);
+
+      if (response.verdict === "CE") {
+        setExecutionError(`Compilation Error\n${response.compile_output || ""}`);
+        toast.error("Compilation Error");
+        return;
+      }
 
       if (response.success || response.results) {
         setExecutionResults(response.results);
@@ -474,6 +481,8 @@ export default function StartPage() {
     <CodeEditor
       language={language}
       setLanguage={setLanguage}
+      toolchain={toolchain}
+      setToolchain={setToolchain}
       code={code || ""}
       setCode={setCode}
       onRun={handleRun}
@@ -3,7 +3,9 @@
 import { Button } from "@/components/ui/button";
 import { PlayIcon, SendIcon, Loader2Icon } from "lucide-react";
 
-import { useEffect } from "react";
+import { useEffect, useState } from "react";
+import { api } from "@/lib/api";
+import type { LanguageToolchains, Toolchain } from "@/lib/api";
 import {
     Select,
     SelectContent,
@@ -24,6 +26,24 @@ interface CodeEditorProps {
     isRunning?: boolean;
     isSubmitting?: boolean;
     readOnly?: boolean;
+    toolchain?: Toolchain;
+    setToolchain?: (toolchain: Toolchain) => void;
+}
+
+// Toolchains are fixed for the lifetime of the server, so fetch them once
+let toolchainsPromise: Promise<LanguageToolchains[]> | null = null;
+
+function loadToolchains(): Promise<LanguageToolchains[]> {
+    if (!toolchainsPromise) {
+        toolchainsPromise = api.execution
+            .languages()
+            .then((res) => res.languages || [])
+            .catch(() => {
+                toolchainsPromise = null;
+                return [];
+            });
+    }
+    return toolchainsPromise;
 }
 
 export function CodeEditor({
@@ -36,7 +56,29 @@ export function CodeEditor({
     isRunning = false,
     isSubmitting = false,
     readOnly = false,
+    toolchain = {},
+    setToolchain,
 }: CodeEditorProps) {
+    const [toolchains, setToolchains] = useState<LanguageToolchains[]>([]);
+
+    useEffect(() => {
+        if (!setToolchain) return;
+        loadToolchains().then(setToolchains);
+    }, [setToolchain]);
+
+    // Only options with a real choice are shown
+    const toolchainOptions = (toolchains.find((t) => t.id === language)?.options || []).filter(
+        (option) => option.values.length > 1
+    );
+
+    // Languages the server cannot run stay visible but cannot be picked
+    const isUnavailable = (id: string) => toolchains.find((t) => t.id === id)?.available === false;
+
+    const handleLanguageChange = (lang: string) => {
+        setLanguage?.(lang);
+        setToolchain?.({});
+    };
+
     useEffect(() => {
         const handleKeyDown = (e: KeyboardEvent) => {
             if (e.ctrlKey || e.metaKey) {
@@ -59,20 +101,41 @@ export function CodeEditor({
             <div className="border-b p-2 flex items-center justify-between bg-card flex-shrink-0">
                 <div className="flex items-center gap-2">
                     {setLanguage ? (
-                        <Select value={language} onValueChange={setLanguage}>
+                        <Select value={language} onValueChange={handleLanguageChange}>
                             <SelectTrigger className="w-[120px] h-8 text-xs bg-background">
                                 <SelectValue />
                             </SelectTrigger>
                             <SelectContent>
-                                <SelectItem value="cpp">C++</SelectItem>
-                                <SelectItem value="python">Python</SelectItem>
-                                <SelectItem value="java">Java</SelectItem>
-                                <SelectItem value="javascript">JavaScript</SelectItem>
+                                <SelectItem value="cpp" disabled={isUnavailable("cpp")}>C++</SelectItem>
+                                <SelectItem value="python" disabled={isUnavailable("python")}>Python</SelectItem>
+                                <SelectItem value="java" disabled={isUnavailable("java")}>Java</SelectItem>
+                                <SelectItem value="javascript" disabled={isUnavailable("javascript")}>JavaScript</SelectItem>
+                                <SelectItem value="c" disabled={isUnavailable("c")}>C</SelectItem>
+                                <SelectItem value="go" disabled={isUnavailable("go")}>Go</SelectItem>
+                                <SelectItem value="rust" disabled={isUnavailable("rust")}>Rust</SelectItem>
+                                <SelectItem value="kotlin" disabled={isUnavailable("kotlin")}>Kotlin</SelectItem>
+                                <SelectItem value="typescript" disabled={isUnavailable("typescript")}>TypeScript</SelectItem>
                             </SelectContent>
                         </Select>
                     ) : (
                         <span className="text-sm font-medium text-muted-foreground px-2">{language}</span>
                     )}
+                    {setToolchain && toolchainOptions.map((option) => (
+                        <Select
+                            key={option.name}
+                            value={toolchain[option.name] || option.default}
+                            onValueChange={(value) => setToolchain({ ...toolchain, [option.name]: value })}
+                        >
+                            <SelectTrigger className="w-[90px] h-8 text-xs bg-background" title={option.name}>
+                                <SelectValue />
+                            </SelectTrigger>
+                            <SelectContent>
+                                {option.values.map((value) => (
+                                    <SelectItem key={value} value={value}>{value}</SelectItem>
+                                ))}
+                            </SelectContent>
+                        </Select>
+                    ))}
                 </div>
                 <div className="flex gap-2">
                     <Button
@@ -181,6 +181,30 @@ export function TestCasesView({ testCases, results, customTestCases, setCustomTe
                                         )}>
                                             {result.actual_output || result.error || "No output"}
                                         </div>
+                                        {result.checker_message && (
+                                            <div className="text-xs text-muted-foreground font-mono whitespace-pre-wrap">
+                                                Checker: {result.checker_message}
+                                            </div>
+                                        )}
+                                        {result.query_count !== undefined && result.query_count > 0 && (
+                                            <div className="text-xs text-muted-foreground font-mono">
+                                                Queries: {result.query_count}
+                                            </div>
+                                        )}
+                                        {result.sanitizer_findings?.map((finding, i) => (
+                                            <div key={i} className="rounded-md border border-destructive/20 bg-destructive/5 p-3 text-xs font-mono space-y-1">
+                                                <div className="font-semibold text-destructive">
+                                                    {finding.sanitizer}: {finding.message}
+                                                    {finding.file && finding.line ? ` (${finding.file}:${finding.line})` : ""}
+                                                </div>
+                                                {finding.stack?.map((frame, j) => (
+                                                    <div key={j} className="text-muted-foreground pl-2">
+                                                        #{j} {frame.function}
+                                                        {frame.file && frame.line ? ` at ${frame.file}:${frame.line}` : ""}
+                                                    </div>
+                                                ))}
+                                            </div>
+                                        ))}
                                     </div>
                                 )}
                             </TabsContent>
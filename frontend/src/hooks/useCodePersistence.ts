@@ -69,17 +69,21 @@ function safeLocalStorageRemove(key: string): void {
 export function useCodePersistence(
   problemId: string | null,
   language: string,
-  sessionId: string | null = null
+  sessionId: string | null = null,
+  // Replaces the boilerplate of a language, e.g. the function of a function problem
+  starterCode?: Record<string, string>
 ): UseCodePersistenceReturn {
+  const initialCode = starterCode?.[language] || BOILERPLATES[language] || "";
+
   const [code, setCode] = useState<string>(() => {
     if (typeof window === "undefined" || !problemId) {
-      return BOILERPLATES[language] || "";
+      return initialCode;
     }
 
     const key = getStorageKey(sessionId, problemId, language);
     const savedCode = safeLocalStorageGet(key);
 
-    return savedCode || BOILERPLATES[language] || "";
+    return savedCode || initialCode;
   });
 
   const [pendingSave, setPendingSave] = useState<string | null>(null);
@@ -95,9 +99,9 @@ export function useCodePersistence(
     if (savedCode) {
       setCode(savedCode);
     } else {
-      setCode(BOILERPLATES[language] || "");
+      setCode(initialCode);
     }
-  }, [problemId, language, sessionId]);
+  }, [problemId, language, sessionId, initialCode]);
 
   // Debounced save
   useEffect(() => {
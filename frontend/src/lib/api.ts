@@ -106,24 +106,248 @@ export interface Problem {
   rating: number;
   focus_area: string;
   sample_cases: TestCase[];
+  status?: SessionProblemStatus;
+  // Only returned for a single problem
+  limits?: ProblemLimits;
+  // "function" problems call the user's function with one JSON argument per input line
+  problem_type?: "standard" | "interactive" | "function";
+  signature?: FunctionSignature;
+  // Function to start from by language id, for function problems
+  starter_code?: Record<string, string>;
   created_at: string;
 }
 
+// Typed function users implement in function problems
+export interface FunctionSignature {
+  name: string;
+  params: { name: string; type: string }[];
+  return_type: string;
+}
+
+// Per-case limits a problem is judged with
+export interface ProblemLimits {
+  time_limit_ms: number;
+  memory_limit_mb: number;
+  output_limit_mb: number;
+  // Time limit of each language id after its multiplier
+  language_time_limits_ms: Record<string, number>;
+}
+
 // Execution
+// "debug" runs like "run" but builds with sanitizers, where the language supports them
+// "analyze" runs like "submit" and then estimates the time complexity of an accepted solution
+export type ExecutionMode = "run" | "submit" | "debug" | "analyze";
+
+export type Verdict = "AC" | "WA" | "TLE" | "MLE" | "RE" | "CE" | "OLE";
+
 export interface ExecutionResult {
   case_number: number;
   input: string;
   expected_output: string;
   actual_output: string;
   passed: boolean;
+  verdict: Verdict;
   error?: string;
+  checker_message?: string;
+  query_count?: number;
+  sanitizer_findings?: SanitizerFinding[];
+  time_ms: number;
+  cpu_time_ms: number;
+  memory_kb: number;
+  diff?: OutputDiff;
+  // Size of the program's stdout; actual_output only holds its start when truncated
+  output_bytes: number;
+  output_truncated?: boolean;
+}
+
+// Where a wrong answer departs from the expected output. Positions are 1-based.
+export interface OutputDiff {
+  first_mismatch_line: number;
+  first_mismatch_token: number;
+  expected_token: string;
+  actual_token: string;
+  expected_lines: number;
+  actual_lines: number;
+  hunks: DiffHunk[];
+  truncated?: boolean;
+  summary: string;
+}
+
+export interface DiffHunk {
+  expected_start: number;
+  expected_count: number;
+  actual_start: number;
+  actual_count: number;
+  lines: DiffLine[];
+}
+
+export interface DiffLine {
+  // "expected" lines are missing from the output, "actual" lines were not expected
+  kind: "context" | "expected" | "actual";
+  text: string;
+}
+
+export interface StackFrame {
+  function: string;
+  file?: string;
+  line?: number;
+  column?: number;
+}
+
+export interface SanitizerFinding {
+  sanitizer: string;
+  kind: string;
+  message: string;
+  file?: string;
+  line?: number;
+  column?: number;
+  stack?: StackFrame[];
+}
+
+export interface CompileDiagnostic {
+  file: string;
+  line: number;
+  column?: number;
+  severity: "error" | "warning";
+  message: string;
 }
 
 export interface ExecutionResponse {
   success: boolean;
+  verdict: Verdict;
   results: ExecutionResult[];
   total_passed: number;
   total_cases: number;
+  max_time_ms: number;
+  max_memory_kb: number;
+  compile_output?: string;
+  diagnostics?: CompileDiagnostic[];
+  // Set when the execution was recorded as a submission
+  submission_id?: string;
+  // Set in analyze mode
+  complexity?: ComplexityEstimate;
+}
+
+export interface ComplexitySample {
+  n: number;
+  time_ms: number;
+  verdict: Verdict;
+}
+
+export interface ComplexityFit {
+  class: string;
+  r_squared: number;
+}
+
+// Time complexity estimated from running times on inputs of growing size
+export interface ComplexityEstimate {
+  class: string; // e.g. "O(n log n)"; empty when there was too little to fit
+  confidence: number; // 0 to 1
+  samples: ComplexitySample[];
+  fits: ComplexityFit[]; // Best fit first
+  claimed_class?: string;
+  claim_matches?: boolean;
+  note?: string;
+}
+
+// A recorded execution. Lists leave out the code and per-case results.
+export interface Submission {
+  id: string;
+  user_id: string;
+  problem_id?: string;
+  session_problem_id?: string;
+  language: string;
+  code?: string;
+  mode: ExecutionMode;
+  verdict: Verdict;
+  total_passed: number;
+  total_cases: number;
+  max_time_ms: number;
+  max_memory_kb: number;
+  result?: ExecutionResponse;
+  created_at: string;
+}
+
+// Stress testing
+export interface StressProgram {
+  code: string;
+  language: string;
+  toolchain?: Toolchain;
+}
+
+export interface StressRequest {
+  // The generator gets the seed as its only argument and prints an input
+  generator: StressProgram;
+  brute: StressProgram;
+  solution: StressProgram;
+  iterations?: number;
+  time_budget_ms?: number;
+  comparison_mode?: string;
+  float_epsilon?: number;
+}
+
+export interface StressCounterexample {
+  iteration: number;
+  seed: number;
+  input: string;
+  brute_output: string;
+  solution_output: string;
+  verdict: Verdict;
+  error?: string;
+}
+
+export interface StressFailure {
+  program: "generator" | "brute" | "solution";
+  verdict: Verdict;
+  error?: string;
+  seed?: number;
+  input?: string;
+  compile_output?: string;
+  diagnostics?: CompileDiagnostic[];
+}
+
+export interface StressResponse {
+  found: boolean;
+  iterations: number;
+  elapsed_ms: number;
+  counterexample?: StressCounterexample;
+  failure?: StressFailure;
+}
+
+// Toolchain option choices per language, e.g. {"standard": "c++17"}
+export type Toolchain = Record<string, string>;
+
+export interface ToolchainOption {
+  name: string;
+  default: string;
+  values: string[];
+}
+
+export interface LanguageToolchains {
+  id: string;
+  name: string;
+  version: string;
+  options: ToolchainOption[];
+  // False when the server lacks the language's compiler or runtime
+  available: boolean;
+  unavailable_reason?: string;
+  source_file: string;
+  required_snippet?: string;
+}
+
+export type ExecutionJobStatus = "queued" | "compiling" | "running" | "done" | "failed";
+
+export interface ExecutionJob {
+  id: string;
+  problem_id: string;
+  language: string;
+  mode: ExecutionMode;
+  status: ExecutionJobStatus;
+  result?: ExecutionResponse;
+  error?: string;
+  created_at: string;
+  updated_at: string;
+  finished_at?: string;
 }
 
 // Sessions
@@ -148,7 +372,9 @@ export interface SessionCreateResponse {
   first_problem: Problem;
 }
 
-export type SessionProblemStatus = "generating" | "ready" | "failed";
+// "unverified" problems can be solved, but their reference solution disagreed
+// with some expected outputs; error_message says where
+export type SessionProblemStatus = "generating" | "ready" | "unverified" | "failed";
 
 export interface SessionProblem {
   problem_number: number;
@@ -157,10 +383,14 @@ export interface SessionProblem {
   error_message?: string;
 }
 
+export function isPlayableStatus(status?: SessionProblemStatus): boolean {
+  return status === "ready" || status === "unverified";
+}
+
 export function isReady(
   problem: SessionProblem
-): problem is SessionProblem & { status: "ready"; problem: Problem } {
-  return problem.status === "ready" && problem.problem !== undefined;
+): problem is SessionProblem & { status: "ready" | "unverified"; problem: Problem } {
+  return isPlayableStatus(problem.status) && problem.problem !== undefined;
 }
 
 export interface SessionData {
@@ -454,6 +684,37 @@ export const api = {
     getSession: async (id: string): Promise<Problem> => {
       return fetchWithRetry<Problem>(`/api/problems/${id}/session`);
     },
+
+    // The user's submissions on the problem, newest first
+    getSubmissions: async (
+      id: string,
+      mode?: ExecutionMode
+    ): Promise<{ submissions: Submission[] }> => {
+      const params = mode ? `?mode=${mode}` : "";
+      return fetchWithRetry<{ submissions: Submission[] }>(`/api/problems/${id}/submissions${params}`);
+    },
+  },
+
+  submissions: {
+    get: async (id: string): Promise<Submission> => {
+      return fetchWithRetry<Submission>(`/api/submissions/${id}`);
+    },
+
+    // The full input or output of a test case, e.g. to save a large output as a file
+    getCaseFile: async (
+      id: string,
+      caseNumber: number,
+      file: "input" | "expected" | "actual"
+    ): Promise<Blob> => {
+      const token = getAuthToken();
+      const response = await fetch(`${API_BASE_URL}/api/submissions/${id}/cases/${caseNumber}/${file}`, {
+        headers: token ? { Authorization: `Bearer ${token}` } : {},
+      });
+      if (!response.ok) {
+        throw new Error(`Failed to download ${file}: ${response.statusText}`);
+      }
+      return response.blob();
+    },
   },
 
   execution: {
@@ -462,7 +723,9 @@ export const api = {
       problemID: string,
       language: string = "cpp",
       customCases?: TestCase[],
-      mode: "run" | "submit" = "run"
+      mode: ExecutionMode = "run",
+      toolchain?: Toolchain,
+      claimedComplexity?: string
     ): Promise<ExecutionResponse> => {
       return fetchWithRetry<ExecutionResponse>("/api/execute", {
         method: "POST",
@@ -472,9 +735,52 @@ export const api = {
           language,
           custom_cases: customCases || [],
           mode,
+          toolchain,
+          claimed_complexity: claimedComplexity,
         }),
       });
     },
+
+    // Languages with their toolchain options and availability on the server
+    languages: async (): Promise<{ languages: LanguageToolchains[] }> => {
+      return fetchWithRetry<{ languages: LanguageToolchains[] }>("/api/languages");
+    },
+
+    // Starts a background execution; poll it with getJob until done or failed
+    createJob: async (
+      code: string,
+      problemID: string,
+      language: string = "cpp",
+      customCases?: TestCase[],
+      mode: ExecutionMode = "run",
+      toolchain?: Toolchain,
+      claimedComplexity?: string
+    ): Promise<ExecutionJob> => {
+      return fetchWithRetry<ExecutionJob>("/api/executions", {
+        method: "POST",
+        body: JSON.stringify({
+          code,
+          problem_id: problemID,
+          language,
+          custom_cases: customCases || [],
+          mode,
+          toolchain,
+          claimed_complexity: claimedComplexity,
+        }),
+      });
+    },
+
+    getJob: async (jobID: string): Promise<ExecutionJob> => {
+      return fetchWithRetry<ExecutionJob>(`/api/executions/${jobID}`);
+    },
+
+    // Runs the solution against the brute force on generated inputs
+    stress: async (request: StressRequest): Promise<StressResponse> => {
+      return fetchWithRetry<StressResponse>("/api/stress", {
+        method: "POST",
+        body: JSON.stringify(request),
+      });
+    },
   },
 
   sessions: {
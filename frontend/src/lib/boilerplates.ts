@@ -99,9 +99,146 @@ rl.on('line', (line) => {
     }
 });`;
 
+export const C_BOILERPLATE = `#include <stdio.h>
+
+void solve() {
+    // ============================================
+    // START: Write your solution code here
+    // ============================================
+    
+    int a, b;
+    scanf("%d %d", &a, &b);
+    printf("%d\n", a + b);
+    
+    // ============================================
+    // END: Write your solution code above
+    // ============================================
+}
+
+// DO NOT MODIFY BELOW THIS LINE
+int main() {
+    int t;
+    scanf("%d", &t);
+    while (t--) solve();
+    return 0;
+}`;
+
+export const GO_BOILERPLATE = `package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+)
+
+var reader = bufio.NewReader(os.Stdin)
+var writer = bufio.NewWriter(os.Stdout)
+
+func solve() {
+    // ============================================
+    // START: Write your solution code here
+    // ============================================
+    
+    var a, b int
+    fmt.Fscan(reader, &a, &b)
+    fmt.Fprintln(writer, a+b)
+    
+    // ============================================
+    // END: Write your solution code above
+    // ============================================
+}
+
+// DO NOT MODIFY BELOW THIS LINE
+func main() {
+    defer writer.Flush()
+    var t int
+    fmt.Fscan(reader, &t)
+    for ; t > 0; t-- {
+        solve()
+    }
+}`;
+
+export const RUST_BOILERPLATE = `use std::io::{self, Read, Write};
+
+fn solve(tokens: &mut std::str::SplitWhitespace, out: &mut impl Write) {
+    // ============================================
+    // START: Write your solution code here
+    // ============================================
+    
+    let a: i64 = tokens.next().unwrap().parse().unwrap();
+    let b: i64 = tokens.next().unwrap().parse().unwrap();
+    writeln!(out, "{}", a + b).unwrap();
+    
+    // ============================================
+    // END: Write your solution code above
+    // ============================================
+}
+
+// DO NOT MODIFY BELOW THIS LINE
+fn main() {
+    let mut input = String::new();
+    io::stdin().read_to_string(&mut input).unwrap();
+    let mut tokens = input.split_whitespace();
+    let mut out = io::BufWriter::new(io::stdout());
+    let t: usize = tokens.next().unwrap().parse().unwrap();
+    for _ in 0..t {
+        solve(&mut tokens, &mut out);
+    }
+}`;
+
+export const KOTLIN_BOILERPLATE = `import java.io.*
+import java.util.*
+
+fun solve(st: StreamTokenizer) {
+    // ============================================
+    // START: Write your solution code here
+    // ============================================
+    
+    st.nextToken(); val a = st.nval.toLong()
+    st.nextToken(); val b = st.nval.toLong()
+    println(a + b)
+    
+    // ============================================
+    // END: Write your solution code above
+    // ============================================
+}
+
+// DO NOT MODIFY BELOW THIS LINE
+fun main() {
+    val st = StreamTokenizer(BufferedInputStream(System.\`in\`))
+    st.nextToken()
+    val t = st.nval.toInt()
+    repeat(t) { solve(st) }
+}`;
+
+export const TYPESCRIPT_BOILERPLATE = `declare const require: any;
+const fs = require('fs');
+
+const lines: string[] = fs.readFileSync(0, 'utf8').split('\n');
+const t = parseInt(lines[0]);
+let idx = 1;
+
+for (let i = 0; i < t; i++) {
+    // ============================================
+    // START: Write your solution code here
+    // ============================================
+    
+    const [a, b] = lines[idx++].split(' ').map(Number);
+    console.log(a + b);
+    
+    // ============================================
+    // END: Write your solution code above
+    // ============================================
+}`;
+
 export const BOILERPLATES: Record<string, string> = {
   cpp: CPP_BOILERPLATE,
   python: PYTHON_BOILERPLATE,
   java: JAVA_BOILERPLATE,
   javascript: JAVASCRIPT_BOILERPLATE,
+  c: C_BOILERPLATE,
+  go: GO_BOILERPLATE,
+  rust: RUST_BOILERPLATE,
+  kotlin: KOTLIN_BOILERPLATE,
+  typescript: TYPESCRIPT_BOILERPLATE,
 };